@@ -0,0 +1,4 @@
+data "restapi_download" "ca" {
+  path    = "/api/ca/certificate"
+  headers = { Accept = "application/x-pem-file" }
+}
@@ -0,0 +1,11 @@
+data "restapi_exists" "team" {
+  path         = "/api/teams"
+  search_key   = "name"
+  search_value = "platform"
+}
+
+resource "restapi_object" "team" {
+  count = data.restapi_exists.team.exists ? 0 : 1
+  path  = "/api/teams"
+  data  = jsonencode({ name = "platform" })
+}
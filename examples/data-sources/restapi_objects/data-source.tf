@@ -0,0 +1,14 @@
+data "restapi_objects" "production" {
+  path        = "/api/objects"
+  results_key = "items"
+
+  filter {
+    expression = "env"
+    values     = ["production"]
+  }
+
+  extract {
+    name       = "name"
+    expression = "metadata.name"
+  }
+}
@@ -0,0 +1,15 @@
+data "restapi_request" "quota" {
+  path = "/api/quotas"
+
+  query = {
+    tenant = "platform"
+  }
+
+  headers = {
+    Accept = "application/json"
+  }
+}
+
+output "remaining" {
+  value = jsondecode(data.restapi_request.quota.response_body).remaining
+}
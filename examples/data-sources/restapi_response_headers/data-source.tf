@@ -0,0 +1,8 @@
+data "restapi_response_headers" "version" {
+  path  = "/api"
+  names = ["X-API-Version"]
+}
+
+locals {
+  supports_bulk = tonumber(split(".", data.restapi_response_headers.version.response_headers["X-API-Version"])[0]) >= 2
+}
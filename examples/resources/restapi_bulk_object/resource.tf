@@ -0,0 +1,10 @@
+locals {
+  users = ["alice", "bob", "carol"]
+}
+
+resource "restapi_bulk_object" "users" {
+  path = "/api/users"
+  objects = {
+    for name in local.users : name => jsonencode({ id = name, first = name, last = "Example" })
+  }
+}
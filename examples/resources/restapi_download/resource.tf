@@ -0,0 +1,5 @@
+resource "restapi_download" "bundle" {
+  path            = "/api/certificates/${restapi_object.cert.id}/bundle"
+  output_path     = "${path.module}/certs/bundle.pem"
+  file_permission = "0600"
+}
@@ -0,0 +1,8 @@
+resource "restapi_graphql" "user" {
+  query            = "mutation($name: String!) { createUser(name: $name) { user { id } } }"
+  variables        = jsonencode({ name = "Foo" })
+  id_path          = "data/createUser/user/id"
+  read_query       = "query($id: ID!) { user(id: $id) { id name } }"
+  read_result_path = "data/user"
+  delete_query     = "mutation($id: ID!) { deleteUser(id: $id) { id } }"
+}
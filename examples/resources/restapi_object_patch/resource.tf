@@ -0,0 +1,5 @@
+resource "restapi_object_patch" "owner" {
+  path      = "/api/objects"
+  object_id = "55555"
+  data      = jsonencode({ owner = "team-a", labels = { managed_by = "terraform" } })
+}
@@ -0,0 +1,10 @@
+resource "restapi_token" "ci" {
+  path              = "/api/tokens"
+  data              = jsonencode({ name = "ci", scopes = ["deploy"] })
+  token_key         = "token"
+  rotation_interval = 2592000
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
@@ -6,8 +6,10 @@ require (
 	github.com/davecgh/go-spew v1.1.1
 	github.com/hashicorp/terraform-json v0.17.1 // indirect; forced so test cases pass
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.29.0
+	github.com/jmespath/go-jmespath v0.4.0
 	golang.org/x/oauth2 v0.12.0
 	golang.org/x/time v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/hashicorp/terraform-plugin-docs v0.16.0
@@ -12,7 +12,9 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -22,10 +24,14 @@ import (
 
 type apiClientOpt struct {
 	uri                 string
+	failoverURIs        []string
+	failoverStatusCodes []int
+	trustedURIs         []string
 	insecure            bool
 	username            string
 	password            string
 	headers             map[string]string
+	queryParams         map[string]string
 	timeout             int
 	idAttribute         string
 	createMethod        string
@@ -39,6 +45,7 @@ type apiClientOpt struct {
 	createReturnsObject bool
 	xssiPrefix          string
 	useCookies          bool
+	cacheReads          bool
 	rateLimit           float64
 	oauthClientID       string
 	oauthClientSecret   string
@@ -56,10 +63,14 @@ type apiClientOpt struct {
 type APIClient struct {
 	httpClient          *http.Client
 	uri                 string
+	failoverURIs        []string
+	failoverStatusCodes []int
+	trustedURIs         []string
 	insecure            bool
 	username            string
 	password            string
 	headers             map[string]string
+	queryParams         map[string]string
 	idAttribute         string
 	createMethod        string
 	readMethod          string
@@ -74,6 +85,29 @@ type APIClient struct {
 	rateLimiter         *rate.Limiter
 	debug               bool
 	oauthConfig         *clientcredentials.Config
+	cacheReads          bool
+	cacheMutex          sync.Mutex
+	responseCache       map[string]*cachedResponse
+}
+
+/*
+A response to a read of a data source, shared by the identical reads
+
+	of the rest of the run. Reads that arrive while the request is in
+	flight wait for it to be done
+*/
+type cachedResponse struct {
+	done chan struct{}
+	resp *apiClientResponse
+	err  error
+}
+
+/* The parts of an HTTP response that are of interest beyond the body */
+type apiClientResponse struct {
+	body       string
+	statusCode int
+	headers    http.Header
+	url        string
 }
 
 // NewAPIClient makes a new api client for RESTful calls
@@ -96,6 +130,10 @@ func NewAPIClient(opt *apiClientOpt) (*APIClient, error) {
 	if strings.HasSuffix(opt.uri, "/") {
 		opt.uri = opt.uri[:len(opt.uri)-1]
 	}
+	failoverURIs := make([]string, 0, len(opt.failoverURIs))
+	for _, uri := range opt.failoverURIs {
+		failoverURIs = append(failoverURIs, strings.TrimSuffix(uri, "/"))
+	}
 
 	if opt.createMethod == "" {
 		opt.createMethod = "POST"
@@ -155,10 +193,14 @@ func NewAPIClient(opt *apiClientOpt) (*APIClient, error) {
 		},
 		rateLimiter:         rateLimiter,
 		uri:                 opt.uri,
+		failoverURIs:        failoverURIs,
+		failoverStatusCodes: opt.failoverStatusCodes,
+		trustedURIs:         opt.trustedURIs,
 		insecure:            opt.insecure,
 		username:            opt.username,
 		password:            opt.password,
 		headers:             opt.headers,
+		queryParams:         opt.queryParams,
 		idAttribute:         opt.idAttribute,
 		createMethod:        opt.createMethod,
 		readMethod:          opt.readMethod,
@@ -171,6 +213,8 @@ func NewAPIClient(opt *apiClientOpt) (*APIClient, error) {
 		createReturnsObject: opt.createReturnsObject,
 		xssiPrefix:          opt.xssiPrefix,
 		debug:               opt.debug,
+		cacheReads:          opt.cacheReads,
+		responseCache:       make(map[string]*cachedResponse),
 	}
 
 	if opt.oauthClientID != "" && opt.oauthClientSecret != "" && opt.oauthTokenURL != "" {
@@ -194,12 +238,16 @@ func NewAPIClient(opt *apiClientOpt) (*APIClient, error) {
 func (client *APIClient) toString() string {
 	var buffer bytes.Buffer
 	buffer.WriteString(fmt.Sprintf("uri: %s\n", client.uri))
+	buffer.WriteString(fmt.Sprintf("failover_uris: %v\n", client.failoverURIs))
+	buffer.WriteString(fmt.Sprintf("trusted_uris: %v\n", client.trustedURIs))
+	buffer.WriteString(fmt.Sprintf("failover_status_codes: %v\n", client.failoverStatusCodes))
 	buffer.WriteString(fmt.Sprintf("insecure: %t\n", client.insecure))
 	buffer.WriteString(fmt.Sprintf("username: %s\n", client.username))
 	buffer.WriteString(fmt.Sprintf("password: %s\n", client.password))
 	buffer.WriteString(fmt.Sprintf("id_attribute: %s\n", client.idAttribute))
 	buffer.WriteString(fmt.Sprintf("write_returns_object: %t\n", client.writeReturnsObject))
 	buffer.WriteString(fmt.Sprintf("create_returns_object: %t\n", client.createReturnsObject))
+	buffer.WriteString(fmt.Sprintf("cache_data_source_reads: %t\n", client.cacheReads))
 	buffer.WriteString("headers:\n")
 	for k, v := range client.headers {
 		buffer.WriteString(fmt.Sprintf("  %s: %s\n", k, v))
@@ -213,10 +261,153 @@ func (client *APIClient) toString() string {
 /*
 Helper function that handles sending/receiving and handling
 
-	of HTTP data in and out.
+	of HTTP data in and out. If failover_uris are configured, the
+	request is sent to each of them in order until one can be reached
+	and does not answer with one of the failover_status_codes.
 */
 func (client *APIClient) sendRequest(ctx context.Context, method string, path string, data string) (string, error) {
-	fullURI := client.uri + path
+	return client.sendRequestWithHeaders(ctx, method, path, data, nil)
+}
+
+/*
+Same as sendRequest, but the given headers are set on the request
+
+	after the provider-wide headers, so they take precedence over them.
+*/
+func (client *APIClient) sendRequestWithHeaders(ctx context.Context, method string, path string, data string, headers map[string]string) (string, error) {
+	resp, err := client.sendRequestWithResponse(ctx, method, path, data, headers)
+	return resp.body, err
+}
+
+/*
+Same as sendRequestWithHeaders, but returns the status code and
+
+	headers of the response along with the body. The response is
+	never nil, though it is empty if the server could not be reached.
+*/
+func (client *APIClient) sendRequestWithResponse(ctx context.Context, method string, path string, data string, headers map[string]string) (*apiClientResponse, error) {
+	uris := append([]string{client.uri}, client.failoverURIs...)
+	/* An absolute URL is the same no matter the base URI */
+	if isAbsoluteURL(path) {
+		uris = uris[:1]
+	}
+
+	var resp *apiClientResponse
+	var err error
+	for i, uri := range uris {
+		var failover bool
+		resp, failover, err = client.sendRequestTo(ctx, uri, method, path, data, headers)
+		if !failover || i == len(uris)-1 {
+			break
+		}
+		log.Printf("api_client.go: Request to '%s' failed (%v). Failing over to '%s'\n", uri, err, uris[i+1])
+	}
+
+	return resp, err
+}
+
+/*
+Same as sendRequestWithResponse for the reads of the data sources, but
+
+	identical GET and HEAD requests are only sent once per run of the
+	provider if cache_data_source_reads is set, such as when many
+	instances of a module look up the same collection. Failed requests
+	are not kept.
+*/
+func (client *APIClient) sendCachedRequest(ctx context.Context, method string, path string, headers map[string]string) (*apiClientResponse, error) {
+	if !client.cacheReads || (method != "GET" && method != "HEAD") {
+		return client.sendRequestWithResponse(ctx, method, path, "", headers)
+	}
+
+	/* Headers are sorted for a stable key */
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	key := method + " " + path
+	for _, name := range names {
+		key += "\n" + name + ": " + headers[name]
+	}
+
+	client.cacheMutex.Lock()
+	cached, found := client.responseCache[key]
+	if !found {
+		cached = &cachedResponse{done: make(chan struct{})}
+		client.responseCache[key] = cached
+	}
+	client.cacheMutex.Unlock()
+
+	if found {
+		select {
+		case <-cached.done:
+			if client.debug {
+				log.Printf("api_client.go: Using the cached response to %s", key)
+			}
+			return cached.resp, cached.err
+		case <-ctx.Done():
+			return &apiClientResponse{headers: http.Header{}}, ctx.Err()
+		}
+	}
+
+	cached.resp, cached.err = client.sendRequestWithResponse(ctx, method, path, "", headers)
+	if cached.err != nil {
+		client.cacheMutex.Lock()
+		delete(client.responseCache, key)
+		client.cacheMutex.Unlock()
+	}
+	close(cached.done)
+	return cached.resp, cached.err
+}
+
+/*
+Resolves a URL given by the API, such as a Location header, against
+
+	the base URI so that it can be requested no matter if it is relative
+	or absolute.
+*/
+func (client *APIClient) resolveURL(ref string) (string, error) {
+	base, err := url.Parse(client.uri + "/")
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("api_client.go: invalid URL '%s' given by the API: %v", ref, err)
+	}
+	return base.ResolveReference(refURL).String(), nil
+}
+
+func isAbsoluteURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+/*
+Whether a URL is on one of the API servers the provider is configured
+
+	with, by its scheme and host, so the credentials can be sent to it.
+*/
+func (client *APIClient) isTrustedURL(target *url.URL) bool {
+	uris := append([]string{client.uri}, client.failoverURIs...)
+	for _, uri := range append(uris, client.trustedURIs...) {
+		base, err := url.Parse(uri)
+		if err == nil && strings.EqualFold(base.Scheme, target.Scheme) && strings.EqualFold(base.Host, target.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sends the request to a single base URI. The returned bool signals
+// whether a failure should be retried against the next failover URI.
+func (client *APIClient) sendRequestTo(ctx context.Context, baseURI string, method string, path string, data string, headers map[string]string) (*apiClientResponse, bool, error) {
+	result := &apiClientResponse{headers: http.Header{}}
+	fullURI := baseURI + path
+	/* Status URLs of asynchronous operations may point anywhere */
+	absolute := isAbsoluteURL(path)
+	if absolute {
+		fullURI = path
+	}
 	var req *http.Request
 	var err error
 
@@ -239,31 +430,65 @@ func (client *APIClient) sendRequest(ctx context.Context, method string, path st
 
 	if err != nil {
 		log.Fatal(err)
-		return "", err
+		return result, false, err
+	}
+
+	/* Parameters given in the path take precedence. Absolute URLs
+	   given by the API, such as pre-signed links, are left alone */
+	if !absolute {
+		query := req.URL.Query()
+		defaults := url.Values{}
+		for n, v := range client.queryParams {
+			if !query.Has(n) {
+				defaults.Set(n, v)
+			}
+		}
+		if len(defaults) > 0 {
+			if req.URL.RawQuery != "" {
+				req.URL.RawQuery += "&"
+			}
+			req.URL.RawQuery += defaults.Encode()
+		}
 	}
+	result.url = req.URL.String()
 
 	if client.debug {
 		log.Printf("api_client.go: Sending HTTP request to %s...\n", req.URL)
 	}
 
+	/* Credentials are only sent to the configured API servers, and not
+	   to wherever an absolute URL given by the API points */
+	trusted := !absolute || client.isTrustedURL(req.URL)
+	if !trusted {
+		log.Printf("api_client.go: '%s' is not on uri, failover_uris or trusted_uris. Sending the request without credentials.\n", req.URL.Redacted())
+	}
+
 	/* Allow for tokens or other pre-created secrets */
-	if len(client.headers) > 0 {
+	if trusted && len(client.headers) > 0 {
 		for n, v := range client.headers {
+			/* A request without a body has no content type, and
+			   some APIs reject such requests if one is given */
+			if data == "" && http.CanonicalHeaderKey(n) == "Content-Type" {
+				continue
+			}
 			req.Header.Set(n, v)
 		}
 	}
+	for n, v := range headers {
+		req.Header.Set(n, v)
+	}
 
-	if client.oauthConfig != nil {
+	if trusted && client.oauthConfig != nil {
 		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client.httpClient)
 		tokenSource := client.oauthConfig.TokenSource(ctx)
 		token, err := tokenSource.Token()
 		if err != nil {
-			return "", err
+			return result, false, err
 		}
 		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	}
 
-	if client.username != "" && client.password != "" {
+	if trusted && client.username != "" && client.password != "" {
 		/* ... and fall back to basic auth if configured */
 		req.SetBasicAuth(client.username, client.password)
 	}
@@ -296,8 +521,10 @@ func (client *APIClient) sendRequest(ctx context.Context, method string, path st
 
 	if err != nil {
 		//log.Printf("api_client.go: Error detected: %s\n", err)
-		return "", err
+		return result, true, err
 	}
+	result.statusCode = resp.StatusCode
+	result.headers = resp.Header
 
 	if client.debug {
 		log.Printf("api_client.go: Response code: %d\n", resp.StatusCode)
@@ -313,17 +540,18 @@ func (client *APIClient) sendRequest(ctx context.Context, method string, path st
 	resp.Body.Close()
 
 	if err2 != nil {
-		return "", err2
+		return result, false, err2
 	}
 	body := strings.TrimPrefix(string(bodyBytes), client.xssiPrefix)
+	result.body = body
 	if client.debug {
 		log.Printf("api_client.go: BODY:\n%s\n", body)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return body, fmt.Errorf("unexpected response code '%d': %s", resp.StatusCode, body)
+		return result, containsInt(client.failoverStatusCodes, resp.StatusCode), fmt.Errorf("unexpected response code '%d': %s", resp.StatusCode, body)
 	}
 
-	return body, nil
+	return result, false, nil
 
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 )
@@ -93,6 +95,49 @@ func TestAPIClient(t *testing.T) {
 	}
 }
 
+func TestAPIClientFailover(t *testing.T) {
+	ctx := context.Background()
+	setupAPIClientServer()
+	defer shutdownAPIClientServer()
+
+	/* Nothing listens on 8086, so the request must fail over to 8083 */
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:          "http://127.0.0.1:8086",
+		failoverURIs: []string{"http://127.0.0.1:8083/"},
+		headers:      make(map[string]string),
+		timeout:      2,
+		rateLimit:    10,
+	})
+	res, err := client.sendRequest(ctx, "GET", "/ok", "")
+	if err != nil {
+		t.Fatalf("client_test.go: failover on connection error did not happen: %s", err)
+	}
+	if res != "It works!" {
+		t.Fatalf("client_test.go: Got back '%s' but expected 'It works!'\n", res)
+	}
+
+	/* A 503 from the primary only fails over if asked to */
+	client, _ = NewAPIClient(&apiClientOpt{
+		uri:          "http://127.0.0.1:8083/down",
+		failoverURIs: []string{"http://127.0.0.1:8083"},
+		headers:      make(map[string]string),
+		timeout:      2,
+		rateLimit:    10,
+	})
+	if _, err = client.sendRequest(ctx, "GET", "/ok", ""); err == nil {
+		t.Fatalf("client_test.go: expected a 503 without failover_status_codes")
+	}
+
+	client.failoverStatusCodes = []int{503}
+	res, err = client.sendRequest(ctx, "GET", "/ok", "")
+	if err != nil {
+		t.Fatalf("client_test.go: failover on status code did not happen: %s", err)
+	}
+	if res != "It works!" {
+		t.Fatalf("client_test.go: Got back '%s' but expected 'It works!'\n", res)
+	}
+}
+
 func setupAPIClientServer() {
 	serverMux := http.NewServeMux()
 	serverMux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
@@ -102,6 +147,9 @@ func setupAPIClientServer() {
 		time.Sleep(9999 * time.Second)
 		w.Write([]byte("This will never return!!!!!"))
 	})
+	serverMux.HandleFunc("/down/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	})
 	serverMux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/ok", http.StatusPermanentRedirect)
 	})
@@ -118,3 +166,155 @@ func setupAPIClientServer() {
 func shutdownAPIClientServer() {
 	apiClientServer.Close()
 }
+
+/* Starts a server for the test on a free port, which is closed when the test is done */
+func newTestServer(t *testing.T, handler http.Handler) *httptest.Server {
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+	return svr
+}
+
+func TestSendCachedRequest(t *testing.T) {
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	requests := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		/* Keep the request in flight while the others arrive */
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`[]`))
+	})
+	serverMux.HandleFunc("/api/broken", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:        svr.URL,
+		headers:    make(map[string]string),
+		timeout:    2,
+		rateLimit:  10,
+		cacheReads: true,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := client.sendCachedRequest(ctx, "GET", "/api/objects", nil); err != nil || resp.body != "[]" {
+				t.Errorf("api_client_test.go: Unexpected cached response %v: %v", resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if requests != 1 {
+		t.Fatalf("api_client_test.go: Expected identical reads to send 1 request but they sent %d", requests)
+	}
+
+	client.sendCachedRequest(ctx, "GET", "/api/objects", map[string]string{"X-Tenant": "a"})
+	client.sendCachedRequest(ctx, "GET", "/api/broken", nil)
+	client.sendCachedRequest(ctx, "GET", "/api/broken", nil)
+	if requests != 4 {
+		t.Fatalf("api_client_test.go: Expected other headers and failed reads not to be cached, but %d requests were sent", requests)
+	}
+
+	client.cacheReads = false
+	client.sendCachedRequest(ctx, "GET", "/api/objects", nil)
+	if requests != 5 {
+		t.Fatalf("api_client_test.go: Expected reads not to be cached without cache_data_source_reads, but %d requests were sent", requests)
+	}
+}
+
+func TestAPIClientDefaultQueryParams(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		queryParams: map[string]string{"api-version": "2024-01-01", "tenant": "a"},
+		timeout:     2,
+		rateLimit:   10,
+	})
+
+	for path, expected := range map[string]string{
+		"/api/objects":                   "api-version=2024-01-01&tenant=a",
+		"/api/objects?tenant=b&search=x": "tenant=b&search=x&api-version=2024-01-01",
+		svr.URL + "/api/objects?page":    "page",
+	} {
+		if query, err := client.sendRequest(ctx, "GET", path, ""); err != nil || query != expected {
+			t.Fatalf("api_client_test.go: Expected the query of '%s' to be '%s' but got '%s': %v", path, expected, query, err)
+		}
+	}
+}
+
+func TestAPIClientAbsoluteURLCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	requests := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key")))
+	})
+	serverMux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	other := newTestServer(t, serverMux)
+	api := newTestServer(t, serverMux)
+
+	opt := &apiClientOpt{
+		uri:                 api.URL,
+		failoverURIs:        []string{api.URL, api.URL},
+		failoverStatusCodes: []int{http.StatusServiceUnavailable},
+		username:            "user",
+		password:            "secret",
+		headers:             map[string]string{"X-Api-Key": "key"},
+		timeout:             2,
+		rateLimit:           10,
+	}
+	client, _ := NewAPIClient(opt)
+
+	/* Relative paths and absolute URLs on the API server carry the credentials */
+	for _, path := range []string{"/status", api.URL + "/status"} {
+		if res, err := client.sendRequest(ctx, "GET", path, ""); err != nil || res != "Basic dXNlcjpzZWNyZXQ=|key" {
+			t.Fatalf("api_client_test.go: Expected the credentials to be sent to '%s' but got '%s': %v", path, res, err)
+		}
+	}
+
+	if res, err := client.sendRequest(ctx, "GET", other.URL+"/status", ""); err != nil || res != "|" {
+		t.Fatalf("api_client_test.go: Expected no credentials to be sent to another host but got '%s': %v", res, err)
+	}
+
+	/* An absolute URL is not sent again for every failover URI */
+	requests = 0
+	client.sendRequest(ctx, "GET", other.URL+"/down", "")
+	if requests != 1 {
+		t.Fatalf("api_client_test.go: Expected an absolute URL to be requested once but it was requested %d times", requests)
+	}
+
+	opt.trustedURIs = []string{other.URL}
+	client, _ = NewAPIClient(opt)
+	if res, err := client.sendRequest(ctx, "GET", other.URL+"/status", ""); err != nil || res != "Basic dXNlcjpzZWNyZXQ=|key" {
+		t.Fatalf("api_client_test.go: Expected the credentials to be sent to a host in trusted_uris but got '%s': %v", res, err)
+	}
+}
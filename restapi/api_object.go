@@ -3,68 +3,297 @@ package restapi
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 )
 
+/* A file part sent along with data when body_format is multipart */
+type multipartFile struct {
+	name        string
+	path        string
+	content     string
+	filename    string
+	contentType string
+}
+
+/* How to wait for an asynchronous operation to finish, as set by a *_poll block */
+type pollOpts struct {
+	urlHeader     string
+	urlField      string
+	statusField   string
+	statusExpr    string
+	successValues []string
+	failureValues []string
+	interval      time.Duration
+	timeout       time.Duration
+}
+
+/* How to find errors in successful responses, as set by an error_expression block */
+type errorExpressionOpts struct {
+	expression        string
+	errorValues       []string
+	successValues     []string
+	messageExpression string
+}
+
+/* A friendlier error for a status code, as set by an error_message block */
+type errorMessage struct {
+	message string
+	hint    string
+}
+
+/* The state to wait for the object to reach, as set by a wait_for block */
+type waitForOpts struct {
+	field      string
+	expression string
+	value      string
+	valueRegex *regexp.Regexp
+	interval   time.Duration
+	timeout    time.Duration
+}
+
+/* A request sent around the requests for the object, as set by a post_create_request or hook block */
+type extraRequest struct {
+	operation     string
+	when          string
+	method        string
+	path          string
+	data          string
+	successField  string
+	successExpr   string
+	successValues []string
+}
+
+/* How to walk the pages of a collection, as set by a pagination block */
+type paginationOpts struct {
+	mode        string
+	pageParam   string
+	firstPage   int
+	limitParam  string
+	limit       int
+	cursorField string
+	cursorParam string
+	maxPages    int
+}
+
+/* A key and the value it must hold for a search result to match, as set by a search_criteria block */
+type searchCriterion struct {
+	key   string
+	value string
+	match string
+}
+
+/* A check that must pass before the object is destroyed, as set by a destroy_precondition block */
+type destroyPrecondition struct {
+	request extraRequest
+	message string
+}
+
 type apiObjectOpts struct {
-	path               string
-	getPath            string
-	postPath           string
-	putPath            string
-	createMethod       string
-	readMethod         string
-	updateMethod       string
-	updateData         string
-	destroyMethod      string
-	destroyData        string
-	deletePath         string
-	searchPath         string
-	queryString        string
-	readQueryString    string
-	createQueryString  string
-	updateQueryString  string
-	destroyQueryString string
-	debug              bool
-	readSearch         map[string]string
-	id                 string
-	idAttribute        string
-	data               string
+	path                string
+	getPath             string
+	postPath            string
+	putPath             string
+	createMethod        string
+	readMethod          string
+	updateMethod        string
+	updateData          string
+	destroyMethod       string
+	destroyData         string
+	deletePath          string
+	searchPath          string
+	pagination          *paginationOpts
+	cacheReads          bool
+	queryString         string
+	readQueryString     string
+	createQueryString   string
+	updateQueryString   string
+	destroyQueryString  string
+	debug               bool
+	readSearch          map[string]string
+	id                  string
+	idAttribute         string
+	idExpression        string
+	idTemplate          string
+	escapePathValues    bool
+	extract             map[string]string
+	responseEnvelopeKey string
+	responseTransform   string
+	responseSchema      string
+	captureHeaders      []string
+	responseHeaders     map[string]string
+	data                string
+	dataFormat          string
+	bodyFormat          string
+	multipartFiles      []multipartFile
+	dataFile            string
+	dataFileContentType string
+	ndjsonData          []string
+	updateStrategy      string
+	listMergeKeys       map[string]string
+	nullHandling        string
+	nullSentinel        string
+	previousData        string
+	useIfMatch          bool
+	versionHeader       string
+	versionField        string
+	version             string
+	createIfNoneMatch   bool
+	createConflict      string
+	createSuccessCodes  []int
+	readSuccessCodes    []int
+	updateSuccessCodes  []int
+	destroySuccessCodes []int
+	replaceStatusCodes  []int
+	notFoundCodes       []int
+	createPoll          *pollOpts
+	updatePoll          *pollOpts
+	destroyPoll         *pollOpts
+	followLocation      bool
+	idHeader            string
+	idHeaderRegex       string
+	locationIDRegex     string
+	location            string
+	createStrategy      string
+	destroySuccessField string
+	destroySuccessVals  []string
+	createReturnsObject *bool
+	writeReturnsObject  *bool
+	readAfterWrite      bool
+	keepCreateResponse  bool
+	createReadTimeout   time.Duration
+	readFailureBehavior string
+	readRetries         int
+	waitForDeletion     time.Duration
+	waitFor             *waitForOpts
+	errorExpression     *errorExpressionOpts
+	errorMessages       map[int]errorMessage
+	errorDetailsExpr    string
+	copyKeys            []string
+	postCreateRequests  []extraRequest
+	hooks               []extraRequest
+	destroyPrecondition *destroyPrecondition
 }
 
 /*APIObject is the state holding struct for a restapi_object resource*/
 type APIObject struct {
-	apiClient          *APIClient
-	getPath            string
-	postPath           string
-	putPath            string
-	createMethod       string
-	readMethod         string
-	updateMethod       string
-	destroyMethod      string
-	deletePath         string
-	searchPath         string
-	queryString        string
-	readQueryString    string
-	createQueryString  string
-	updateQueryString  string
-	destroyQueryString string
-	debug              bool
-	readSearch         map[string]string
-	id                 string
-	idAttribute        string
+	apiClient           *APIClient
+	getPath             string
+	postPath            string
+	putPath             string
+	createMethod        string
+	readMethod          string
+	updateMethod        string
+	destroyMethod       string
+	deletePath          string
+	searchPath          string
+	pagination          *paginationOpts
+	cacheReads          bool
+	queryString         string
+	readQueryString     string
+	createQueryString   string
+	updateQueryString   string
+	destroyQueryString  string
+	debug               bool
+	readSearch          map[string]string
+	id                  string
+	idAttribute         string
+	idExpression        string
+	idTemplate          string
+	escapePathValues    bool
+	extract             map[string]string
+	responseEnvelopeKey string
+	responseTransform   string
+	responseSchema      map[string]interface{}
+	captureHeaders      []string
+	dataFormat          string
+	bodyFormat          string
+	multipartFiles      []multipartFile
+	dataFile            string
+	dataFileContentType string
+	ndjsonData          []string
+	updateStrategy      string
+	listMergeKeys       map[string]string
+	nullHandling        string
+	nullSentinel        interface{}
+	useIfMatch          bool
+	versionHeader       string
+	versionField        string
+	createIfNoneMatch   bool
+	createConflict      string
+	createSuccessCodes  []int
+	readSuccessCodes    []int
+	updateSuccessCodes  []int
+	destroySuccessCodes []int
+	replaceStatusCodes  []int
+	notFoundCodes       []int
+	createPoll          *pollOpts
+	updatePoll          *pollOpts
+	destroyPoll         *pollOpts
+	followLocation      bool
+	idHeader            string
+	idHeaderRegex       *regexp.Regexp
+	locationIDRegex     *regexp.Regexp
+	createStrategy      string
+	destroySuccessField string
+	destroySuccessVals  []string
+	createReturnsObject bool
+	writeReturnsObject  bool
+	readAfterWrite      bool
+	keepCreateResponse  bool
+	createReadTimeout   time.Duration
+	readFailureBehavior string
+	readRetries         int
+	waitForDeletion     time.Duration
+	waitFor             *waitForOpts
+	errorExpression     *errorExpressionOpts
+	errorMessages       map[int]errorMessage
+	errorDetailsExpr    string
+	copyKeys            []string
+	postCreateRequests  []extraRequest
+	hooks               []extraRequest
+	destroyPrecondition *destroyPrecondition
 
 	/* Set internally */
-	data        map[string]interface{} /* Data as managed by the user */
-	updateData  map[string]interface{} /* Update data as managed by the user */
-	destroyData map[string]interface{} /* Destroy data as managed by the user */
-	apiData     map[string]interface{} /* Data as available from the API */
-	apiResponse string
+	data             map[string]interface{} /* Data as managed by the user */
+	updateData       map[string]interface{} /* Update data as managed by the user */
+	destroyData      map[string]interface{} /* Destroy data as managed by the user */
+	apiData          map[string]interface{} /* Data as available from the API */
+	previousData     map[string]interface{} /* Data as last applied, used to compute patches */
+	emptyData        bool                   /* No data was provided, so no body is sent */
+	dataValue        interface{}            /* Data as managed by the user if it is not a JSON object */
+	updateDataValue  interface{}            /* Update data as managed by the user if it is not a JSON object */
+	destroyDataValue interface{}            /* Destroy data as managed by the user if it is not a JSON object */
+	apiDataValue     interface{}            /* Data as available from the API if it is not a JSON object */
+	apiResponse      string
+	createResponse   string            /* Body of the response to the create request */
+	dataFileSHA256   string            /* Checksum of the data_file content last sent */
+	version          string            /* ETag or version of the object as last seen, sent as If-Match */
+	location         string            /* URL of the object from the Location header of the create response */
+	responseHeaders  map[string]string /* Values of capture_response_headers as last seen */
+	lastOperation    string            /* Operation of the last request for the object */
+	lastRequestURL   string            /* URL of the last request for the object */
+	lastStatusCode   int               /* Status code of the response to the last request for the object */
+	readFromLocation bool              /* Whether the object is read from location as read_path is not set */
+	created          bool              /* Whether the create request succeeded, even if what followed failed */
 }
 
 // NewAPIObject makes an APIobject to manage a RESTful object in an API
@@ -82,12 +311,43 @@ func NewAPIObject(iClient *APIClient, opts *apiObjectOpts) (*APIObject, error) {
 		opts.idAttribute = iClient.idAttribute
 	}
 
+	/* An upsert creates the object where it is updated */
+	if opts.createStrategy == "upsert" {
+		if opts.createMethod == "" {
+			opts.createMethod = "PUT"
+		}
+		if opts.postPath == "" {
+			opts.postPath = opts.putPath
+		}
+		if opts.postPath == "" {
+			opts.postPath = opts.path + "/{id}"
+		}
+	}
+	if opts.createStrategy == "" {
+		opts.createStrategy = "post"
+	}
 	if opts.createMethod == "" {
 		opts.createMethod = iClient.createMethod
 	}
 	if opts.readMethod == "" {
 		opts.readMethod = iClient.readMethod
 	}
+	createReturnsObject := iClient.createReturnsObject
+	if opts.createReturnsObject != nil {
+		createReturnsObject = *opts.createReturnsObject
+	}
+	writeReturnsObject := iClient.writeReturnsObject
+	if opts.writeReturnsObject != nil {
+		writeReturnsObject = *opts.writeReturnsObject
+	}
+	if opts.copyKeys == nil {
+		opts.copyKeys = iClient.copyKeys
+	}
+	/* A patch is meaningless to PUT, so default to PATCH
+	   unless the method is set for this object */
+	if (opts.updateStrategy == "merge_patch" || opts.updateStrategy == "json_patch") && opts.updateMethod == "" {
+		opts.updateMethod = "PATCH"
+	}
 	if opts.updateMethod == "" {
 		opts.updateMethod = iClient.updateMethod
 	}
@@ -103,6 +363,10 @@ func NewAPIObject(iClient *APIClient, opts *apiObjectOpts) (*APIObject, error) {
 	if opts.postPath == "" {
 		opts.postPath = opts.path
 	}
+	readFromLocation := opts.followLocation && opts.getPath == ""
+	if readFromLocation && opts.location != "" {
+		opts.getPath = opts.location
+	}
 	if opts.getPath == "" {
 		opts.getPath = opts.path + "/{id}"
 	}
@@ -115,39 +379,169 @@ func NewAPIObject(iClient *APIClient, opts *apiObjectOpts) (*APIObject, error) {
 	if opts.searchPath == "" {
 		opts.searchPath = opts.path
 	}
+	if opts.bodyFormat == "" {
+		opts.bodyFormat = "json"
+	}
+	if opts.dataFormat == "" {
+		opts.dataFormat = "json"
+	}
+	if opts.updateStrategy == "" {
+		opts.updateStrategy = "replace"
+	}
+	if opts.versionHeader == "" {
+		opts.versionHeader = "ETag"
+	}
+	if len(opts.notFoundCodes) == 0 {
+		opts.notFoundCodes = []int{404}
+	}
+
+	/* YAML input is converted to JSON up front so that everything
+	   else can keep working on JSON */
+	if opts.dataFormat == "yaml" {
+		for name, v := range map[string]*string{"data": &opts.data, "update data": &opts.updateData, "destroy data": &opts.destroyData, "previous data": &opts.previousData} {
+			if *v == "" {
+				continue
+			}
+			converted, err := yamlToJSON(*v)
+			if err != nil {
+				return nil, fmt.Errorf("api_object.go: error parsing %s provided as YAML: %v", name, err)
+			}
+			*v = converted
+		}
+	}
+
+	var locationIDRegex *regexp.Regexp
+	if opts.locationIDRegex != "" {
+		var err error
+		if locationIDRegex, err = regexp.Compile(opts.locationIDRegex); err != nil {
+			return nil, fmt.Errorf("api_object.go: error parsing location_id_regex: %v", err)
+		}
+	}
+
+	var responseSchema map[string]interface{}
+	if opts.responseSchema != "" {
+		var err error
+		if responseSchema, err = parseJSONSchema(opts.responseSchema); err != nil {
+			return nil, fmt.Errorf("api_object.go: error parsing response_schema: %v", err)
+		}
+	}
+
+	var nullSentinel interface{}
+	if opts.nullSentinel != "" {
+		if err := json.Unmarshal([]byte(opts.nullSentinel), &nullSentinel); err != nil {
+			return nil, fmt.Errorf("api_object.go: error parsing null_sentinel as JSON: %v", err)
+		}
+	}
+
+	var idHeaderRegex *regexp.Regexp
+	if opts.idHeaderRegex != "" {
+		var err error
+		if idHeaderRegex, err = regexp.Compile(opts.idHeaderRegex); err != nil {
+			return nil, fmt.Errorf("api_object.go: error parsing id_header_regex: %v", err)
+		}
+	}
 
 	obj := APIObject{
-		apiClient:          iClient,
-		getPath:            opts.getPath,
-		postPath:           opts.postPath,
-		putPath:            opts.putPath,
-		createMethod:       opts.createMethod,
-		readMethod:         opts.readMethod,
-		updateMethod:       opts.updateMethod,
-		destroyMethod:      opts.destroyMethod,
-		deletePath:         opts.deletePath,
-		searchPath:         opts.searchPath,
-		queryString:        opts.queryString,
-		readQueryString:    opts.readQueryString,
-		createQueryString:  opts.createQueryString,
-		updateQueryString:  opts.updateQueryString,
-		destroyQueryString: opts.destroyQueryString,
-		debug:              opts.debug,
-		readSearch:         opts.readSearch,
-		id:                 opts.id,
-		idAttribute:        opts.idAttribute,
-		data:               make(map[string]interface{}),
-		updateData:         make(map[string]interface{}),
-		destroyData:        make(map[string]interface{}),
-		apiData:            make(map[string]interface{}),
-	}
-
-	if opts.data != "" {
+		apiClient:           iClient,
+		getPath:             opts.getPath,
+		postPath:            opts.postPath,
+		putPath:             opts.putPath,
+		createMethod:        opts.createMethod,
+		readMethod:          opts.readMethod,
+		updateMethod:        opts.updateMethod,
+		destroyMethod:       opts.destroyMethod,
+		deletePath:          opts.deletePath,
+		searchPath:          opts.searchPath,
+		pagination:          opts.pagination,
+		cacheReads:          opts.cacheReads,
+		queryString:         opts.queryString,
+		readQueryString:     opts.readQueryString,
+		createQueryString:   opts.createQueryString,
+		updateQueryString:   opts.updateQueryString,
+		destroyQueryString:  opts.destroyQueryString,
+		debug:               opts.debug,
+		readSearch:          opts.readSearch,
+		id:                  opts.id,
+		idAttribute:         opts.idAttribute,
+		idExpression:        opts.idExpression,
+		idTemplate:          opts.idTemplate,
+		escapePathValues:    opts.escapePathValues,
+		extract:             opts.extract,
+		responseEnvelopeKey: opts.responseEnvelopeKey,
+		responseTransform:   opts.responseTransform,
+		responseSchema:      responseSchema,
+		captureHeaders:      opts.captureHeaders,
+		responseHeaders:     make(map[string]string),
+		dataFormat:          opts.dataFormat,
+		bodyFormat:          opts.bodyFormat,
+		multipartFiles:      opts.multipartFiles,
+		dataFile:            opts.dataFile,
+		dataFileContentType: opts.dataFileContentType,
+		ndjsonData:          opts.ndjsonData,
+		updateStrategy:      opts.updateStrategy,
+		listMergeKeys:       opts.listMergeKeys,
+		nullHandling:        opts.nullHandling,
+		nullSentinel:        nullSentinel,
+		useIfMatch:          opts.useIfMatch,
+		versionHeader:       opts.versionHeader,
+		versionField:        opts.versionField,
+		version:             opts.version,
+		createIfNoneMatch:   opts.createIfNoneMatch,
+		createConflict:      opts.createConflict,
+		createSuccessCodes:  opts.createSuccessCodes,
+		readSuccessCodes:    opts.readSuccessCodes,
+		updateSuccessCodes:  opts.updateSuccessCodes,
+		replaceStatusCodes:  opts.replaceStatusCodes,
+		destroySuccessCodes: opts.destroySuccessCodes,
+		notFoundCodes:       opts.notFoundCodes,
+		createPoll:          opts.createPoll,
+		updatePoll:          opts.updatePoll,
+		destroyPoll:         opts.destroyPoll,
+		followLocation:      opts.followLocation,
+		idHeader:            opts.idHeader,
+		idHeaderRegex:       idHeaderRegex,
+		locationIDRegex:     locationIDRegex,
+		location:            opts.location,
+		readFromLocation:    readFromLocation,
+		createStrategy:      opts.createStrategy,
+		destroySuccessField: opts.destroySuccessField,
+		destroySuccessVals:  opts.destroySuccessVals,
+		createReturnsObject: createReturnsObject,
+		writeReturnsObject:  writeReturnsObject,
+		copyKeys:            opts.copyKeys,
+		postCreateRequests:  opts.postCreateRequests,
+		hooks:               opts.hooks,
+		destroyPrecondition: opts.destroyPrecondition,
+		readAfterWrite:      opts.readAfterWrite,
+		keepCreateResponse:  opts.keepCreateResponse,
+		createReadTimeout:   opts.createReadTimeout,
+		readFailureBehavior: opts.readFailureBehavior,
+		readRetries:         opts.readRetries,
+		waitForDeletion:     opts.waitForDeletion,
+		waitFor:             opts.waitFor,
+		errorExpression:     opts.errorExpression,
+		errorMessages:       opts.errorMessages,
+		errorDetailsExpr:    opts.errorDetailsExpr,
+		data:                make(map[string]interface{}),
+		updateData:          make(map[string]interface{}),
+		destroyData:         make(map[string]interface{}),
+		apiData:             make(map[string]interface{}),
+		previousData:        make(map[string]interface{}),
+	}
+
+	for k, v := range opts.responseHeaders {
+		obj.responseHeaders[k] = v
+	}
+
+	if opts.data == "" {
+		obj.emptyData = true
+	} else {
 		if opts.debug {
 			log.Printf("api_object.go: Parsing data: '%s'", opts.data)
 		}
 
-		err := json.Unmarshal([]byte(opts.data), &obj.data)
+		var err error
+		obj.dataValue, err = parseDataValue(opts.data, obj.data)
 		if err != nil {
 			return &obj, fmt.Errorf("api_object.go: error parsing data provided: %v", err.Error())
 		}
@@ -156,13 +550,13 @@ func NewAPIObject(iClient *APIClient, opts *apiObjectOpts) (*APIObject, error) {
 		   If it is not set, we will get it later in synchronize_state */
 		if obj.id == "" {
 			var tmp string
-			tmp, err := GetStringAtKey(obj.data, obj.idAttribute, obj.debug)
+			tmp, err := obj.idFromData(obj.data)
 			if err == nil {
 				if opts.debug {
 					log.Printf("api_object.go: opportunisticly set id from data provided.")
 				}
 				obj.id = tmp
-			} else if !obj.apiClient.writeReturnsObject && !obj.apiClient.createReturnsObject && obj.searchPath == "" {
+			} else if !obj.writeReturnsObject && !obj.createReturnsObject && !obj.followLocation && obj.idHeader == "" && obj.searchPath == "" {
 				/* If the id is not set and we cannot obtain it
 				   later, error out to be safe */
 				return &obj, fmt.Errorf("provided data does not have %s attribute for the object's id and the client is not configured to read the object from a POST response; without an id, the object cannot be managed", obj.idAttribute)
@@ -175,23 +569,42 @@ func NewAPIObject(iClient *APIClient, opts *apiObjectOpts) (*APIObject, error) {
 			log.Printf("api_object.go: Parsing update data: '%s'", opts.updateData)
 		}
 
-		err := json.Unmarshal([]byte(opts.updateData), &obj.updateData)
+		var err error
+		obj.updateDataValue, err = parseDataValue(opts.updateData, obj.updateData)
 		if err != nil {
 			return &obj, fmt.Errorf("api_object.go: error parsing update data provided: %v", err.Error())
 		}
 	}
 
+	if opts.previousData != "" {
+		/* Only needed to compute patches, so data that is not
+		   a JSON object is simply not used */
+		if _, err := parseDataValue(opts.previousData, obj.previousData); err != nil {
+			return &obj, fmt.Errorf("api_object.go: error parsing previous data: %v", err.Error())
+		}
+	}
+
 	if opts.destroyData != "" {
 		if opts.debug {
 			log.Printf("api_object.go: Parsing destroy data: '%s'", opts.destroyData)
 		}
 
-		err := json.Unmarshal([]byte(opts.destroyData), &obj.destroyData)
+		var err error
+		obj.destroyDataValue, err = parseDataValue(opts.destroyData, obj.destroyData)
 		if err != nil {
 			return &obj, fmt.Errorf("api_object.go: error parsing destroy data provided: %v", err.Error())
 		}
 	}
 
+	/* Objects that belong to a parent may have paths made from their data */
+	for _, path := range []*string{&obj.getPath, &obj.postPath, &obj.putPath, &obj.deletePath, &obj.searchPath} {
+		expanded, err := expandDataTemplate(*path, obj.data, obj.escapePathValues, obj.debug)
+		if err != nil {
+			return &obj, fmt.Errorf("api_object.go: error expanding path '%s': %v", *path, err)
+		}
+		*path = expanded
+	}
+
 	if opts.debug {
 		log.Printf("api_object.go: Constructed object: %s", obj.toString())
 	}
@@ -203,6 +616,15 @@ func NewAPIObject(iClient *APIClient, opts *apiObjectOpts) (*APIObject, error) {
 func (obj *APIObject) toString() string {
 	var buffer bytes.Buffer
 	buffer.WriteString(fmt.Sprintf("id: %s\n", obj.id))
+	buffer.WriteString(fmt.Sprintf("id_expression: %s\n", obj.idExpression))
+	buffer.WriteString(fmt.Sprintf("id_template: %s\n", obj.idTemplate))
+	buffer.WriteString(fmt.Sprintf("escape_path_values: %t\n", obj.escapePathValues))
+	for name, expression := range obj.extract {
+		buffer.WriteString(fmt.Sprintf("extract: %s = %s\n", name, expression))
+	}
+	buffer.WriteString(fmt.Sprintf("capture_response_headers: %v\n", obj.captureHeaders))
+	buffer.WriteString(fmt.Sprintf("response_envelope_key: %s\n", obj.responseEnvelopeKey))
+	buffer.WriteString(fmt.Sprintf("response_transform: %s\n", obj.responseTransform))
 	buffer.WriteString(fmt.Sprintf("get_path: %s\n", obj.getPath))
 	buffer.WriteString(fmt.Sprintf("post_path: %s\n", obj.postPath))
 	buffer.WriteString(fmt.Sprintf("put_path: %s\n", obj.putPath))
@@ -216,15 +638,127 @@ func (obj *APIObject) toString() string {
 	buffer.WriteString(fmt.Sprintf("read_method: %s\n", obj.readMethod))
 	buffer.WriteString(fmt.Sprintf("update_method: %s\n", obj.updateMethod))
 	buffer.WriteString(fmt.Sprintf("destroy_method: %s\n", obj.destroyMethod))
+	buffer.WriteString(fmt.Sprintf("data_format: %s\n", obj.dataFormat))
+	buffer.WriteString(fmt.Sprintf("body_format: %s\n", obj.bodyFormat))
+	buffer.WriteString(fmt.Sprintf("data_file: %s (%s)\n", obj.dataFile, obj.dataFileContentType))
+	buffer.WriteString(fmt.Sprintf("ndjson_data: %d documents\n", len(obj.ndjsonData)))
+	buffer.WriteString(fmt.Sprintf("update_strategy: %s\n", obj.updateStrategy))
+	buffer.WriteString(fmt.Sprintf("list_merge_keys: %v\n", obj.listMergeKeys))
+	buffer.WriteString(fmt.Sprintf("null_handling: %s\n", obj.nullHandling))
+	buffer.WriteString(fmt.Sprintf("create_strategy: %s\n", obj.createStrategy))
+	buffer.WriteString(fmt.Sprintf("create_returns_object: %t\n", obj.createReturnsObject))
+	buffer.WriteString(fmt.Sprintf("write_returns_object: %t\n", obj.writeReturnsObject))
+	buffer.WriteString(fmt.Sprintf("copy_keys: %v\n", obj.copyKeys))
+	for _, r := range obj.postCreateRequests {
+		buffer.WriteString(fmt.Sprintf("post_create_request: %s %s\n", r.method, r.path))
+	}
+	if obj.destroyPrecondition != nil {
+		buffer.WriteString(fmt.Sprintf("destroy_precondition: %s %s\n", obj.destroyPrecondition.request.method, obj.destroyPrecondition.request.path))
+	}
+	for _, r := range obj.hooks {
+		buffer.WriteString(fmt.Sprintf("hook: %s %s: %s %s\n", r.when, r.operation, r.method, r.path))
+	}
+	buffer.WriteString(fmt.Sprintf("read_after_write: %t\n", obj.readAfterWrite))
+	buffer.WriteString(fmt.Sprintf("keep_create_response: %t\n", obj.keepCreateResponse))
+	buffer.WriteString(fmt.Sprintf("create_read_timeout: %s\n", obj.createReadTimeout))
+	buffer.WriteString(fmt.Sprintf("read_failure_behavior: %s (%d retries)\n", obj.readFailureBehavior, obj.readRetries))
+	buffer.WriteString(fmt.Sprintf("wait_for_deletion: %s\n", obj.waitForDeletion))
+	if obj.waitFor != nil {
+		buffer.WriteString(fmt.Sprintf("wait_for: %s\n", obj.waitFor.field))
+	}
+	buffer.WriteString(fmt.Sprintf("create_if_none_match: %t\n", obj.createIfNoneMatch))
+	buffer.WriteString(fmt.Sprintf("create_conflict_behavior: %s\n", obj.createConflict))
+	buffer.WriteString(fmt.Sprintf("success_codes: create %v, read %v, update %v, destroy %v\n", obj.createSuccessCodes, obj.readSuccessCodes, obj.updateSuccessCodes, obj.destroySuccessCodes))
+	buffer.WriteString(fmt.Sprintf("replace_on_update_status_codes: %v\n", obj.replaceStatusCodes))
+	buffer.WriteString(fmt.Sprintf("not_found_codes: %v\n", obj.notFoundCodes))
+	buffer.WriteString(fmt.Sprintf("destroy_success_field: %s (values: %v)\n", obj.destroySuccessField, obj.destroySuccessVals))
+	buffer.WriteString(fmt.Sprintf("follow_location: %t (location: '%s')\n", obj.followLocation, obj.location))
+	buffer.WriteString(fmt.Sprintf("id_header: %s\n", obj.idHeader))
+	buffer.WriteString(fmt.Sprintf("poll: create %t, update %t, destroy %t\n", obj.createPoll != nil, obj.updatePoll != nil, obj.destroyPoll != nil))
+	buffer.WriteString(fmt.Sprintf("use_if_match: %t (version_header: '%s', version_field: '%s', version: '%s')\n", obj.useIfMatch, obj.versionHeader, obj.versionField, obj.version))
+	for _, f := range obj.multipartFiles {
+		buffer.WriteString(fmt.Sprintf("multipart_file: %s (path: '%s', filename: '%s', content_type: '%s')\n", f.name, f.path, f.filename, f.contentType))
+	}
 	buffer.WriteString(fmt.Sprintf("debug: %t\n", obj.debug))
 	buffer.WriteString(fmt.Sprintf("read_search: %s\n", spew.Sdump(obj.readSearch)))
-	buffer.WriteString(fmt.Sprintf("data: %s\n", spew.Sdump(obj.data)))
-	buffer.WriteString(fmt.Sprintf("update_data: %s\n", spew.Sdump(obj.updateData)))
-	buffer.WriteString(fmt.Sprintf("destroy_data: %s\n", spew.Sdump(obj.destroyData)))
-	buffer.WriteString(fmt.Sprintf("api_data: %s\n", spew.Sdump(obj.apiData)))
+	buffer.WriteString(fmt.Sprintf("data: %s\n", spew.Sdump(dataOrValue(obj.data, obj.dataValue))))
+	buffer.WriteString(fmt.Sprintf("update_data: %s\n", spew.Sdump(dataOrValue(obj.updateData, obj.updateDataValue))))
+	buffer.WriteString(fmt.Sprintf("destroy_data: %s\n", spew.Sdump(dataOrValue(obj.destroyData, obj.destroyDataValue))))
+	buffer.WriteString(fmt.Sprintf("api_data: %s\n", spew.Sdump(dataOrValue(obj.apiData, obj.apiDataValue))))
 	return buffer.String()
 }
 
+/* Finds the id of the object in data with id_template, id_expression or id_attribute */
+func (obj *APIObject) idFromData(data map[string]interface{}) (string, error) {
+	if obj.idTemplate != "" {
+		return expandDataTemplate(obj.idTemplate, data, false, obj.debug)
+	}
+	if obj.idExpression != "" {
+		return evalExpression(data, obj.idExpression)
+	}
+	return getStringAtAttributePath(data, obj.idAttribute, obj.debug)
+}
+
+/*
+Updates the state from the body of a response to a create, read or
+
+	update request, unwrapping the object from response_envelope_key
+	for APIs that wrap every object in an envelope and then applying
+	response_transform.
+*/
+func (obj *APIObject) updateStateFromResponse(body string) error {
+	if obj.responseEnvelopeKey == "" && obj.responseTransform == "" {
+		return obj.updateState(body)
+	}
+
+	var object interface{}
+	if err := json.Unmarshal([]byte(body), &object); err != nil {
+		return fmt.Errorf("api_object.go: unable to parse the response: %v", err)
+	}
+	if obj.responseEnvelopeKey != "" {
+		unwrapped, err := evalExpressionValue(object, obj.responseEnvelopeKey)
+		if err != nil {
+			return fmt.Errorf("api_object.go: unable to find response_envelope_key in the response: %v", err)
+		}
+		object = unwrapped
+	}
+	object, err := obj.transformResponse(object)
+	if err != nil {
+		return err
+	}
+	objectJSON, _ := json.Marshal(object)
+	if err := obj.updateState(string(objectJSON)); err != nil {
+		return err
+	}
+
+	/* The raw body is still available, envelope and all */
+	obj.apiResponse = body
+	return nil
+}
+
+/*
+Applies response_transform to an object that came back from the API
+
+	so it can be compared to data. Without a transform the object is
+	returned as is.
+*/
+func (obj *APIObject) transformResponse(object interface{}) (interface{}, error) {
+	if obj.responseTransform == "" {
+		return object, nil
+	}
+	transformed, err := evalExpressionValue(object, obj.responseTransform)
+	if err != nil {
+		return nil, fmt.Errorf("api_object.go: unable to apply response_transform to the response: %v", err)
+	}
+	if _, ok := transformed.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("api_object.go: response_transform must give an object, but gave '%T'", transformed)
+	}
+	if obj.debug {
+		log.Printf("api_object.go: Transformed the response to: %v", transformed)
+	}
+	return transformed, nil
+}
+
 /*
 Centralized function to ensure that our data as managed by
 
@@ -241,10 +775,20 @@ func (obj *APIObject) updateState(state string) error {
 	d.UseNumber()
 	err = d.Decode(&obj.api_data)
 	*/
-	err := json.Unmarshal([]byte(state), &obj.apiData)
+	value, err := parseDataValue(state, obj.apiData)
 	if err != nil {
+		/* Action-style endpoints may respond with anything. That is fine
+		   as long as the id is known and there is no data to compare */
+		if obj.id != "" && !obj.detectsDrift() {
+			if obj.debug {
+				log.Printf("api_object.go: Ignoring response that is not JSON: %v\n", err)
+			}
+			obj.apiResponse = state
+			return nil
+		}
 		return err
 	}
+	obj.apiDataValue = value
 
 	/* Store response body for parsing via jsondecode() */
 	obj.apiResponse = state
@@ -252,7 +796,7 @@ func (obj *APIObject) updateState(state string) error {
 	/* A usable ID was not passed (in constructor or here),
 	   so we have to guess what it is from the data structure */
 	if obj.id == "" {
-		val, err := GetStringAtKey(obj.apiData, obj.idAttribute, obj.debug)
+		val, err := obj.idFromData(obj.apiData)
 		if err != nil {
 			return fmt.Errorf("api_object.go: Error extracting ID from data element: %s", err)
 		}
@@ -262,8 +806,8 @@ func (obj *APIObject) updateState(state string) error {
 	}
 
 	/* Any keys that come from the data we want to copy are done here */
-	if len(obj.apiClient.copyKeys) > 0 {
-		for _, key := range obj.apiClient.copyKeys {
+	if len(obj.copyKeys) > 0 {
+		for _, key := range obj.copyKeys {
 			if obj.debug {
 				log.Printf("api_object.go: Copying key '%s' from api_data (%v) to data (%v)\n", key, obj.apiData[key], obj.data[key])
 			}
@@ -280,15 +824,48 @@ func (obj *APIObject) updateState(state string) error {
 }
 
 func (obj *APIObject) createObject(ctx context.Context) error {
+	/* A bulk response does not describe the object, so there is
+	   no way to learn the id from it */
+	if obj.bodyFormat == "ndjson" && obj.id == "" {
+		return fmt.Errorf("provided object does not have an id set; please set object_id when body_format is ndjson")
+	}
+
+	/* An upsert can only put the object where the id says */
+	if obj.createStrategy == "upsert" && obj.id == "" {
+		return fmt.Errorf("provided object does not have an id set; please include an id in the object's data or set object_id when create_strategy is upsert")
+	}
+
 	/* Failsafe: The constructor should prevent this situation, but
 	   protect here also. If no id is set, and the API does not respond
 	   with the id of whatever gets created, we have no way to know what
 	   the object's id will be. Abandon this attempt */
-	if obj.id == "" && !obj.apiClient.writeReturnsObject && !obj.apiClient.createReturnsObject {
-		return fmt.Errorf("provided object does not have an id set and the client is not configured to read the object from a POST or PUT response; please set write_returns_object to true, or include an id in the object's data")
+	if obj.id == "" && !obj.writeReturnsObject && !obj.createReturnsObject && !obj.followLocation && obj.idHeader == "" {
+		return fmt.Errorf("provided object does not have an id set and the client is not configured to read the object from a POST or PUT response; please set write_returns_object or follow_location to true, set id_header, or include an id in the object's data")
+	}
+
+	if obj.createConflict != "" {
+		exists, err := obj.existsBeforeCreate(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			switch obj.createConflict {
+			case "adopt":
+				log.Printf("api_object.go: Object '%s' already exists. Adopting it instead of creating it.\n", obj.id)
+				return nil
+			case "update":
+				log.Printf("api_object.go: Object '%s' already exists. Updating it instead of creating it.\n", obj.id)
+				return obj.updateObject(ctx)
+			default:
+				return fmt.Errorf("object '%s' already exists; import it, remove it or set create_conflict_behavior to adopt or update it", obj.id)
+			}
+		}
 	}
 
-	b, _ := json.Marshal(obj.data)
+	body, headers, err := obj.encodeBody(obj.handleNulls(obj.requestData(), false), true)
+	if err != nil {
+		return err
+	}
 
 	postPath := obj.postPath
 	if obj.createQueryString != "" {
@@ -298,33 +875,119 @@ func (obj *APIObject) createObject(ctx context.Context) error {
 		postPath = fmt.Sprintf("%s?%s", obj.postPath, obj.createQueryString)
 	}
 
-	resultString, err := obj.apiClient.sendRequest(ctx, obj.createMethod, strings.Replace(postPath, "{id}", obj.id, -1), string(b))
+	/* Ask the server not to overwrite an object that already exists */
+	if obj.createIfNoneMatch {
+		withIfNoneMatch := map[string]string{"If-None-Match": "*"}
+		for k, v := range headers {
+			withIfNoneMatch[k] = v
+		}
+		headers = withIfNoneMatch
+	}
+
+	resp, err := obj.apiClient.sendRequestWithResponse(ctx, obj.createMethod, strings.Replace(postPath, "{id}", obj.pathValue(obj.id), -1), body, headers)
+	err = obj.checkResponse(resp, err, obj.createSuccessCodes)
+	if err != nil {
+		if obj.createIfNoneMatch && resp.statusCode == http.StatusPreconditionFailed {
+			return fmt.Errorf("object '%s' already exists at '%s'; import it or remove it before creating it again: %v", obj.id, postPath, err)
+		}
+		return err
+	}
+	obj.created = true
+	if err := obj.pollOperation(ctx, resp, obj.createPoll); err != nil {
+		return err
+	}
+	obj.recordResponse("create", resp)
+	resultString := resp.body
+	obj.createResponse = resultString
+
+	followed, err := obj.followCreateLocation(resp)
+	if err != nil {
+		return err
+	}
+	fromHeader, err := obj.idFromHeader(resp)
 	if err != nil {
 		return err
 	}
 
-	/* We will need to sync state as well as get the object's ID */
-	if obj.apiClient.writeReturnsObject || obj.apiClient.createReturnsObject {
+	if obj.bodyFormat == "ndjson" {
+		obj.apiResponse = resultString
+		return checkBulkResponse(resultString)
+	}
+
+	/* We will need to sync state as well as get the object's ID, unless
+	   the object is only described by the response headers */
+	if (obj.writeReturnsObject || obj.createReturnsObject) && !((followed || fromHeader) && strings.TrimSpace(resultString) == "") {
 		if obj.debug {
 			log.Printf("api_object.go: Parsing response from POST to update internal structures (write_returns_object=%t, create_returns_object=%t)...\n",
-				obj.apiClient.writeReturnsObject, obj.apiClient.createReturnsObject)
+				obj.writeReturnsObject, obj.createReturnsObject)
 		}
-		err = obj.updateState(resultString)
+		err = obj.updateStateFromResponse(resultString)
+		obj.captureVersion(resp.headers)
 		/* Yet another failsafe. In case something terrible went wrong internally,
 		   bail out so the user at least knows that the ID did not get set. */
 		if obj.id == "" {
 			return fmt.Errorf("internal validation failed; object ID is not set, but *may* have been created; this should never happen")
 		}
+		/* A create response that is kept may hold secrets, so the
+		   rest of the state comes from reading the object instead */
+		if err == nil && obj.keepCreateResponse {
+			obj.apiData = make(map[string]interface{})
+		}
+		if err == nil && (obj.readAfterWrite || obj.keepCreateResponse) {
+			err = obj.readCreatedObject(ctx)
+		}
 	} else {
 		if obj.debug {
 			log.Printf("api_object.go: Requesting created object from API (write_returns_object=%t, create_returns_object=%t)...\n",
-				obj.apiClient.writeReturnsObject, obj.apiClient.createReturnsObject)
+				obj.writeReturnsObject, obj.createReturnsObject)
 		}
-		err = obj.readObject(ctx)
+		err = obj.readCreatedObject(ctx)
 	}
 	return err
 }
 
+/*
+Reads the object right after it was created. Eventually consistent
+
+	APIs may not find the object for a while, so a read that comes back
+	not found is retried with backoff until create_read_timeout passes.
+*/
+func (obj *APIObject) readCreatedObject(ctx context.Context) error {
+	id := obj.id
+	deadline := time.Now().Add(obj.createReadTimeout)
+	wait := 500 * time.Millisecond
+	for {
+		if err := obj.readObject(ctx); err != nil || obj.id != "" {
+			return err
+		}
+
+		/* readObject forgets the id of objects it cannot find */
+		obj.id = id
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("object '%s' was created but could not be read back from '%s' within %s; it may still be created later", id, obj.getPath, obj.createReadTimeout)
+		}
+		if obj.debug {
+			log.Printf("api_object.go: Created object '%s' is not found yet. Retrying in %s\n", id, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait < 8*time.Second {
+			wait *= 2
+		}
+	}
+}
+
+/* Sends a read request, which the data sources share within a run */
+func (obj *APIObject) sendReadRequest(ctx context.Context, method string, path string) (*apiClientResponse, error) {
+	if obj.cacheReads {
+		return obj.apiClient.sendCachedRequest(ctx, method, path, nil)
+	}
+	return obj.apiClient.sendRequestWithResponse(ctx, method, path, "", nil)
+}
+
 func (obj *APIObject) readObject(ctx context.Context) error {
 	if obj.id == "" {
 		return fmt.Errorf("cannot read an object unless the ID has been set")
@@ -338,22 +1001,28 @@ func (obj *APIObject) readObject(ctx context.Context) error {
 		getPath = fmt.Sprintf("%s?%s", obj.getPath, obj.readQueryString)
 	}
 
-	resultString, err := obj.apiClient.sendRequest(ctx, obj.readMethod, strings.Replace(getPath, "{id}", obj.id, -1), "")
+	resp, err := obj.sendReadRequest(ctx, obj.readMethod, strings.Replace(getPath, "{id}", obj.pathValue(obj.id), -1))
+	err = obj.checkResponse(resp, err, obj.readSuccessCodes)
+	resultString := resp.body
 	if err != nil {
-		if strings.Contains(err.Error(), "unexpected response code '404'") {
-			log.Printf("api_object.go: 404 error while refreshing state for '%s' at path '%s'. Removing from state.", obj.id, obj.getPath)
+		if containsInt(obj.notFoundCodes, resp.statusCode) {
+			log.Printf("api_object.go: %d error while refreshing state for '%s' at path '%s'. Removing from state.", resp.statusCode, obj.id, obj.getPath)
 			obj.id = ""
 			return nil
 		}
+		if isTransientResponse(resp) {
+			return &transientError{err: err}
+		}
 		return err
 	}
+	obj.recordResponse("read", resp)
 
 	searchKey := obj.readSearch["search_key"]
 	searchValue := obj.readSearch["search_value"]
 
 	if searchKey != "" && searchValue != "" {
 
-		obj.searchPath = strings.Replace(obj.getPath, "{id}", obj.id, -1)
+		obj.searchPath = strings.Replace(obj.getPath, "{id}", obj.pathValue(obj.id), -1)
 
 		queryString := obj.readSearch["query_string"]
 		if obj.queryString != "" {
@@ -363,16 +1032,67 @@ func (obj *APIObject) readObject(ctx context.Context) error {
 			queryString = fmt.Sprintf("%s&%s", obj.readSearch["query_string"], obj.queryString)
 		}
 		resultsKey := obj.readSearch["results_key"]
-		objFound, err := obj.findObject(ctx, queryString, searchKey, searchValue, resultsKey)
+		objFound, err := obj.findObjectMatching(ctx, queryString, obj.readSearchCriteria(), "and", resultsKey)
 		if err != nil {
+			/* A failed search does not mean that the object is gone */
+			var transient *transientError
+			if errors.As(err, &transient) {
+				return err
+			}
 			obj.id = ""
 			return nil
 		}
-		objFoundString, _ := json.Marshal(objFound)
-		return obj.updateState(string(objFoundString))
+		transformed, err := obj.transformResponse(objFound)
+		if err != nil {
+			return err
+		}
+		objFoundString, _ := json.Marshal(transformed)
+		err = obj.updateState(string(objFoundString))
+	} else {
+		err = obj.updateStateFromResponse(resultString)
+	}
+	if err == nil {
+		obj.captureVersion(resp.headers)
+		err = obj.validateResponse()
+	}
+	return err
+}
+
+/*
+Reads the object to refresh it. Reads that fail transiently, such as
+
+	with a 5xx or a timeout, are retried with backoff up to read_retries
+	times if read_failure_behavior is retry.
+*/
+func (obj *APIObject) refreshObject(ctx context.Context) error {
+	wait := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := obj.readObject(ctx)
+		var transient *transientError
+		if err == nil || !errors.As(err, &transient) || obj.readFailureBehavior != "retry" || attempt > obj.readRetries {
+			return err
+		}
+		log.Printf("api_object.go: Read of '%s' failed (%v). Retrying in %s (retry %d of %d)\n", obj.id, err, wait, attempt, obj.readRetries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait < 8*time.Second {
+			wait *= 2
+		}
 	}
+}
 
-	return obj.updateState(resultString)
+/* Checks the object read from the API against response_schema, if set */
+func (obj *APIObject) validateResponse() error {
+	if obj.responseSchema == nil {
+		return nil
+	}
+	if violations := validateJSONSchema(obj.responseSchema, dataOrValue(obj.apiData, obj.apiDataValue)); len(violations) > 0 {
+		return fmt.Errorf("the object '%s' read from the API does not match response_schema:\n  %s", obj.id, strings.Join(violations, "\n  "))
+	}
+	return nil
 }
 
 func (obj *APIObject) updateObject(ctx context.Context) error {
@@ -380,14 +1100,51 @@ func (obj *APIObject) updateObject(ctx context.Context) error {
 		return fmt.Errorf("cannot update an object unless the ID has been set")
 	}
 
-	b, _ := json.Marshal(obj.data)
+	var body string
+	var headers map[string]string
+	var err error
+	if obj.updateStrategy == "merge_patch" || obj.updateStrategy == "json_patch" {
+		if obj.emptyData || obj.dataValue != nil {
+			return fmt.Errorf("update_strategy %s requires data to be a JSON object", obj.updateStrategy)
+		}
 
-	updateData, _ := json.Marshal(obj.updateData)
-	if string(updateData) != "{}" {
-		if obj.debug {
-			log.Printf("api_object.go: Using update data '%s'", string(updateData))
+		var patch interface{}
+		var patchLen int
+		contentType := "application/merge-patch+json"
+		if obj.updateStrategy == "json_patch" {
+			operations := getJSONPatch(obj.previousData, obj.data, "", obj.listMergeKeys)
+			patch, patchLen = operations, len(operations)
+			contentType = "application/json-patch+json"
+		} else {
+			mergePatch := getMergePatch(obj.previousData, obj.data, obj.listMergeKeys)
+			patch, patchLen = mergePatch, len(mergePatch)
+		}
+		if patchLen == 0 {
+			if obj.debug {
+				log.Printf("api_object.go: Data is unchanged. Not sending an empty patch.\n")
+			}
+			return obj.readObject(ctx)
+		}
+
+		b, err := json.Marshal(patch)
+		if err != nil {
+			return err
+		}
+		body = string(b)
+		headers = map[string]string{"Content-Type": contentType}
+	} else {
+		data := obj.requestData()
+		if len(obj.updateData) > 0 || obj.updateDataValue != nil {
+			data = dataOrValue(obj.updateData, obj.updateDataValue)
+			if obj.debug {
+				log.Printf("api_object.go: Using update data '%v'", data)
+			}
+		}
+
+		body, headers, err = obj.encodeBody(obj.handleNulls(data, true), true)
+		if err != nil {
+			return err
 		}
-		b = updateData
 	}
 
 	putPath := obj.putPath
@@ -398,16 +1155,34 @@ func (obj *APIObject) updateObject(ctx context.Context) error {
 		putPath = fmt.Sprintf("%s?%s", obj.putPath, obj.updateQueryString)
 	}
 
-	resultString, err := obj.apiClient.sendRequest(ctx, obj.updateMethod, strings.Replace(putPath, "{id}", obj.id, -1), string(b))
+	resp, err := obj.apiClient.sendRequestWithResponse(ctx, obj.updateMethod, strings.Replace(putPath, "{id}", obj.pathValue(obj.id), -1), body, obj.withIfMatch(headers))
+	err = obj.checkResponse(resp, err, obj.updateSuccessCodes)
 	if err != nil {
+		if containsInt(obj.replaceStatusCodes, resp.statusCode) {
+			return &updateRejectedError{statusCode: resp.statusCode, err: err}
+		}
+		return obj.checkPreconditionFailed(resp, err)
+	}
+	if err := obj.pollOperation(ctx, resp, obj.updatePoll); err != nil {
 		return err
 	}
+	obj.recordResponse("update", resp)
+	resultString := resp.body
+
+	if obj.bodyFormat == "ndjson" {
+		obj.apiResponse = resultString
+		return checkBulkResponse(resultString)
+	}
 
-	if obj.apiClient.writeReturnsObject {
+	if obj.writeReturnsObject {
 		if obj.debug {
 			log.Printf("api_object.go: Parsing response from PUT to update internal structures (write_returns_object=true)...\n")
 		}
-		err = obj.updateState(resultString)
+		err = obj.updateStateFromResponse(resultString)
+		obj.captureVersion(resp.headers)
+		if err == nil && obj.readAfterWrite {
+			err = obj.readObject(ctx)
+		}
 	} else {
 		if obj.debug {
 			log.Printf("api_object.go: Requesting updated object from API (write_returns_object=false)...\n")
@@ -423,6 +1198,15 @@ func (obj *APIObject) deleteObject(ctx context.Context) error {
 		return nil
 	}
 
+	if p := obj.destroyPrecondition; p != nil {
+		if err := obj.sendExtraRequest(ctx, p.request, make(map[string]interface{})); err != nil {
+			if p.message != "" {
+				return fmt.Errorf("cannot destroy '%s': %s (%v)", obj.id, p.message, err)
+			}
+			return fmt.Errorf("cannot destroy '%s' as destroy_precondition is not met: %v", obj.id, err)
+		}
+	}
+
 	deletePath := obj.deletePath
 	if obj.destroyQueryString != "" {
 		if obj.debug {
@@ -431,87 +1215,1224 @@ func (obj *APIObject) deleteObject(ctx context.Context) error {
 		deletePath = fmt.Sprintf("%s?%s", obj.deletePath, obj.destroyQueryString)
 	}
 
-	b := []byte{}
-	destroyData, _ := json.Marshal(obj.destroyData)
-	if string(destroyData) != "{}" {
+	body := ""
+	var headers map[string]string
+	if len(obj.destroyData) > 0 || obj.destroyDataValue != nil {
+		destroyData := dataOrValue(obj.destroyData, obj.destroyDataValue)
 		if obj.debug {
-			log.Printf("api_object.go: Using destroy data '%s'", string(destroyData))
+			log.Printf("api_object.go: Using destroy data '%v'", destroyData)
+		}
+		var err error
+		body, headers, err = obj.encodeBody(destroyData, false)
+		if err != nil {
+			return err
 		}
-		b = destroyData
 	}
 
-	_, err := obj.apiClient.sendRequest(ctx, obj.destroyMethod, strings.Replace(deletePath, "{id}", obj.id, -1), string(b))
+	resp, err := obj.apiClient.sendRequestWithResponse(ctx, obj.destroyMethod, strings.Replace(deletePath, "{id}", obj.pathValue(obj.id), -1), body, obj.withIfMatch(headers))
+	if resp.statusCode != 0 {
+		log.Printf("api_object.go: Response to destroy '%s' (%d): %s\n", obj.id, resp.statusCode, resp.body)
+	}
+	err = obj.checkResponse(resp, err, obj.destroySuccessCodes)
 	if err != nil {
-		return err
+		if containsInt(obj.notFoundCodes, resp.statusCode) {
+			/* The object doesn't exist. Call that good enough */
+			log.Printf("api_object.go: %d error while deleting '%s'. Assuming it is already gone.", resp.statusCode, obj.id)
+			return nil
+		}
+		return obj.checkPreconditionFailed(resp, obj.destroyError(resp, err))
 	}
 
-	return nil
-}
-
-func (obj *APIObject) findObject(ctx context.Context, queryString string, searchKey string, searchValue string, resultsKey string) (map[string]interface{}, error) {
-	var objFound map[string]interface{}
-	var dataArray []interface{}
-	var ok bool
-
-	/*
-	   Issue a GET to the base path and expect results to come back
-	*/
-	searchPath := obj.searchPath
-	if queryString != "" {
-		if obj.debug {
-			log.Printf("api_object.go: Adding query string '%s'", queryString)
+	/* A soft delete may be accepted without having any effect */
+	if obj.destroySuccessField != "" {
+		result := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(resp.body), &result); err != nil {
+			return fmt.Errorf("api_object.go: unable to parse the response to destroy '%s': %v", obj.id, err)
+		}
+		value, err := GetStringAtKey(result, obj.destroySuccessField, obj.debug)
+		if err != nil {
+			return fmt.Errorf("api_object.go: unable to find destroy_success_field in the response to destroy '%s': %v", obj.id, err)
+		}
+		if !containsString(obj.destroySuccessVals, value) {
+			return fmt.Errorf("destroy of '%s' did not succeed; '%s' is '%s' but expected one of %v", obj.id, obj.destroySuccessField, value, obj.destroySuccessVals)
 		}
-		searchPath = fmt.Sprintf("%s?%s", obj.searchPath, queryString)
 	}
 
-	if obj.debug {
-		log.Printf("api_object.go: Calling API on path '%s'", searchPath)
-	}
-	resultString, err := obj.apiClient.sendRequest(ctx, obj.apiClient.readMethod, searchPath, "")
-	if err != nil {
-		return objFound, err
+	if err := obj.pollOperation(ctx, resp, obj.destroyPoll); err != nil {
+		return err
 	}
+	return obj.waitUntilDeleted(ctx)
+}
 
-	/*
-	   Parse it seeking JSON data
-	*/
-	if obj.debug {
-		log.Printf("api_object.go: Response received... parsing")
-	}
-	var result interface{}
-	err = json.Unmarshal([]byte(resultString), &result)
-	if err != nil {
-		return objFound, err
-	}
+/*
+Reads the object until the field set in the wait_for block holds
 
-	if resultsKey != "" {
-		var tmp interface{}
+	the expected value, such as a status that says the object is ready.
+*/
+func (obj *APIObject) waitForState(ctx context.Context) error {
+	w := obj.waitFor
+	if w == nil {
+		return nil
+	}
 
-		if obj.debug {
-			log.Printf("api_object.go: Locating '%s' in the results", resultsKey)
+	check := w.field
+	if w.expression != "" {
+		check = w.expression
+	}
+	id := obj.id
+	deadline := time.Now().Add(w.timeout)
+	for {
+		if err := obj.readObject(ctx); err != nil {
+			return err
 		}
 
-		/* First verify the data we got back is a hash */
-		if _, ok = result.(map[string]interface{}); !ok {
-			return objFound, fmt.Errorf("api_object.go: The results of a GET to '%s' did not return a hash. Cannot search within for results_key '%s'", searchPath, resultsKey)
+		/* The object may not be found until it is ready */
+		value := ""
+		if obj.id == "" {
+			obj.id = id
+		} else if v, err := lookupValue(obj.apiData, w.field, w.expression, obj.debug); err == nil {
+			value = v
+			if (w.valueRegex == nil && value == w.value) || (w.valueRegex != nil && w.valueRegex.MatchString(value)) {
+				return nil
+			}
 		}
 
-		tmp, err = GetObjectAtKey(result.(map[string]interface{}), resultsKey, obj.debug)
-		if err != nil {
-			return objFound, fmt.Errorf("api_object.go: Error finding results_key: %s", err)
+		if time.Now().Add(w.interval).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for '%s' of object '%s' to be ready (last value '%s')", w.timeout, check, obj.id, value)
 		}
-		if dataArray, ok = tmp.([]interface{}); !ok {
-			return objFound, fmt.Errorf("api_object.go: The data at results_key location '%s' is not an array. It is a '%s'", resultsKey, reflect.TypeOf(tmp))
+		if obj.debug {
+			log.Printf("api_object.go: '%s' of object '%s' is '%s'. Waiting %s\n", check, obj.id, value, w.interval)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+/*
+Reads the object after it was deleted until the API responds that
+
+	it is not found, for APIs that tear objects down in the background.
+	Reads are retried with backoff until wait_for_deletion passes.
+*/
+func (obj *APIObject) waitUntilDeleted(ctx context.Context) error {
+	if obj.waitForDeletion == 0 {
+		return nil
+	}
+
+	getPath := strings.Replace(obj.getPath, "{id}", obj.pathValue(obj.id), -1)
+	deadline := time.Now().Add(obj.waitForDeletion)
+	wait := 500 * time.Millisecond
+	for {
+		resp, err := obj.apiClient.sendRequestWithResponse(ctx, obj.readMethod, getPath, "", nil)
+		if containsInt(obj.notFoundCodes, resp.statusCode) {
+			return nil
+		}
+		if err := checkStatusCode(resp, err, obj.readSuccessCodes); err != nil {
+			return err
+		}
+
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("object '%s' was deleted but could still be read from '%s' after %s", obj.id, getPath, obj.waitForDeletion)
+		}
+		if obj.debug {
+			log.Printf("api_object.go: Deleted object '%s' is still found. Retrying in %s\n", obj.id, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait < 8*time.Second {
+			wait *= 2
+		}
+	}
+}
+
+/*
+Sends the post_create_request requests in order once the object
+
+	is created, such as a request to activate the object.
+*/
+func (obj *APIObject) postCreate(ctx context.Context) error {
+	/* The create response may not be JSON, in which case
+	   only {id} can be used */
+	response := make(map[string]interface{})
+	json.Unmarshal([]byte(obj.createResponse), &response)
+
+	for i, r := range obj.postCreateRequests {
+		if err := obj.sendExtraRequest(ctx, r, response); err != nil {
+			return fmt.Errorf("post_create_request %d failed: %v", i, err)
+		}
+	}
+	return nil
+}
+
+/*
+Runs an operation on the object between the hooks set for it.
+
+	The hooks after the operation are run even if it failed, so that
+	a lock taken before it is released. Values from the responses to
+	the hooks are available to the hooks that follow.
+*/
+func (obj *APIObject) withHooks(ctx context.Context, operation string, f func() error) error {
+	response := make(map[string]interface{})
+	for _, r := range obj.hooks {
+		if r.operation == operation && r.when == "before" {
+			if err := obj.sendExtraRequest(ctx, r, response); err != nil {
+				return fmt.Errorf("hook before %s failed: %v", operation, err)
+			}
+		}
+	}
+
+	err := f()
+
+	for _, r := range obj.hooks {
+		if r.operation == operation && r.when == "after" {
+			if hookErr := obj.sendExtraRequest(ctx, r, response); hookErr != nil && err == nil {
+				err = fmt.Errorf("hook after %s failed: %v", operation, hookErr)
+			}
+		}
+	}
+	return err
+}
+
+/*
+Sends a post_create_request or hook with {id} and {response.<key>}
+
+	expanded from the given response, then merges its own response
+	into it for the requests that follow.
+*/
+func (obj *APIObject) sendExtraRequest(ctx context.Context, r extraRequest, response map[string]interface{}) error {
+	path, err := obj.expandTemplate(r.path, response, true)
+	if err != nil {
+		return err
+	}
+	data, err := obj.expandTemplate(r.data, response, false)
+	if err != nil {
+		return err
+	}
+
+	if obj.debug {
+		log.Printf("api_object.go: Sending extra request: %s %s\n", r.method, path)
+	}
+	result, err := obj.apiClient.sendRequest(ctx, r.method, path, data)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v", r.method, path, err)
+	}
+
+	/* Responses that are not JSON objects have nothing to offer */
+	resultData := make(map[string]interface{})
+	if json.Unmarshal([]byte(result), &resultData) == nil {
+		for k, v := range resultData {
+			response[k] = v
+		}
+	}
+
+	if r.successField != "" {
+		value, err := GetStringAtKey(resultData, r.successField, obj.debug)
+		if err != nil {
+			return fmt.Errorf("%s %s: unable to find success_field: %v", r.method, path, err)
+		}
+		if !containsString(r.successValues, value) {
+			return fmt.Errorf("%s %s: '%s' is '%s' but expected one of %v", r.method, path, r.successField, value, r.successValues)
+		}
+	}
+	if r.successExpr != "" {
+		var body interface{}
+		if err := json.Unmarshal([]byte(result), &body); err != nil {
+			return fmt.Errorf("%s %s: the response is not JSON: %v", r.method, path, err)
+		}
+		value, err := evalExpression(body, r.successExpr)
+		if err != nil {
+			return fmt.Errorf("%s %s: unable to evaluate '%s': %v", r.method, path, r.successExpr, err)
+		}
+		if !containsString(r.successValues, value) {
+			return fmt.Errorf("%s %s: '%s' is '%s' but expected one of %v", r.method, path, r.successExpr, value, r.successValues)
+		}
+	}
+	return nil
+}
+
+var responsePlaceholder = regexp.MustCompile(`\{response\.([^}]+)\}`)
+var dataPlaceholder = regexp.MustCompile(`\{data\.([^}]+)\}`)
+
+/*
+Replaces {data.<key>} with the value of <key> in data, such as the
+
+	data of the object. Nested keys are separated by dots or slashes,
+	as in id_attribute: {data.metadata.name} or {data.metadata/name}
+*/
+func expandDataTemplate(template string, data map[string]interface{}, escape bool, debug bool) (string, error) {
+	var err error
+	expanded := dataPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		key := dataPlaceholder.FindStringSubmatch(placeholder)[1]
+		value, keyErr := getStringAtAttributePath(data, key, debug)
+		if keyErr != nil && err == nil {
+			err = fmt.Errorf("unable to expand '%s' from the data: %v", placeholder, keyErr)
+		}
+		if escape {
+			return url.PathEscape(value)
+		}
+		return value
+	})
+	return expanded, err
+}
+
+/*
+Replaces {id} with the id of the object and {response.<key>} with
+
+	the value at key in the response, with nested keys separated by dots
+	or slashes. Values are escaped as set by escape_path_values if the
+	template is a path.
+*/
+func (obj *APIObject) expandTemplate(template string, response map[string]interface{}, isPath bool) (string, error) {
+	escape := func(value string) string { return value }
+	if isPath {
+		escape = obj.pathValue
+	}
+
+	var err error
+	expanded := responsePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		key := responsePlaceholder.FindStringSubmatch(placeholder)[1]
+		value, keyErr := getStringAtAttributePath(response, key, obj.debug)
+		if keyErr != nil && err == nil {
+			err = fmt.Errorf("unable to expand '%s' from the response: %v", placeholder, keyErr)
+		}
+		return escape(value)
+	})
+	return strings.Replace(expanded, "{id}", escape(obj.id), -1), err
+}
+
+/* Percent-encodes a value that replaces a placeholder in a path if escape_path_values is set */
+func (obj *APIObject) pathValue(value string) string {
+	if obj.escapePathValues {
+		return url.PathEscape(value)
+	}
+	return value
+}
+
+/*
+Learns the id of the object from the Location header of a 201 or
+
+	202 response to a create request, and reads the object from there
+	if read_path is not set. Returns whether the header was used.
+*/
+func (obj *APIObject) followCreateLocation(resp *apiClientResponse) (bool, error) {
+	location := resp.headers.Get("Location")
+	if !obj.followLocation || location == "" || (resp.statusCode != http.StatusCreated && resp.statusCode != http.StatusAccepted) {
+		return false, nil
+	}
+
+	location, err := obj.apiClient.resolveURL(location)
+	if err != nil {
+		return false, err
+	}
+	locationURL, _ := url.Parse(location)
+
+	/* By default, the id is the last segment of the path */
+	var id string
+	if obj.locationIDRegex != nil {
+		match := obj.locationIDRegex.FindStringSubmatch(location)
+		if len(match) < 2 {
+			return false, fmt.Errorf("location_id_regex '%s' does not capture an id from the Location '%s'", obj.locationIDRegex, location)
+		}
+		id = match[1]
+	} else {
+		segments := strings.Split(strings.TrimSuffix(locationURL.Path, "/"), "/")
+		id = segments[len(segments)-1]
+	}
+
+	if obj.debug {
+		log.Printf("api_object.go: Following Location '%s' with id '%s'\n", location, id)
+	}
+	if obj.id == "" {
+		obj.id = id
+	}
+	obj.location = location
+	if obj.readFromLocation {
+		obj.getPath = location
+	}
+	return true, nil
+}
+
+/*
+Learns the id of the object from the id_header header of the
+
+	response to a create request, for APIs that return the id only
+	in a header. Returns whether the header was used.
+*/
+func (obj *APIObject) idFromHeader(resp *apiClientResponse) (bool, error) {
+	if obj.idHeader == "" {
+		return false, nil
+	}
+
+	value := resp.headers.Get(obj.idHeader)
+	if value == "" {
+		if obj.id != "" {
+			return false, nil
+		}
+		return false, fmt.Errorf("the response to the create request does not have the '%s' header to learn the id of the object from", obj.idHeader)
+	}
+
+	id := value
+	if obj.idHeaderRegex != nil {
+		match := obj.idHeaderRegex.FindStringSubmatch(value)
+		if len(match) < 2 {
+			return false, fmt.Errorf("id_header_regex '%s' does not capture an id from the %s header '%s'", obj.idHeaderRegex, obj.idHeader, value)
+		}
+		id = match[1]
+	}
+
+	if obj.debug {
+		log.Printf("api_object.go: Found id '%s' in the %s header\n", id, obj.idHeader)
+	}
+	if obj.id == "" {
+		obj.id = id
+	}
+	return true, nil
+}
+
+/*
+Looks for the object before it is created, either by its id or
+
+	by read_search if the id is not known until the API assigns it.
+	If the object is found, its state is updated from the API.
+*/
+func (obj *APIObject) existsBeforeCreate(ctx context.Context) (bool, error) {
+	if obj.id != "" {
+		id := obj.id
+		if err := obj.readObject(ctx); err != nil {
+			return false, err
+		}
+		/* readObject forgets the id if the object is not there */
+		if obj.id == "" {
+			obj.id = id
+			return false, nil
+		}
+		return true, nil
+	}
+
+	searchKey := obj.readSearch["search_key"]
+	searchValue := obj.readSearch["search_value"]
+	if searchKey == "" || searchValue == "" {
+		return false, fmt.Errorf("create_conflict_behavior requires the id of the object to be known from data or object_id, or read_search to be set")
+	}
+
+	objFound, err := obj.findObjectMatching(ctx, obj.readSearch["query_string"], obj.readSearchCriteria(), "and", obj.readSearch["results_key"])
+	if err != nil {
+		var notFound *notFoundError
+		if obj.id == "" && errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	objFoundString, _ := json.Marshal(objFound)
+	return true, obj.updateState(string(objFoundString))
+}
+
+/*
+Waits for an asynchronous operation that the API accepted with
+
+	a 201 or 202 to finish by polling its status URL until the status
+	field holds one of the success or failure values.
+*/
+func (obj *APIObject) pollOperation(ctx context.Context, resp *apiClientResponse, poll *pollOpts) error {
+	if poll == nil || (resp.statusCode != http.StatusCreated && resp.statusCode != http.StatusAccepted) {
+		return nil
+	}
+
+	var statusURL string
+	if poll.urlField != "" {
+		response := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(resp.body), &response); err != nil {
+			return fmt.Errorf("api_object.go: unable to parse the response to find the status URL: %v", err)
+		}
+		fieldURL, err := GetStringAtKey(response, poll.urlField, obj.debug)
+		if err != nil {
+			return fmt.Errorf("api_object.go: unable to find the status URL at '%s': %v", poll.urlField, err)
+		}
+		statusURL = fieldURL
+	} else if poll.urlHeader != "" {
+		statusURL = resp.headers.Get(poll.urlHeader)
+	} else if statusURL = resp.headers.Get("Operation-Location"); statusURL == "" {
+		statusURL = resp.headers.Get("Location")
+	}
+	if statusURL == "" {
+		return fmt.Errorf("api_object.go: the API responded with %d but did not provide a status URL to poll", resp.statusCode)
+	}
+	statusURL, err := obj.apiClient.resolveURL(statusURL)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(poll.timeout)
+	for {
+		result, err := obj.apiClient.sendRequest(ctx, "GET", statusURL, "")
+		if err != nil {
+			return err
+		}
+
+		/* The status may not be there until the operation has started */
+		var status interface{}
+		if err := json.Unmarshal([]byte(result), &status); err != nil {
+			return fmt.Errorf("api_object.go: unable to parse the status of the operation at '%s': %v", statusURL, err)
+		}
+		value, err := lookupValue(status, poll.statusField, poll.statusExpr, obj.debug)
+		if err == nil {
+			if containsString(poll.successValues, value) {
+				return nil
+			}
+			if containsString(poll.failureValues, value) {
+				return fmt.Errorf("operation at '%s' failed with status '%s': %s", statusURL, value, result)
+			}
+		}
+		if obj.debug {
+			log.Printf("api_object.go: Operation at '%s' is not done yet (status '%s')\n", statusURL, value)
+		}
+
+		if time.Now().Add(poll.interval).After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the operation at '%s' to finish (last status '%s')", poll.timeout, statusURL, value)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll.interval):
+		}
+	}
+}
+
+/*
+Finds the value that a block with a field or an expression checks.
+
+	A field is a path of keys separated by slashes and an expression is
+	JMESPath, such as status.conditions[?type=='Ready'].status | [0].
+	Values that are not strings, such as booleans, are compared as JSON.
+*/
+func lookupValue(data interface{}, field string, expression string, debug bool) (string, error) {
+	if expression != "" {
+		value, err := evalExpressionValue(data, expression)
+		if err != nil {
+			return "", err
+		}
+		return toExpressionString(value).(string), nil
+	}
+	hash, ok := data.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unable to find '%s' as the response is not a JSON object", field)
+	}
+	return GetStringAtKey(hash, field, debug)
+}
+
+/*
+Records the operation, URL and status code of the last request
+
+	for the object along with the capture_response_headers headers of
+	its response. Headers that are not in the response keep the value
+	they had, such as a Location header only sent on create.
+*/
+func (obj *APIObject) recordResponse(operation string, resp *apiClientResponse) {
+	obj.lastOperation = operation
+	obj.lastRequestURL = resp.url
+	obj.lastStatusCode = resp.statusCode
+	for _, name := range obj.captureHeaders {
+		if value := resp.headers.Get(name); value != "" {
+			obj.responseHeaders[name] = value
+		}
+	}
+}
+
+/*
+Records the version of the object from the response headers, or from
+
+	the object itself if version_field is set, so that it can be sent
+	as If-Match with the next update or delete.
+*/
+func (obj *APIObject) captureVersion(headers http.Header) {
+	if !obj.useIfMatch {
+		return
+	}
+
+	version := headers.Get(obj.versionHeader)
+	if obj.versionField != "" {
+		var err error
+		if version, err = getStringAtAttributePath(obj.apiData, obj.versionField, obj.debug); err != nil {
+			log.Printf("api_object.go: WARNING: Unable to find the version of '%s' at '%s': %v", obj.id, obj.versionField, err)
+			return
+		}
+	}
+
+	if version != "" {
+		if obj.debug {
+			log.Printf("api_object.go: Captured version '%s' for '%s'\n", version, obj.id)
+		}
+		obj.version = version
+	}
+}
+
+/* Adds an If-Match header with the known version of the object to the headers of a request */
+func (obj *APIObject) withIfMatch(headers map[string]string) map[string]string {
+	if !obj.useIfMatch || obj.version == "" {
+		return headers
+	}
+
+	/* If-Match takes entity tags, which are quoted */
+	version := obj.version
+	if !strings.HasPrefix(version, "\"") && !strings.HasPrefix(version, "W/\"") {
+		version = strconv.Quote(version)
+	}
+
+	withIfMatch := map[string]string{"If-Match": version}
+	for k, v := range headers {
+		withIfMatch[k] = v
+	}
+	return withIfMatch
+}
+
+/* Explains a 412 response to a request sent with If-Match */
+func (obj *APIObject) checkPreconditionFailed(resp *apiClientResponse, err error) error {
+	if obj.useIfMatch && resp.statusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("object '%s' changed remotely since it was last read (version '%s' no longer matches); refresh and apply again to review the changes: %v", obj.id, obj.version, err)
+	}
+	return err
+}
+
+/*
+Adds the response to a failed destroy to its error, as the object stays
+
+	in the state and the response is often the only clue to why it could
+	not be destroyed. Errors with details of their own are kept as they are.
+*/
+func (obj *APIObject) destroyError(resp *apiClientResponse, err error) error {
+	var apiErr *apiError
+	if resp.statusCode == 0 || errors.As(err, &apiErr) {
+		return err
+	}
+	summary := fmt.Sprintf("failed to destroy '%s' as the API server responded with status code %d", obj.id, resp.statusCode)
+	if _, ok := obj.errorMessages[resp.statusCode]; ok {
+		summary = err.Error()
+	}
+	return &apiError{
+		summary: summary,
+		detail:  fmt.Sprintf("The request to %s failed. The response was: %s", resp.url, truncateBody(resp.body)),
+	}
+}
+
+/*
+Decides whether a response is a success from the status codes
+
+	accepted for an operation. Without any, the client's default of
+	accepting any 2xx response stands.
+*/
+func checkStatusCode(resp *apiClientResponse, err error, successCodes []int) error {
+	/* The server could not be reached, so there is no status to check */
+	if len(successCodes) == 0 || resp.statusCode == 0 {
+		return err
+	}
+	if containsInt(successCodes, resp.statusCode) {
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("unexpected response code '%d': %s", resp.statusCode, resp.body)
+	}
+	return err
+}
+
+/*
+Checks the status code of a response like checkStatusCode and then
+
+	looks for an error in the body with error_expression, for APIs that
+	report errors with a successful status code. Responses that are not
+	JSON or that lack the field of the expression are not errors.
+*/
+func (obj *APIObject) checkResponse(resp *apiClientResponse, err error, successCodes []int) error {
+	if err := checkStatusCode(resp, err, successCodes); err != nil {
+		return obj.friendlyError(resp, err)
+	}
+	if obj.errorExpression == nil {
+		return nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(resp.body), &body); err != nil {
+		return nil
+	}
+	value, err := evalExpression(body, obj.errorExpression.expression)
+	if err != nil {
+		return nil
+	}
+	if len(obj.errorExpression.errorValues) > 0 && !containsString(obj.errorExpression.errorValues, value) {
+		return nil
+	}
+	if len(obj.errorExpression.successValues) > 0 && containsString(obj.errorExpression.successValues, value) {
+		return nil
+	}
+
+	message := resp.body
+	if obj.errorExpression.messageExpression != "" {
+		if m, err := evalExpression(body, obj.errorExpression.messageExpression); err == nil {
+			message = m
+		}
+	}
+	return fmt.Errorf("the API reported an error with status code %d and %s '%s': %s", resp.statusCode, obj.errorExpression.expression, value, message)
+}
+
+/*
+Replaces the error for a status code with the message set by an
+
+	error_message block, so operators see what went wrong and what to do
+	about it. The response is still logged for troubleshooting.
+*/
+func (obj *APIObject) friendlyError(resp *apiClientResponse, err error) error {
+	friendly, ok := obj.errorMessages[resp.statusCode]
+	if !ok {
+		return obj.detailedError(resp, err)
+	}
+	log.Printf("api_object.go: Replacing the error for status code %d with its error_message: %v", resp.statusCode, err)
+	if friendly.hint != "" {
+		return fmt.Errorf("%s (status code %d)\nHint: %s", friendly.message, resp.statusCode, friendly.hint)
+	}
+	return fmt.Errorf("%s (status code %d)", friendly.message, resp.statusCode)
+}
+
+/*
+Finds the details of a failed request in the response with
+
+	error_detail_expression, so they can be shown instead of the whole
+	response. The error is kept as it is if none are found.
+*/
+func (obj *APIObject) detailedError(resp *apiClientResponse, err error) error {
+	if obj.errorDetailsExpr == "" || resp.statusCode == 0 {
+		return err
+	}
+
+	var body interface{}
+	if json.Unmarshal([]byte(resp.body), &body) != nil {
+		return err
+	}
+	res, evalErr := evalExpressionValue(body, obj.errorDetailsExpr)
+	if evalErr != nil {
+		log.Printf("api_object.go: Unable to find error_detail_expression in the response: %v", evalErr)
+		return err
+	}
+	var details []string
+	switch v := res.(type) {
+	case []interface{}:
+		for _, detail := range v {
+			details = append(details, toExpressionString(detail).(string))
+		}
+	default:
+		details = append(details, toExpressionString(v).(string))
+	}
+	if len(details) == 0 {
+		return err
+	}
+
+	return &apiError{
+		summary: fmt.Sprintf("request failed with status code %d: %s", resp.statusCode, strings.Join(details, "; ")),
+		detail:  fmt.Sprintf("The request to %s failed. The response was: %s", resp.url, truncateBody(resp.body)),
+	}
+}
+
+/*
+Serializes data into a request body according to body_format and
+
+	returns any headers that must accompany it. The content of data_file,
+	ndjson_data and multipart file parts are only used if withFiles is set.
+*/
+func (obj *APIObject) encodeBody(data interface{}, withFiles bool) (string, map[string]string, error) {
+	if withFiles && obj.dataFile != "" {
+		content, err := os.ReadFile(obj.dataFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("api_object.go: unable to read data_file: %v", err)
+		}
+		sum := sha256.Sum256(content)
+		obj.dataFileSHA256 = hex.EncodeToString(sum[:])
+
+		contentType := obj.dataFileContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return string(content), map[string]string{"Content-Type": contentType}, nil
+	}
+
+	if withFiles && obj.bodyFormat == "ndjson" {
+		return ndjsonEncode(obj.ndjsonData)
+	}
+
+	hash, isObject := data.(map[string]interface{})
+	if !isObject && data != nil && (obj.bodyFormat == "multipart" || obj.bodyFormat == "form") {
+		return "", nil, fmt.Errorf("api_object.go: body_format %s requires data to be a JSON object", obj.bodyFormat)
+	}
+
+	switch obj.bodyFormat {
+	case "multipart":
+		files := obj.multipartFiles
+		if !withFiles {
+			files = nil
+		}
+		return multipartEncode(hash, files)
+	case "form":
+		values, err := formEncode(hash)
+		if err != nil {
+			return "", nil, err
+		}
+		return values.Encode(), map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, nil
+	default:
+		if data == nil {
+			return "", nil, nil
+		}
+		b, err := json.Marshal(data)
+		return string(b), nil, err
+	}
+}
+
+/*
+Parses data provided as JSON. Objects are stored in hash so that keys
+
+	can be looked up as usual, while arrays and scalars are returned
+	as they are.
+*/
+func parseDataValue(in string, hash map[string]interface{}) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(in), &value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			hash[key] = val
+		}
+		return nil, nil
+	case nil:
+		return nil, nil
+	default:
+		return v, nil
+	}
+}
+
+/* Returns the data to send on create and update, which is nil if no data was provided */
+func (obj *APIObject) requestData() interface{} {
+	if obj.emptyData {
+		return nil
+	}
+	return dataOrValue(obj.data, obj.dataValue)
+}
+
+/*
+Applies null_handling to the fields of the data of a request. Nulls are
+
+	sent as they are by default. They can also be left out, or be replaced
+	by null_sentinel in update requests so that the API clears the field.
+*/
+func (obj *APIObject) handleNulls(data interface{}, update bool) interface{} {
+	if obj.nullHandling != "omit" && obj.nullHandling != "unset" {
+		return data
+	}
+	return replaceNulls(data, obj.nullHandling == "unset" && update, obj.nullSentinel)
+}
+
+/* Items of lists are kept as they are, as leaving them out would shift the others */
+func replaceNulls(value interface{}, replace bool, sentinel interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		replaced := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if val == nil {
+				if replace {
+					replaced[key] = sentinel
+				}
+				continue
+			}
+			replaced[key] = replaceNulls(val, replace, sentinel)
+		}
+		return replaced
+	case []interface{}:
+		replaced := make([]interface{}, len(v))
+		for i, item := range v {
+			replaced[i] = replaceNulls(item, replace, sentinel)
+		}
+		return replaced
+	default:
+		return value
+	}
+}
+
+/*
+Whether the data sent is a JSON object or array that can be compared
+
+	with what the API returns to detect changes made outside of Terraform
+*/
+func (obj *APIObject) detectsDrift() bool {
+	if obj.dataFile != "" || obj.bodyFormat == "ndjson" || obj.emptyData {
+		return false
+	}
+	switch obj.dataValue.(type) {
+	case nil, []interface{}:
+		return true
+	}
+	return false
+}
+
+/* Returns the value of some data if it is not a JSON object, or the object otherwise */
+func dataOrValue(hash map[string]interface{}, value interface{}) interface{} {
+	if value != nil {
+		return value
+	}
+	return hash
+}
+
+/* Serializes documents as newline-delimited JSON, one compacted document per line */
+func ndjsonEncode(documents []string) (string, map[string]string, error) {
+	var buffer bytes.Buffer
+	for i, doc := range documents {
+		if err := json.Compact(&buffer, []byte(doc)); err != nil {
+			return "", nil, fmt.Errorf("api_object.go: ndjson_data document %d is invalid JSON: %v", i, err)
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.String(), map[string]string{"Content-Type": "application/x-ndjson"}, nil
+}
+
+/*
+Checks the per-item results of a bulk request. The response may either
+
+	be a JSON object with an items array (as Elasticsearch does) or
+	newline-delimited JSON with one result per line. An item fails if it
+	has an error or a status of 300 or above, either directly or nested
+	under the action it reports on (such as {"index": {"status": 400}}).
+*/
+func checkBulkResponse(response string) error {
+	var items []interface{}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(response), &result); err == nil {
+		if v, ok := result["items"].([]interface{}); ok {
+			items = v
+		} else {
+			items = []interface{}{result}
+		}
+	} else {
+		for _, line := range strings.Split(response, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			var item interface{}
+			if err := json.Unmarshal([]byte(line), &item); err != nil {
+				return fmt.Errorf("api_object.go: unable to parse bulk response line '%s': %v", line, err)
+			}
+			items = append(items, item)
+		}
+	}
+
+	var failures []string
+	for i, item := range items {
+		if bulkItemFailed(item) {
+			b, _ := json.Marshal(item)
+			failures = append(failures, fmt.Sprintf("item %d: %s", i, string(b)))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("bulk request had %d failed items: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func bulkItemFailed(item interface{}) bool {
+	hash, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if e, ok := hash["error"]; ok && e != nil && e != false {
+		return true
+	}
+	if status, ok := hash["status"].(float64); ok && status >= 300 {
+		return true
+	}
+	for _, v := range hash {
+		if nested, ok := v.(map[string]interface{}); ok {
+			if _, ok := nested["status"]; ok && bulkItemFailed(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+Converts a JSON object to form values. Arrays become repeated keys
+
+	and nested objects are sent as their JSON representation, as
+	there is no common convention for them in form posts.
+*/
+func formEncode(data map[string]interface{}) (url.Values, error) {
+	values := url.Values{}
+	for k, v := range data {
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		for _, item := range items {
+			switch val := item.(type) {
+			case nil:
+				values.Add(k, "")
+			case string:
+				values.Add(k, val)
+			case float64:
+				values.Add(k, strconv.FormatFloat(val, 'f', -1, 64))
+			case bool:
+				values.Add(k, strconv.FormatBool(val))
+			default:
+				b, err := json.Marshal(val)
+				if err != nil {
+					return values, fmt.Errorf("api_object.go: unable to form encode key '%s': %v", k, err)
+				}
+				values.Add(k, string(b))
+			}
+		}
+	}
+	return values, nil
+}
+
+/*
+Builds a multipart/form-data body out of the keys in data (encoded
+
+	the same way as for form bodies) and the given file parts
+*/
+func multipartEncode(data map[string]interface{}, files []multipartFile) (string, map[string]string, error) {
+	values, err := formEncode(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if err := writer.WriteField(k, v); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	for _, f := range files {
+		content := []byte(f.content)
+		filename := f.filename
+		if f.path != "" {
+			content, err = os.ReadFile(f.path)
+			if err != nil {
+				return "", nil, fmt.Errorf("api_object.go: unable to read file for multipart part '%s': %v", f.name, err)
+			}
+			if filename == "" {
+				filename = filepath.Base(f.path)
+			}
+		}
+		if filename == "" {
+			filename = f.name
+		}
+		contentType := f.contentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(f.name), escapeQuotes(filename)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := part.Write(content); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", nil, err
+	}
+	return buffer.String(), map[string]string{"Content-Type": writer.FormDataContentType()}, nil
+}
+
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+/*
+Fetches the records at searchPath, from results_key of the response if
+
+	it is set. With pagination, the records of all of the pages are
+	fetched. Also returns the path that was searched, for errors.
+*/
+func (obj *APIObject) listObjects(ctx context.Context, queryString string, resultsKey string) ([]interface{}, string, error) {
+	var dataArray []interface{}
+
+	/*
+	   Issue a GET to the base path and expect results to come back
+	*/
+	searchPath := obj.searchPath
+	if queryString != "" {
+		if obj.debug {
+			log.Printf("api_object.go: Adding query string '%s'", queryString)
+		}
+		searchPath = fmt.Sprintf("%s?%s", obj.searchPath, queryString)
+	}
+
+	pagePath := searchPath
+	if p := obj.pagination; p != nil {
+		if p.limitParam != "" && p.limit > 0 {
+			searchPath = withQueryParam(searchPath, p.limitParam, strconv.Itoa(p.limit))
+		}
+		pagePath = searchPath
+		if p.mode == "page" {
+			pagePath = withQueryParam(searchPath, p.pageParam, strconv.Itoa(p.firstPage))
+		}
+	}
+
+	for page := 1; pagePath != ""; page++ {
+		if obj.debug {
+			log.Printf("api_object.go: Calling API on path '%s'", pagePath)
+		}
+		resp, err := obj.sendReadRequest(ctx, obj.apiClient.readMethod, pagePath)
+		if err != nil {
+			if isTransientResponse(resp) {
+				return dataArray, searchPath, &transientError{err: err}
+			}
+			return dataArray, searchPath, err
+		}
+
+		result, items, err := obj.parseResults(resp.body, pagePath, resultsKey)
+		if err != nil {
+			return dataArray, searchPath, err
+		}
+		dataArray = append(dataArray, items...)
+
+		if obj.pagination == nil {
+			break
+		}
+		pagePath, err = obj.nextPagePath(searchPath, page, resp, result, items)
+		if err != nil {
+			return dataArray, searchPath, err
+		}
+		if pagePath != "" && page >= obj.pagination.maxPages {
+			return dataArray, searchPath, fmt.Errorf("api_object.go: '%s' has more than max_pages %d pages", searchPath, obj.pagination.maxPages)
+		}
+	}
+
+	return dataArray, searchPath, nil
+}
+
+/* Parses the records out of the response to a GET to searchPath */
+func (obj *APIObject) parseResults(body string, searchPath string, resultsKey string) (interface{}, []interface{}, error) {
+	var dataArray []interface{}
+	var ok bool
+
+	/*
+	   Parse it seeking JSON data
+	*/
+	if obj.debug {
+		log.Printf("api_object.go: Response received... parsing")
+	}
+	var result interface{}
+	err := json.Unmarshal([]byte(body), &result)
+	if err != nil {
+		return result, dataArray, err
+	}
+
+	if resultsKey != "" {
+		var tmp interface{}
+
+		if obj.debug {
+			log.Printf("api_object.go: Locating '%s' in the results", resultsKey)
+		}
+
+		/* First verify the data we got back is a hash */
+		if _, ok = result.(map[string]interface{}); !ok {
+			return result, dataArray, fmt.Errorf("api_object.go: The results of a GET to '%s' did not return a hash. Cannot search within for results_key '%s'", searchPath, resultsKey)
+		}
+
+		tmp, err = GetObjectAtKey(result.(map[string]interface{}), resultsKey, obj.debug)
+		if err != nil {
+			return result, dataArray, fmt.Errorf("api_object.go: Error finding results_key: %s", err)
+		}
+		if dataArray, ok = tmp.([]interface{}); !ok {
+			return result, dataArray, fmt.Errorf("api_object.go: The data at results_key location '%s' is not an array. It is a '%s'", resultsKey, reflect.TypeOf(tmp))
 		}
 	} else {
 		if obj.debug {
 			log.Printf("api_object.go: results_key is not set - coaxing data to array of interfaces")
 		}
 		if dataArray, ok = result.([]interface{}); !ok {
-			return objFound, fmt.Errorf("api_object.go: The results of a GET to '%s' did not return an array. It is a '%s'. Perhaps you meant to add a results_key?", searchPath, reflect.TypeOf(result))
+			return result, dataArray, fmt.Errorf("api_object.go: The results of a GET to '%s' did not return an array. It is a '%s'. Perhaps you meant to add a results_key?", searchPath, reflect.TypeOf(result))
 		}
 	}
 
+	return result, dataArray, nil
+}
+
+/*
+Finds the path of the page after the given one, or "" if it was the
+
+	last page, according to the mode of pagination
+*/
+func (obj *APIObject) nextPagePath(searchPath string, page int, resp *apiClientResponse, result interface{}, items []interface{}) (string, error) {
+	p := obj.pagination
+	switch p.mode {
+	case "page":
+		/* A short or empty page is the last one */
+		if len(items) == 0 || (p.limit > 0 && len(items) < p.limit) {
+			return "", nil
+		}
+		return withQueryParam(searchPath, p.pageParam, strconv.Itoa(p.firstPage+page)), nil
+	case "cursor":
+		hash, ok := result.(map[string]interface{})
+		if !ok {
+			return "", nil
+		}
+		cursor, err := GetStringAtKey(hash, p.cursorField, obj.debug)
+		if err != nil || cursor == "" {
+			return "", nil
+		}
+		/* Some APIs give the URL of the next page instead of a cursor */
+		if strings.HasPrefix(cursor, "http://") || strings.HasPrefix(cursor, "https://") || strings.HasPrefix(cursor, "/") {
+			return obj.apiClient.resolveURL(cursor)
+		}
+		return withQueryParam(searchPath, p.cursorParam, cursor), nil
+	case "link":
+		next := nextLink(resp.headers.Values("Link"))
+		if next == "" {
+			return "", nil
+		}
+		return obj.apiClient.resolveURL(next)
+	}
+	return "", fmt.Errorf("api_object.go: unknown pagination mode '%s'", p.mode)
+}
+
+func (obj *APIObject) findObject(ctx context.Context, queryString string, searchKey string, searchValue string, resultsKey string) (map[string]interface{}, error) {
+	return obj.findObjectMatching(ctx, queryString, []searchCriterion{{key: searchKey, value: searchValue}}, "and", resultsKey)
+}
+
+/*
+Like findObject, but finds the record that matches all of the criteria
+
+	when operator is "and" or any of them when it is "or"
+*/
+func (obj *APIObject) findObjectMatching(ctx context.Context, queryString string, criteria []searchCriterion, operator string, resultsKey string) (map[string]interface{}, error) {
+	var objFound map[string]interface{}
+	var ok bool
+
+	dataArray, searchPath, err := obj.listObjects(ctx, queryString, resultsKey)
+	if err != nil {
+		return objFound, err
+	}
+
 	/* Loop through all of the results seeking the specific record */
 	for _, item := range dataArray {
 		var hash map[string]interface{}
@@ -522,20 +2443,19 @@ func (obj *APIObject) findObject(ctx context.Context, queryString string, search
 
 		if obj.debug {
 			log.Printf("api_object.go: Examining %v", hash)
-			log.Printf("api_object.go:   Comparing '%s' to the value in '%s'", searchValue, searchKey)
 		}
 
-		tmp, err := GetStringAtKey(hash, searchKey, obj.debug)
+		matched, err := obj.matchesCriteria(hash, criteria, operator, resultsKey)
 		if err != nil {
-			return objFound, (fmt.Errorf("failed to get the value of '%s' in the results array at '%s': %s", searchKey, resultsKey, err))
+			return objFound, err
 		}
 
 		/* We found our record */
-		if tmp == searchValue {
+		if matched {
 			objFound = hash
-			obj.id, err = GetStringAtKey(hash, obj.idAttribute, obj.debug)
+			obj.id, err = obj.idFromData(hash)
 			if err != nil {
-				return objFound, (fmt.Errorf("failed to find id_attribute '%s' in the record: %s", obj.idAttribute, err))
+				return objFound, (fmt.Errorf("failed to find the id of the record: %s", err))
 			}
 
 			if obj.debug {
@@ -544,15 +2464,78 @@ func (obj *APIObject) findObject(ctx context.Context, queryString string, search
 
 			/* But there is no id attribute??? */
 			if obj.id == "" {
-				return objFound, (fmt.Errorf(fmt.Sprintf("The object for '%s'='%s' did not have the id attribute '%s', or the value was empty.", searchKey, searchValue, obj.idAttribute)))
+				return objFound, (fmt.Errorf(fmt.Sprintf("The object for %s did not have the id attribute '%s', or the value was empty.", describeCriteria(criteria, operator, "'%s'='%s'"), obj.idAttribute)))
 			}
 			break
 		}
 	}
 
 	if obj.id == "" {
-		return objFound, (fmt.Errorf("failed to find an object with the '%s' key = '%s' at %s", searchKey, searchValue, searchPath))
+		return objFound, &notFoundError{criteria: describeCriteria(criteria, operator, "the '%s' key = '%s'"), searchPath: searchPath}
 	}
 
 	return objFound, nil
 }
+
+/*
+Whether a search result holds the values of all of the criteria, or of
+
+	any of them when operator is "or". A result that lacks the key of a
+	criterion does not match it, unless it is the only criterion, which
+	fails the search as it always has.
+*/
+func (obj *APIObject) matchesCriteria(hash map[string]interface{}, criteria []searchCriterion, operator string, resultsKey string) (bool, error) {
+	for _, c := range criteria {
+		if obj.debug {
+			log.Printf("api_object.go:   Comparing '%s' to the value in '%s'", c.value, c.key)
+		}
+
+		/* Other matches also compare values that are not strings or numbers */
+		var value interface{}
+		var err error
+		if c.match == "" || c.match == "exact" {
+			value, err = GetStringAtKey(hash, c.key, obj.debug)
+		} else {
+			value, err = GetObjectAtKey(hash, c.key, obj.debug)
+		}
+		if err != nil && len(criteria) == 1 {
+			return false, (fmt.Errorf("failed to get the value of '%s' in the results array at '%s': %s", c.key, resultsKey, err))
+		}
+
+		matched := false
+		if err == nil {
+			if matched, err = matchesSearchValue(c.match, value, c.value); err != nil {
+				return false, err
+			}
+		}
+		if operator == "or" && matched {
+			return true, nil
+		}
+		if operator != "or" && !matched {
+			return false, nil
+		}
+	}
+	return operator != "or", nil
+}
+
+/* The criterion of read_search */
+func (obj *APIObject) readSearchCriteria() []searchCriterion {
+	return []searchCriterion{{
+		key:   obj.readSearch["search_key"],
+		value: obj.readSearch["search_value"],
+		match: obj.readSearch["match"],
+	}}
+}
+
+/* Describes the criteria of a search for errors, one per format */
+func describeCriteria(criteria []searchCriterion, operator string, format string) string {
+	descriptions := make([]string, 0, len(criteria))
+	for _, c := range criteria {
+		description := fmt.Sprintf(format, c.key, c.value)
+		if c.match != "" && c.match != "exact" {
+			description = fmt.Sprintf("%s (match %s)", description, c.match)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, " "+operator+" ")
+}
@@ -3,9 +3,17 @@ package restapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Mastercard/terraform-provider-restapi/fakeserver"
 )
@@ -199,6 +207,44 @@ func TestAPIObject(t *testing.T) {
 		}
 	})
 
+	/* Objects can copy other keys than the client */
+	t.Run("copy_keys_override", func(t *testing.T) {
+		object, err := NewAPIObject(client, &apiObjectOpts{
+			path:     "/api/objects",
+			data:     `{ "Id": "1" }`,
+			copyKeys: []string{"Revision"},
+			debug:    apiObjectDebug,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object with copy_keys")
+		}
+		if err := object.readObject(ctx); err != nil {
+			t.Fatalf("api_object_test.go: Failed to read object with copy_keys: %s", err)
+		}
+		if _, ok := object.data["Thing"]; ok || object.data["Revision"] != float64(1) {
+			t.Fatalf("api_object_test.go: Expected only 'Revision' to be copied but got '%+v'\n", object.data)
+		}
+	})
+
+	/* An empty list turns off the copy_keys of the client */
+	t.Run("copy_keys_off", func(t *testing.T) {
+		object, err := NewAPIObject(client, &apiObjectOpts{
+			path:     "/api/objects",
+			data:     `{ "Id": "1" }`,
+			copyKeys: []string{},
+			debug:    apiObjectDebug,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object without copy_keys")
+		}
+		if err := object.readObject(ctx); err != nil {
+			t.Fatalf("api_object_test.go: Failed to read object without copy_keys: %s", err)
+		}
+		if _, ok := object.data["Thing"]; ok {
+			t.Fatalf("api_object_test.go: Expected no keys to be copied but got '%+v'\n", object.data)
+		}
+	})
+
 	/* Go ahead and update one of our objects */
 	t.Run("update_object", func(t *testing.T) {
 		if testDebug {
@@ -293,6 +339,57 @@ func TestAPIObject(t *testing.T) {
 		}
 	})
 
+	/* A search without a match is told apart from other failures by its type */
+	t.Run("find_object_missing", func(t *testing.T) {
+		object, err := NewAPIObject(client, &apiObjectOpts{
+			path:       "/api/objects",
+			readSearch: map[string]string{"search_key": "Thing", "search_value": "unicorn"},
+			debug:      apiObjectDebug,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object to find")
+		}
+		_, err = object.findObject(ctx, "", "Thing", "unicorn", "")
+		var notFound *notFoundError
+		if !errors.As(err, &notFound) {
+			t.Fatalf("api_object_test.go: Expected a notFoundError for a search without a match but got: %v", err)
+		}
+		if exists, err := object.existsBeforeCreate(ctx); exists || err != nil {
+			t.Fatalf("api_object_test.go: Expected an object that is not found not to exist before create but got %t: %v", exists, err)
+		}
+	})
+
+	/* Create the 'pet' object again while it already exists */
+	t.Run("create_conflict_behavior", func(t *testing.T) {
+		for _, behavior := range []string{"fail", "adopt", "update"} {
+			object, err := NewAPIObject(client, &apiObjectOpts{
+				path:           "/api/objects",
+				data:           `{ "Id": "5", "Is_cat": false }`,
+				createConflict: behavior,
+				debug:          apiObjectDebug,
+			})
+			if err != nil {
+				t.Fatalf("api_object_test.go: Failed to create new api_object for create_conflict_behavior '%s'", behavior)
+			}
+
+			err = object.createObject(ctx)
+			switch behavior {
+			case "fail":
+				if err == nil || !strings.Contains(err.Error(), "already exists") {
+					t.Fatalf("api_object_test.go: Expected create to fail for an existing object but got: %v", err)
+				}
+			case "adopt":
+				if _, ok := object.apiData["Is_cat"]; err != nil || ok {
+					t.Fatalf("api_object_test.go: Expected the existing object to be adopted unchanged but got '%v': %v", object.apiData["Is_cat"], err)
+				}
+			case "update":
+				if err != nil || object.apiData["Is_cat"] != false {
+					t.Fatalf("api_object_test.go: Expected the existing object to be updated but got '%v': %v", object.apiData["Is_cat"], err)
+				}
+			}
+		}
+	})
+
 	/* Delete it again with destroy_data and make sure a 404 follows */
 	t.Run("delete_object_with_destroy_data", func(t *testing.T) {
 		if testDebug {
@@ -314,3 +411,1874 @@ func TestAPIObject(t *testing.T) {
 		log.Println("api_object_test.go: Done")
 	}
 }
+
+func TestFormEncode(t *testing.T) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(`{ "name": "foo", "count": 5, "enabled": true, "tags": ["a", "b"], "meta": { "x": 1 } }`), &data); err != nil {
+		t.Fatalf("api_object_test.go: Error unmarshalling JSON: %s", err)
+	}
+
+	values, err := formEncode(data)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to form encode data: %s", err)
+	}
+
+	expected := "count=5&enabled=true&meta=%7B%22x%22%3A1%7D&name=foo&tags=a&tags=b"
+	if values.Encode() != expected {
+		t.Fatalf("api_object_test.go: Expected form body '%s' but got '%s'", expected, values.Encode())
+	}
+}
+
+func TestMultipartEncode(t *testing.T) {
+	data := map[string]interface{}{"name": "foo"}
+	files := []multipartFile{
+		{name: "cert", content: "-----BEGIN CERTIFICATE-----", filename: "cert.pem", contentType: "application/x-pem-file"},
+	}
+
+	body, headers, err := multipartEncode(data, files)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to build multipart body: %s", err)
+	}
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", headers["Content-Type"])
+	if err := req.ParseMultipartForm(1024); err != nil {
+		t.Fatalf("api_object_test.go: Generated body is not valid multipart: %s", err)
+	}
+	if req.FormValue("name") != "foo" {
+		t.Fatalf("api_object_test.go: Expected field 'name' to be 'foo' but got '%s'", req.FormValue("name"))
+	}
+	file, header, err := req.FormFile("cert")
+	if err != nil {
+		t.Fatalf("api_object_test.go: File part 'cert' missing: %s", err)
+	}
+	content, _ := io.ReadAll(file)
+	if header.Filename != "cert.pem" || string(content) != "-----BEGIN CERTIFICATE-----" {
+		t.Fatalf("api_object_test.go: Unexpected file part '%s' with content '%s'", header.Filename, string(content))
+	}
+}
+
+func TestEncodeBodyDataFile(t *testing.T) {
+	f, err := os.CreateTemp("", "restapi-data-file")
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create temp file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	f.Write([]byte{0x1f, 0x8b, 0x00, 0xff})
+	f.Close()
+
+	obj, err := NewAPIObject(client, &apiObjectOpts{
+		path:                "/api/objects",
+		id:                  "1",
+		dataFile:            f.Name(),
+		dataFileContentType: "application/gzip",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	body, headers, err := obj.encodeBody(obj.data, true)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to encode data_file: %s", err)
+	}
+	if body != string([]byte{0x1f, 0x8b, 0x00, 0xff}) {
+		t.Fatalf("api_object_test.go: Body does not match the content of data_file: %v", []byte(body))
+	}
+	if headers["Content-Type"] != "application/gzip" {
+		t.Fatalf("api_object_test.go: Expected Content-Type 'application/gzip' but got '%s'", headers["Content-Type"])
+	}
+	if sum, _ := fileSHA256(f.Name()); obj.dataFileSHA256 != sum {
+		t.Fatalf("api_object_test.go: Expected checksum '%s' but got '%s'", sum, obj.dataFileSHA256)
+	}
+}
+
+func TestNDJSONEncode(t *testing.T) {
+	body, headers, err := ndjsonEncode([]string{`{ "index": { "_id": "1" } }`, "{\n  \"field\": \"value\"\n}"})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to encode ndjson: %s", err)
+	}
+	expected := "{\"index\":{\"_id\":\"1\"}}\n{\"field\":\"value\"}\n"
+	if body != expected {
+		t.Fatalf("api_object_test.go: Expected ndjson body '%s' but got '%s'", expected, body)
+	}
+	if headers["Content-Type"] != "application/x-ndjson" {
+		t.Fatalf("api_object_test.go: Expected Content-Type 'application/x-ndjson' but got '%s'", headers["Content-Type"])
+	}
+
+	if _, _, err := ndjsonEncode([]string{`{ "broken": `}); err == nil {
+		t.Fatalf("api_object_test.go: Expected invalid ndjson documents to fail")
+	}
+}
+
+func TestCheckBulkResponse(t *testing.T) {
+	testCases := []struct {
+		response string
+		fails    bool
+	}{
+		{`{"took": 3, "errors": false, "items": [{"index": {"_id": "1", "status": 201}}]}`, false},
+		{`{"took": 3, "errors": true, "items": [{"index": {"_id": "1", "status": 201}}, {"create": {"_id": "2", "status": 409, "error": {"type": "version_conflict_engine_exception"}}}]}`, true},
+		{"{\"id\": \"1\", \"status\": 200}\n{\"id\": \"2\", \"status\": 200}\n", false},
+		{"{\"id\": \"1\", \"status\": 200}\n{\"id\": \"2\", \"error\": \"invalid document\"}\n", true},
+		{`{"acknowledged": true}`, false},
+	}
+
+	for _, testCase := range testCases {
+		err := checkBulkResponse(testCase.response)
+		if testCase.fails && err == nil {
+			t.Fatalf("api_object_test.go: Expected bulk response to fail: %s", testCase.response)
+		} else if !testCase.fails && err != nil {
+			t.Fatalf("api_object_test.go: Expected bulk response to succeed: %s", err)
+		}
+	}
+}
+
+func TestArrayData(t *testing.T) {
+	obj, err := NewAPIObject(client, &apiObjectOpts{
+		path:       "/api/rules",
+		id:         "firewall",
+		data:       `[{ "port": 22 }, { "port": 443 }]`,
+		updateData: `[{ "port": 443 }]`,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object with array data: %s", err)
+	}
+	if len(obj.data) != 0 {
+		t.Fatalf("api_object_test.go: Expected array data to leave the object data empty but got '%v'", obj.data)
+	}
+
+	body, _, err := obj.encodeBody(dataOrValue(obj.data, obj.dataValue), true)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to encode array data: %s", err)
+	}
+	if body != `[{"port":22},{"port":443}]` {
+		t.Fatalf("api_object_test.go: Unexpected body for array data: %s", body)
+	}
+
+	obj.bodyFormat = "form"
+	if _, _, err := obj.encodeBody(dataOrValue(obj.updateData, obj.updateDataValue), true); err == nil {
+		t.Fatalf("api_object_test.go: Expected form encoding of array data to fail")
+	}
+}
+
+func TestEmptyAndScalarData(t *testing.T) {
+	testCases := []struct {
+		data string
+		body string
+	}{
+		{"", ""},
+		{`"enabled"`, `"enabled"`},
+		{"true", "true"},
+		{"42", "42"},
+	}
+
+	for _, testCase := range testCases {
+		obj, err := NewAPIObject(client, &apiObjectOpts{
+			path: "/api/rules/{id}/enable",
+			id:   "firewall",
+			data: testCase.data,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object with data '%s': %s", testCase.data, err)
+		}
+		if obj.detectsDrift() {
+			t.Fatalf("api_object_test.go: Expected drift detection to be disabled for data '%s'", testCase.data)
+		}
+
+		body, _, err := obj.encodeBody(obj.requestData(), true)
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to encode data '%s': %s", testCase.data, err)
+		}
+		if body != testCase.body {
+			t.Fatalf("api_object_test.go: Expected body '%s' for data '%s' but got '%s'", testCase.body, testCase.data, body)
+		}
+
+		/* Action-style endpoints may not respond with JSON at all */
+		if err := obj.updateState("OK"); err != nil {
+			t.Fatalf("api_object_test.go: Expected a response that is not JSON to be accepted: %s", err)
+		}
+	}
+}
+
+func TestMergePatchUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	var method, contentType, body string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			b, _ := io.ReadAll(r.Body)
+			method, contentType, body = r.Method, r.Header.Get("Content-Type"), string(b)
+		}
+		w.Write([]byte(`{ "Id": "1", "Thing": "spoon", "Managed_by_server": true }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	patchClient, _ := NewAPIClient(&apiClientOpt{
+		uri:          svr.URL,
+		headers:      make(map[string]string),
+		timeout:      2,
+		idAttribute:  "Id",
+		updateMethod: "PUT",
+		rateLimit:    10,
+	})
+
+	obj, err := NewAPIObject(patchClient, &apiObjectOpts{
+		path:           "/api/objects",
+		data:           `{ "Id": "1", "Thing": "spoon" }`,
+		previousData:   `{ "Id": "1", "Thing": "fork", "Is_cat": false }`,
+		updateStrategy: "merge_patch",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	if err := obj.updateObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to update object with a merge patch: %s", err)
+	}
+	if method != "PATCH" || contentType != "application/merge-patch+json" {
+		t.Fatalf("api_object_test.go: Expected a PATCH with merge patch Content-Type but got %s with '%s'", method, contentType)
+	}
+	if body != `{"Is_cat":null,"Thing":"spoon"}` {
+		t.Fatalf("api_object_test.go: Unexpected merge patch sent: %s", body)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	ctx := context.Background()
+
+	etag := `"v1"`
+	var ifMatch string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Method != "GET" {
+			ifMatch = r.Header.Get("If-Match")
+			if ifMatch != etag {
+				http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+				return
+			}
+		}
+		w.Write([]byte(`{ "Id": "1", "Thing": "spoon" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	etagClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		idAttribute:        "Id",
+		updateMethod:       "PUT",
+		destroyMethod:      "DELETE",
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(etagClient, &apiObjectOpts{
+		path:       "/api/objects",
+		id:         "1",
+		data:       `{ "Id": "1", "Thing": "spoon" }`,
+		useIfMatch: true,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read object: %s", err)
+	}
+	if obj.version != `"v1"` {
+		t.Fatalf("api_object_test.go: Expected the ETag to be captured on read but got '%s'", obj.version)
+	}
+	if err := obj.updateObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to update object: %s", err)
+	}
+	if ifMatch != `"v1"` {
+		t.Fatalf("api_object_test.go: Expected If-Match to be sent on update but got '%s'", ifMatch)
+	}
+
+	/* Someone else changed the object since it was last read */
+	etag = `"v2"`
+	err = obj.deleteObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), "changed remotely") {
+		t.Fatalf("api_object_test.go: Expected a 412 to be reported as a remote change but got: %v", err)
+	}
+}
+
+func TestCaptureVersionField(t *testing.T) {
+	for _, field := range []string{"metadata/resourceVersion", "metadata.resourceVersion"} {
+		obj := &APIObject{
+			useIfMatch:   true,
+			versionField: field,
+			apiData:      map[string]interface{}{"metadata": map[string]interface{}{"resourceVersion": "42"}},
+		}
+		obj.captureVersion(http.Header{"Etag": []string{`"v1"`}})
+		if obj.version != "42" {
+			t.Fatalf("api_object_test.go: Expected the version to be taken from version_field '%s' but got '%s'", field, obj.version)
+		}
+	}
+}
+
+func TestCreateIfNoneMatch(t *testing.T) {
+	ctx := context.Background()
+
+	var ifNoneMatch string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = r.Header.Get("If-None-Match")
+		http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+	})
+	svr := newTestServer(t, serverMux)
+
+	guardClient, _ := NewAPIClient(&apiClientOpt{
+		uri:          svr.URL,
+		headers:      make(map[string]string),
+		timeout:      2,
+		idAttribute:  "Id",
+		createMethod: "POST",
+		rateLimit:    10,
+	})
+
+	obj, err := NewAPIObject(guardClient, &apiObjectOpts{
+		path:              "/api/objects",
+		data:              `{ "Id": "1", "Thing": "spoon" }`,
+		createIfNoneMatch: true,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	err = obj.createObject(ctx)
+	if ifNoneMatch != "*" {
+		t.Fatalf("api_object_test.go: Expected If-None-Match to be sent on create but got '%s'", ifNoneMatch)
+	}
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("api_object_test.go: Expected a 412 to be reported as an existing object but got: %v", err)
+	}
+}
+
+func TestCheckStatusCode(t *testing.T) {
+	failed := fmt.Errorf("unexpected response code '404': Not Found")
+	testCases := []struct {
+		statusCode   int
+		err          error
+		successCodes []int
+		success      bool
+	}{
+		{200, nil, nil, true},
+		{404, failed, nil, false},
+		{404, failed, []int{200, 204, 404}, true},
+		{200, nil, []int{201, 202}, false},
+		{202, nil, []int{201, 202}, true},
+		{0, failed, []int{200}, false},
+	}
+
+	for _, testCase := range testCases {
+		resp := &apiClientResponse{statusCode: testCase.statusCode}
+		err := checkStatusCode(resp, testCase.err, testCase.successCodes)
+		if (err == nil) != testCase.success {
+			t.Fatalf("api_object_test.go: Expected success to be %t for status %d with success codes %v but got: %v", testCase.success, testCase.statusCode, testCase.successCodes, err)
+		}
+	}
+}
+
+func TestFriendlyError(t *testing.T) {
+	obj := &APIObject{
+		errorMessages: map[int]errorMessage{
+			403: {message: "quota exceeded", hint: "request an increase of the quota"},
+			409: {message: "the name is already taken"},
+		},
+	}
+	failed := fmt.Errorf("unexpected response code: body")
+	testCases := map[int]string{
+		403: "quota exceeded (status code 403)\nHint: request an increase of the quota",
+		409: "the name is already taken (status code 409)",
+		500: "unexpected response code: body",
+	}
+	for statusCode, expected := range testCases {
+		err := obj.checkResponse(&apiClientResponse{statusCode: statusCode, body: "body"}, failed, []int{200})
+		if err == nil || err.Error() != expected {
+			t.Fatalf("api_object_test.go: Expected '%s' for status %d but got: %v", expected, statusCode, err)
+		}
+	}
+}
+
+func TestNotFoundCodes(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+	})
+	svr := newTestServer(t, serverMux)
+
+	goneClient, _ := NewAPIClient(&apiClientOpt{
+		uri:           svr.URL,
+		headers:       make(map[string]string),
+		timeout:       2,
+		idAttribute:   "Id",
+		readMethod:    "GET",
+		destroyMethod: "DELETE",
+		rateLimit:     10,
+	})
+
+	obj, err := NewAPIObject(goneClient, &apiObjectOpts{
+		path: "/api/objects",
+		id:   "1",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.readObject(ctx); err == nil {
+		t.Fatalf("api_object_test.go: Expected a 410 to fail the read by default")
+	}
+	if err := obj.deleteObject(ctx); err == nil {
+		t.Fatalf("api_object_test.go: Expected a 410 to fail the delete by default")
+	}
+
+	obj.notFoundCodes = []int{404, 410}
+	if err := obj.deleteObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Expected a 410 to be accepted as gone on delete: %s", err)
+	}
+	if err := obj.readObject(ctx); err != nil || obj.id != "" {
+		t.Fatalf("api_object_test.go: Expected a 410 to remove the object on read but got id '%s': %v", obj.id, err)
+	}
+}
+
+func TestPollOperation(t *testing.T) {
+	ctx := context.Background()
+
+	polls := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Operation-Location", "/api/operations/1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	serverMux.HandleFunc("/api/operations/1", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.Write([]byte(`{ "properties": { "provisioningState": "Running" }, "conditions": [ { "type": "Scheduled", "status": "True" }, { "type": "Ready", "status": "False" } ] }`))
+			return
+		}
+		w.Write([]byte(`{ "properties": { "provisioningState": "Succeeded" }, "conditions": [ { "type": "Scheduled", "status": "True" }, { "type": "Ready", "status": "True" } ] }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "Id": "1", "Thing": "spoon" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	pollClient, _ := NewAPIClient(&apiClientOpt{
+		uri:          svr.URL,
+		headers:      make(map[string]string),
+		timeout:      2,
+		idAttribute:  "Id",
+		createMethod: "POST",
+		readMethod:   "GET",
+		rateLimit:    10,
+	})
+
+	poll := &pollOpts{
+		statusField:   "properties/provisioningState",
+		successValues: []string{"Succeeded"},
+		failureValues: []string{"Failed"},
+		interval:      10 * time.Millisecond,
+		timeout:       5 * time.Second,
+	}
+	obj, err := NewAPIObject(pollClient, &apiObjectOpts{
+		path:       "/api/objects",
+		data:       `{ "Id": "1", "Thing": "spoon" }`,
+		createPoll: poll,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create object with polling: %s", err)
+	}
+	if polls != 3 {
+		t.Fatalf("api_object_test.go: Expected the operation to be polled until it succeeded but it was polled %d times", polls)
+	}
+
+	poll.successValues = []string{"Done"}
+	poll.timeout = 50 * time.Millisecond
+	err = obj.createObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("api_object_test.go: Expected polling to time out but got: %v", err)
+	}
+
+	/* Conditions that a field cannot express are found by an expression */
+	polls = 0
+	poll.statusField = ""
+	poll.statusExpr = "conditions[?type=='Ready'].status | [0]"
+	poll.successValues = []string{"True"}
+	poll.failureValues = nil
+	poll.timeout = 5 * time.Second
+	if err := obj.createObject(ctx); err != nil || polls != 3 {
+		t.Fatalf("api_object_test.go: Expected the operation to be polled until its Ready condition was True but it was polled %d times: %v", polls, err)
+	}
+	poll.failureValues = []string{"False"}
+	polls = 0
+	err = obj.createObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), "failed with status 'False'") || polls != 1 {
+		t.Fatalf("api_object_test.go: Expected the operation to fail on its first poll by the expression but got: %v", err)
+	}
+}
+
+func TestFollowLocation(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/api/v2/objects/abc-123?view=full")
+		w.WriteHeader(http.StatusCreated)
+	})
+	serverMux.HandleFunc("/api/v2/objects/abc-123", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "Thing": "spoon" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	locationClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		idAttribute:        "Id",
+		createMethod:       "POST",
+		readMethod:         "GET",
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	for _, regex := range []string{"", `/objects/([^/?]+)`} {
+		obj, err := NewAPIObject(locationClient, &apiObjectOpts{
+			path:            "/api/objects",
+			data:            `{ "Thing": "spoon" }`,
+			followLocation:  true,
+			locationIDRegex: regex,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+
+		if err := obj.createObject(ctx); err != nil {
+			t.Fatalf("api_object_test.go: Failed to create object following its Location: %s", err)
+		}
+		if obj.id != "abc-123" {
+			t.Fatalf("api_object_test.go: Expected the id to be taken from the Location with regex '%s' but got '%s'", regex, obj.id)
+		}
+		if obj.getPath != svr.URL+"/api/v2/objects/abc-123?view=full" || obj.apiData["Thing"] != "spoon" {
+			t.Fatalf("api_object_test.go: Expected the object to be read from its Location but read '%v' from '%s'", obj.apiData, obj.getPath)
+		}
+	}
+}
+
+func TestUpsertCreate(t *testing.T) {
+	ctx := context.Background()
+
+	var method string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/v1/kv/app", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			method = r.Method
+		}
+		w.Write([]byte(`{ "Key": "app", "Value": "on" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	kvClient, _ := NewAPIClient(&apiClientOpt{
+		uri:          svr.URL,
+		headers:      make(map[string]string),
+		timeout:      2,
+		idAttribute:  "Key",
+		createMethod: "POST",
+		readMethod:   "GET",
+		rateLimit:    10,
+	})
+
+	obj, err := NewAPIObject(kvClient, &apiObjectOpts{
+		path:           "/v1/kv",
+		data:           `{ "Key": "app", "Value": "on" }`,
+		createStrategy: "upsert",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to upsert object: %s", err)
+	}
+	if method != "PUT" {
+		t.Fatalf("api_object_test.go: Expected the object to be created with a PUT to its path but got %s", method)
+	}
+
+	obj, _ = NewAPIObject(kvClient, &apiObjectOpts{
+		path:           "/v1/kv",
+		data:           `{ "Value": "on" }`,
+		createStrategy: "upsert",
+	})
+	if err := obj.createObject(ctx); err == nil {
+		t.Fatalf("api_object_test.go: Expected an upsert without an id to fail")
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+
+	var method, body string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(b)
+		w.Write(b)
+	})
+	svr := newTestServer(t, serverMux)
+
+	softClient, _ := NewAPIClient(&apiClientOpt{
+		uri:           svr.URL,
+		headers:       make(map[string]string),
+		timeout:       2,
+		destroyMethod: "DELETE",
+		rateLimit:     10,
+	})
+
+	for _, status := range []string{"archived", "active"} {
+		obj, err := NewAPIObject(softClient, &apiObjectOpts{
+			path:                "/api/objects",
+			id:                  "1",
+			destroyMethod:       "PATCH",
+			destroyData:         fmt.Sprintf(`{ "status": "%s" }`, status),
+			destroySuccessField: "status",
+			destroySuccessVals:  []string{"archived"},
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+
+		err = obj.deleteObject(ctx)
+		if method != "PATCH" || body != fmt.Sprintf(`{"status":"%s"}`, status) {
+			t.Fatalf("api_object_test.go: Expected a PATCH with the destroy data but got %s with '%s'", method, body)
+		}
+		if (err == nil) != (status == "archived") {
+			t.Fatalf("api_object_test.go: Unexpected result of a soft delete responding with status '%s': %v", status, err)
+		}
+	}
+}
+
+func TestReturnsObjectOverrides(t *testing.T) {
+	ctx := context.Background()
+
+	reads := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "Id": "1", "Thing": "spoon" }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			reads++
+		}
+		w.Write([]byte(`{ "Id": "1", "Thing": "spoon", "Revision": 2 }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	croClient, _ := NewAPIClient(&apiClientOpt{
+		uri:          svr.URL,
+		headers:      make(map[string]string),
+		timeout:      2,
+		idAttribute:  "Id",
+		createMethod: "POST",
+		readMethod:   "GET",
+		rateLimit:    10,
+	})
+
+	createReturnsObject := true
+	testCases := []struct {
+		readAfterWrite bool
+		reads          int
+	}{
+		{false, 0},
+		{true, 1},
+	}
+	for _, testCase := range testCases {
+		reads = 0
+		obj, err := NewAPIObject(croClient, &apiObjectOpts{
+			path:                "/api/objects",
+			data:                `{ "Thing": "spoon" }`,
+			createReturnsObject: &createReturnsObject,
+			readAfterWrite:      testCase.readAfterWrite,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+		if err := obj.createObject(ctx); err != nil {
+			t.Fatalf("api_object_test.go: Failed to create object: %s", err)
+		}
+		if obj.id != "1" || reads != testCase.reads {
+			t.Fatalf("api_object_test.go: Expected id '1' and %d reads with read_after_write %t but got id '%s' and %d reads", testCase.reads, testCase.readAfterWrite, obj.id, reads)
+		}
+	}
+
+	/* The client says writes return the object, but this one does not */
+	croClient.writeReturnsObject = true
+	writeReturnsObject := false
+	reads = 0
+	obj, _ := NewAPIObject(croClient, &apiObjectOpts{
+		path:               "/api/objects",
+		data:               `{ "Id": "1", "Thing": "spoon" }`,
+		writeReturnsObject: &writeReturnsObject,
+	})
+	if err := obj.updateObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to update object: %s", err)
+	}
+	if reads != 1 {
+		t.Fatalf("api_object_test.go: Expected the object to be read after an update that does not return it but got %d reads", reads)
+	}
+}
+
+func TestPostCreate(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "Id": "1", "Links": { "Activate": "/api/objects/1/activate" } }`))
+	})
+	serverMux.HandleFunc("/api/objects/1/", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, b))
+	})
+	svr := newTestServer(t, serverMux)
+
+	postCreateClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		idAttribute:        "Id",
+		createMethod:       "POST",
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(postCreateClient, &apiObjectOpts{
+		path: "/api/objects",
+		data: `{ "Thing": "spoon" }`,
+		postCreateRequests: []extraRequest{
+			{method: "POST", path: "{response.Links/Activate}"},
+			{method: "PUT", path: "/api/objects/{id}/owner", data: `{ "object": "{id}" }`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create object: %s", err)
+	}
+	if err := obj.postCreate(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to send post_create_request: %s", err)
+	}
+
+	expected := []string{"POST /api/objects/1/activate ", `PUT /api/objects/1/owner { "object": "1" }`}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("api_object_test.go: Unexpected post_create_request requests sent:\n%s", strings.Join(requests, "\n"))
+	}
+
+	obj.postCreateRequests = []extraRequest{{method: "POST", path: "{response.Links/Missing}"}}
+	if err := obj.postCreate(ctx); err == nil {
+		t.Fatalf("api_object_test.go: Expected a missing key in the create response to fail")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/locks", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{ "lock": { "token": "abc", "state": "held" } }`))
+	})
+	serverMux.HandleFunc("/api/locks/abc", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+	})
+	svr := newTestServer(t, serverMux)
+
+	hookClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	obj, err := NewAPIObject(hookClient, &apiObjectOpts{
+		path: "/api/objects",
+		id:   "1",
+		hooks: []extraRequest{
+			{operation: "update", when: "before", method: "POST", path: "/api/locks", successField: "lock/state", successValues: []string{"held"}},
+			{operation: "update", when: "after", method: "DELETE", path: "/api/locks/{response.lock/token}"},
+			{operation: "destroy", when: "before", method: "POST", path: "/api/locks", successField: "lock/state", successValues: []string{"free"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	/* The lock is released even if the update fails */
+	err = obj.withHooks(ctx, "update", func() error {
+		requests = append(requests, "update")
+		return fmt.Errorf("update failed")
+	})
+	if err == nil || err.Error() != "update failed" {
+		t.Fatalf("api_object_test.go: Expected the error of the update to be returned but got: %v", err)
+	}
+	expected := []string{"POST /api/locks", "update", "DELETE /api/locks/abc"}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("api_object_test.go: Unexpected requests sent around the update:\n%s", strings.Join(requests, "\n"))
+	}
+
+	/* The destroy is not attempted if a hook before it fails */
+	requests = nil
+	err = obj.withHooks(ctx, "destroy", func() error {
+		requests = append(requests, "destroy")
+		return nil
+	})
+	if err == nil || len(requests) != 1 {
+		t.Fatalf("api_object_test.go: Expected a failed hook to prevent the destroy but got requests %v: %v", requests, err)
+	}
+}
+
+func TestDestroyPrecondition(t *testing.T) {
+	ctx := context.Background()
+
+	dependents := "2"
+	deleted := false
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1/dependents", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "meta": { "count": ` + dependents + ` } }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = true
+		}
+	})
+	svr := newTestServer(t, serverMux)
+
+	preconditionClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	obj, err := NewAPIObject(preconditionClient, &apiObjectOpts{
+		path: "/api/objects",
+		id:   "1",
+		destroyPrecondition: &destroyPrecondition{
+			request: extraRequest{method: "GET", path: "/api/objects/{id}/dependents", successField: "meta/count", successValues: []string{"0"}},
+			message: "remove the children of this object first",
+		},
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+
+	err = obj.deleteObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), "remove the children of this object first") {
+		t.Fatalf("api_object_test.go: Expected the destroy precondition to fail but got: %v", err)
+	}
+	if deleted {
+		t.Fatalf("api_object_test.go: The object was deleted although the destroy precondition failed")
+	}
+
+	dependents = "0"
+	if err := obj.deleteObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Expected the destroy precondition to pass but got: %s", err)
+	}
+	if !deleted {
+		t.Fatalf("api_object_test.go: The object was not deleted although the destroy precondition passed")
+	}
+
+	/* The same check with an expression instead of a field */
+	deleted = false
+	dependents = "3"
+	obj.destroyPrecondition.request.successField = ""
+	obj.destroyPrecondition.request.successExpr = "to_string(meta.count)"
+	if err := obj.deleteObject(ctx); err == nil || deleted {
+		t.Fatalf("api_object_test.go: Expected the destroy precondition expression to fail but got: %v", err)
+	}
+	dependents = "0"
+	if err := obj.deleteObject(ctx); err != nil || !deleted {
+		t.Fatalf("api_object_test.go: Expected the destroy precondition expression to pass but got: %v", err)
+	}
+}
+
+func TestCreateReadTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	reads := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		/* The object only shows up on the third read */
+		reads++
+		if reads < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{ "id": "1", "name": "foo" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	readClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	opts := &apiObjectOpts{
+		path:              "/api/objects",
+		data:              `{ "id": "1", "name": "foo" }`,
+		createReadTimeout: 10 * time.Second,
+	}
+	obj, err := NewAPIObject(readClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Expected the read to be retried until the object is found but got: %s", err)
+	}
+	if obj.id != "1" || reads != 3 {
+		t.Fatalf("api_object_test.go: Expected the object to be found on the third read but got id '%s' after %d reads", obj.id, reads)
+	}
+
+	/* Without a timeout the object is not waited for */
+	reads = 0
+	opts.createReadTimeout = 0
+	obj, err = NewAPIObject(readClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err == nil || reads != 1 {
+		t.Fatalf("api_object_test.go: Expected the create to fail after a single read but got %d reads: %v", reads, err)
+	}
+}
+
+func TestWaitForDeletion(t *testing.T) {
+	ctx := context.Background()
+
+	reads := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		/* The object is only gone on the third read */
+		reads++
+		if reads < 3 {
+			w.Write([]byte(`{ "id": "1", "state": "deleting" }`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	svr := newTestServer(t, serverMux)
+
+	deletionClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	opts := &apiObjectOpts{
+		path:            "/api/objects",
+		id:              "1",
+		waitForDeletion: 10 * time.Second,
+	}
+	obj, err := NewAPIObject(deletionClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.deleteObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Expected to wait until the object is gone but got: %s", err)
+	}
+	if reads != 3 {
+		t.Fatalf("api_object_test.go: Expected the object to be gone on the third read but got %d reads", reads)
+	}
+
+	/* The wait gives up once the timeout passes */
+	reads = -100
+	opts.waitForDeletion = 1 * time.Second
+	obj, err = NewAPIObject(deletionClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.deleteObject(ctx); err == nil || !strings.Contains(err.Error(), "could still be read") {
+		t.Fatalf("api_object_test.go: Expected the wait for deletion to time out but got: %v", err)
+	}
+}
+
+func TestWaitForState(t *testing.T) {
+	ctx := context.Background()
+
+	reads := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		/* The object is only ready on the third read */
+		reads++
+		status, ready := "PROVISIONING", "False"
+		if reads >= 3 {
+			status, ready = "ACTIVE", "True"
+		}
+		w.Write([]byte(`{ "id": "1", "status": { "phase": "` + status + `", "conditions": [ { "type": "Ready", "status": "` + ready + `" } ] } }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	waitClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	opts := &apiObjectOpts{
+		path: "/api/objects",
+		id:   "1",
+		waitFor: &waitForOpts{
+			field:    "status/phase",
+			value:    "ACTIVE",
+			interval: 100 * time.Millisecond,
+			timeout:  5 * time.Second,
+		},
+	}
+	obj, err := NewAPIObject(waitClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.waitForState(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Expected to wait until the object is ready but got: %s", err)
+	}
+	if reads != 3 {
+		t.Fatalf("api_object_test.go: Expected the object to be ready on the third read but got %d reads", reads)
+	}
+
+	/* The wait gives up once the timeout passes */
+	opts.waitFor.valueRegex = regexp.MustCompile("^FAILED")
+	opts.waitFor.timeout = 500 * time.Millisecond
+	obj, err = NewAPIObject(waitClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.waitForState(ctx); err == nil || !strings.Contains(err.Error(), "last value 'ACTIVE'") {
+		t.Fatalf("api_object_test.go: Expected the wait to time out but got: %v", err)
+	}
+
+	/* Conditions that a field cannot express are found by an expression */
+	reads = 0
+	opts.waitFor = &waitForOpts{
+		expression: "status.conditions[?type=='Ready'].status | [0]",
+		value:      "True",
+		interval:   100 * time.Millisecond,
+		timeout:    5 * time.Second,
+	}
+	obj, err = NewAPIObject(waitClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.waitForState(ctx); err != nil || reads != 3 {
+		t.Fatalf("api_object_test.go: Expected to wait until the Ready condition is True on the third read but got %d reads: %v", reads, err)
+	}
+}
+
+func TestDestroyQueryString(t *testing.T) {
+	ctx := context.Background()
+
+	var query, contentType string
+	var body []byte
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	})
+	svr := newTestServer(t, serverMux)
+
+	queryClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   map[string]string{"Content-Type": "application/json"},
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	obj, err := NewAPIObject(queryClient, &apiObjectOpts{
+		path:               "/api/objects",
+		id:                 "1",
+		destroyQueryString: "cascade=true",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.deleteObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to delete the object: %s", err)
+	}
+	if query != "cascade=true" || len(body) != 0 || contentType != "" {
+		t.Fatalf("api_object_test.go: Expected a DELETE with only a query string but got query '%s', Content-Type '%s' and body '%s'", query, contentType, body)
+	}
+}
+
+func TestIDExpression(t *testing.T) {
+	expressionClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                 "http://127.0.0.1:8080/",
+		headers:             make(map[string]string),
+		timeout:             2,
+		writeReturnsObject:  true,
+		createReturnsObject: true,
+		rateLimit:           10,
+	})
+
+	obj, err := NewAPIObject(expressionClient, &apiObjectOpts{
+		path:         "/api/objects",
+		data:         `{ "name": "foo" }`,
+		idExpression: "result.items[0].uuid",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.updateState(`{ "result": { "items": [ { "uuid": "abc-123" } ] } }`); err != nil {
+		t.Fatalf("api_object_test.go: Failed to update state: %s", err)
+	}
+	if obj.id != "abc-123" {
+		t.Fatalf("api_object_test.go: Expected id_expression to find the id 'abc-123' but got '%s'", obj.id)
+	}
+}
+
+func TestIDHeader(t *testing.T) {
+	ctx := context.Background()
+
+	reads := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Resource-Id", "urn:objects:42")
+		w.WriteHeader(http.StatusCreated)
+	})
+	serverMux.HandleFunc("/api/objects/42", func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		w.Write([]byte(`{ "name": "foo", "created": true }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	headerClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(headerClient, &apiObjectOpts{
+		path:          "/api/objects",
+		data:          `{ "name": "foo" }`,
+		idHeader:      "X-Resource-Id",
+		idHeaderRegex: `^urn:objects:(.+)$`,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create the object: %s", err)
+	}
+	if obj.id != "42" {
+		t.Fatalf("api_object_test.go: Expected the id '42' from the X-Resource-Id header but got '%s'", obj.id)
+	}
+	if reads != 1 || obj.apiData["created"] != true {
+		t.Fatalf("api_object_test.go: Expected the object to be read after the create since the response body is empty")
+	}
+}
+
+func TestIDTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/acme/web", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "tenant": "acme", "name": "web", "port": 80 }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	templateClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	obj, err := NewAPIObject(templateClient, &apiObjectOpts{
+		path:       "/api/objects",
+		data:       `{ "tenant": "acme", "name": "web" }`,
+		idTemplate: "{data.tenant}/{data.name}",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if obj.id != "acme/web" {
+		t.Fatalf("api_object_test.go: Expected id_template to compose the id 'acme/web' but got '%s'", obj.id)
+	}
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+	}
+	if obj.id != "acme/web" || obj.apiData["port"] != float64(80) {
+		t.Fatalf("api_object_test.go: Expected to read the object at the composed id but got id '%s' and data %v", obj.id, obj.apiData)
+	}
+
+	/* The id cannot be composed without the fields it is made of */
+	obj, _ = NewAPIObject(templateClient, &apiObjectOpts{
+		path:       "/api/objects",
+		data:       `{ "tenant": "acme" }`,
+		idTemplate: "{data.tenant}/{data.name}",
+	})
+	if _, err := obj.idFromData(obj.data); err == nil || obj.id != "" {
+		t.Fatalf("api_object_test.go: Expected no id for data without the fields of id_template but got '%s'", obj.id)
+	}
+}
+
+func TestTemplateNestedKeys(t *testing.T) {
+	data := map[string]interface{}{
+		"metadata": map[string]interface{}{"tenant": "acme"},
+		"zone.id":  "eu-1",
+	}
+	for _, template := range []string{"/tenants/{data.metadata.tenant}/{data.zone.id}", "/tenants/{data.metadata/tenant}/{data.zone.id}"} {
+		path, err := expandDataTemplate(template, data, false, false)
+		if err != nil || path != "/tenants/acme/eu-1" {
+			t.Fatalf("api_object_test.go: Expected '%s' to give '/tenants/acme/eu-1' but got '%s' (%v)", template, path, err)
+		}
+	}
+	if path, err := expandDataTemplate("/tenants/{data.metadata.name}", data, false, false); err == nil {
+		t.Fatalf("api_object_test.go: Expected a missing nested key to fail but got '%s'", path)
+	}
+
+	obj := &APIObject{id: "1"}
+	response := map[string]interface{}{"lock": map[string]interface{}{"token": "abc"}}
+	for _, template := range []string{"/locks/{id}/{response.lock.token}", "/locks/{id}/{response.lock/token}"} {
+		path, err := obj.expandTemplate(template, response, true)
+		if err != nil || path != "/locks/1/abc" {
+			t.Fatalf("api_object_test.go: Expected '%s' to give '/locks/1/abc' but got '%s' (%v)", template, path, err)
+		}
+	}
+}
+
+func TestDataPathTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/tenants/t1/rules", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{ "id": "5", "tenant_id": "t1" }`))
+	})
+	serverMux.HandleFunc("/api/tenants/t1/rules/5", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{ "id": "5", "tenant_id": "t1" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	pathClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(pathClient, &apiObjectOpts{
+		path: "/api/tenants/{data.tenant_id}/rules",
+		data: `{ "tenant_id": "t1" }`,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create the object: %s", err)
+	}
+	if err := obj.updateObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to update the object: %s", err)
+	}
+	if err := obj.deleteObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to delete the object: %s", err)
+	}
+	expected := []string{"POST /api/tenants/t1/rules", "PUT /api/tenants/t1/rules/5", "DELETE /api/tenants/t1/rules/5"}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("api_object_test.go: Unexpected requests for the object:\n%s", strings.Join(requests, "\n"))
+	}
+
+	/* Paths cannot be made from fields that are not in the data */
+	_, err = NewAPIObject(pathClient, &apiObjectOpts{
+		path: "/api/tenants/{data.tenant_id}/rules",
+		data: `{ "id": "5" }`,
+	})
+	if err == nil {
+		t.Fatalf("api_object_test.go: Expected an error for a path with a field that is not in the data")
+	}
+}
+
+func TestEscapePathValues(t *testing.T) {
+	ctx := context.Background()
+
+	var paths []string
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{ "id": "a/b c:d", "zone": "eu west" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	escapeClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	for _, escape := range []bool{false, true} {
+		paths = nil
+		obj, err := NewAPIObject(escapeClient, &apiObjectOpts{
+			path:             "/api/zones/{data.zone}/objects",
+			data:             `{ "id": "a/b c:d", "zone": "eu west" }`,
+			escapePathValues: escape,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+		if err := obj.readObject(ctx); err != nil {
+			t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+		}
+
+		expected := "/api/zones/eu%20west/objects/a/b%20c:d"
+		if escape {
+			expected = "/api/zones/eu%20west/objects/a%2Fb%20c:d"
+		}
+		if len(paths) != 1 || paths[0] != expected {
+			t.Fatalf("api_object_test.go: Expected a read of '%s' with escape_path_values=%t but got %v", expected, escape, paths)
+		}
+	}
+}
+
+func TestNestedIDAttribute(t *testing.T) {
+	nestedClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                "http://127.0.0.1:8080/",
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	response := `{ "metadata": { "uid": "1234" }, "metadata.uid": "literal", "result": { "id": 42 } }`
+	for idAttribute, expected := range map[string]string{
+		"result/id":    "42",
+		"result.id":    "42",
+		"metadata/uid": "1234",
+		"metadata.uid": "literal",
+	} {
+		obj, err := NewAPIObject(nestedClient, &apiObjectOpts{
+			path:        "/api/objects",
+			data:        `{ "name": "foo" }`,
+			idAttribute: idAttribute,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+		if err := obj.updateState(response); err != nil {
+			t.Fatalf("api_object_test.go: Failed to update state with id_attribute '%s': %s", idAttribute, err)
+		}
+		if obj.id != expected {
+			t.Fatalf("api_object_test.go: Expected id_attribute '%s' to find the id '%s' but got '%s'", idAttribute, expected, obj.id)
+		}
+	}
+}
+
+func TestCaptureResponseHeaders(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/api/objects/1")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Write([]byte(`{ "id": "1" }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("X-RateLimit-Remaining", "98")
+		w.Write([]byte(`{ "id": "1" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	headerClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(headerClient, &apiObjectOpts{
+		path:           "/api/objects",
+		data:           `{ "name": "foo" }`,
+		captureHeaders: []string{"ETag", "Location", "X-RateLimit-Remaining"},
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create the object: %s", err)
+	}
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+	}
+
+	/* The Location header of the create response is kept after the read */
+	expected := map[string]string{"ETag": `"v2"`, "Location": "/api/objects/1", "X-RateLimit-Remaining": "98"}
+	if fmt.Sprintf("%v", obj.responseHeaders) != fmt.Sprintf("%v", expected) {
+		t.Fatalf("api_object_test.go: Expected the captured headers to be %v but got %v", expected, obj.responseHeaders)
+	}
+}
+
+func TestLastRequest(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{ "id": "1" }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	lastClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(lastClient, &apiObjectOpts{
+		path: "/api/objects",
+		data: `{ "name": "foo" }`,
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create the object: %s", err)
+	}
+	if obj.lastOperation != "create" || obj.lastStatusCode != 201 || obj.lastRequestURL != svr.URL+"/api/objects" {
+		t.Fatalf("api_object_test.go: Unexpected last request after create: %s %s %d", obj.lastOperation, obj.lastRequestURL, obj.lastStatusCode)
+	}
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+	}
+	if obj.lastOperation != "read" || obj.lastStatusCode != 200 || obj.lastRequestURL != svr.URL+"/api/objects/1" {
+		t.Fatalf("api_object_test.go: Unexpected last request after read: %s %s %d", obj.lastOperation, obj.lastRequestURL, obj.lastStatusCode)
+	}
+}
+
+func TestResponseEnvelopeKey(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{ "data": { "id": "1", "name": "foo" }, "meta": { "request_id": "a" } }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "data": { "id": "1", "name": "bar" }, "meta": { "request_id": "b" } }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	envelopeClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(envelopeClient, &apiObjectOpts{
+		path:                "/api/objects",
+		data:                `{ "name": "foo" }`,
+		responseEnvelopeKey: "data",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.createObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to create the object: %s", err)
+	}
+	if obj.id != "1" {
+		t.Fatalf("api_object_test.go: Expected the id '1' from the envelope but got '%s'", obj.id)
+	}
+	if _, ok := obj.apiData["meta"]; ok {
+		t.Fatalf("api_object_test.go: Expected the envelope to be removed from api_data: %v", obj.apiData)
+	}
+
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+	}
+	if obj.apiData["name"] != "bar" {
+		t.Fatalf("api_object_test.go: Expected name 'bar' from the envelope but got '%v'", obj.apiData["name"])
+	}
+	if !strings.Contains(obj.apiResponse, `"request_id": "b"`) {
+		t.Fatalf("api_object_test.go: Expected api_response to keep the raw response but got '%s'", obj.apiResponse)
+	}
+
+	obj.responseEnvelopeKey = "result"
+	if err := obj.readObject(ctx); err == nil {
+		t.Fatalf("api_object_test.go: Expected an error when the envelope key is missing")
+	}
+}
+
+func TestResponseTransform(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "data": { "id": "1", "display_name": "foo", "port": "8080" } }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	transformClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	obj, err := NewAPIObject(transformClient, &apiObjectOpts{
+		path:                "/api/objects",
+		id:                  "1",
+		data:                `{ "name": "foo", "port": 8080 }`,
+		responseEnvelopeKey: "data",
+		responseTransform:   "{id: id, name: display_name, port: to_number(port)}",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+	}
+	if obj.apiData["name"] != "foo" || obj.apiData["port"] != float64(8080) {
+		t.Fatalf("api_object_test.go: Expected the transformed object but got %v", obj.apiData)
+	}
+	if _, ok := obj.apiData["display_name"]; ok {
+		t.Fatalf("api_object_test.go: Expected display_name to be renamed but got %v", obj.apiData)
+	}
+
+	obj.responseTransform = "display_name"
+	if err := obj.readObject(ctx); err == nil {
+		t.Fatalf("api_object_test.go: Expected an error when response_transform does not give an object")
+	}
+}
+
+func TestResponseSchema(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1", "port": "80" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	schemaClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	opts := &apiObjectOpts{
+		path:           "/api/objects",
+		id:             "1",
+		data:           `{ "port": 80 }`,
+		responseSchema: `{ "type": "object", "required": ["port"], "properties": { "port": { "type": "integer" } } }`,
+	}
+	obj, err := NewAPIObject(schemaClient, opts)
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	err = obj.readObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), "/port: expected integer but got string") {
+		t.Fatalf("api_object_test.go: Expected the response to violate response_schema but got: %v", err)
+	}
+
+	opts.responseTransform = "{id: id, port: to_number(port)}"
+	obj, _ = NewAPIObject(schemaClient, opts)
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Expected the transformed response to match response_schema but got: %s", err)
+	}
+
+	opts.responseSchema = `{ "type": `
+	if _, err := NewAPIObject(schemaClient, opts); err == nil {
+		t.Fatalf("api_object_test.go: Expected an invalid response_schema to fail")
+	}
+}
+
+func TestErrorExpression(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "status": "error", "message": "name is already taken" }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1", "name": "foo" }`))
+	})
+	serverMux.HandleFunc("/api/objects/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "status": "ok", "data": { "id": "2" } }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	errorClient, _ := NewAPIClient(&apiClientOpt{
+		uri:                svr.URL,
+		headers:            make(map[string]string),
+		timeout:            2,
+		writeReturnsObject: true,
+		rateLimit:          10,
+	})
+
+	obj, err := NewAPIObject(errorClient, &apiObjectOpts{
+		path: "/api/objects",
+		data: `{ "name": "foo" }`,
+		errorExpression: &errorExpressionOpts{
+			expression:        "status",
+			errorValues:       []string{"error"},
+			messageExpression: "message",
+		},
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	err = obj.createObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), "name is already taken") {
+		t.Fatalf("api_object_test.go: Expected the create to fail with the message from the response but got: %v", err)
+	}
+
+	/* The field is missing from the response, so it is not an error */
+	obj.id = "1"
+	if err := obj.readObject(ctx); err != nil {
+		t.Fatalf("api_object_test.go: Failed to read the object: %s", err)
+	}
+
+	obj.id = "2"
+	obj.errorExpression = &errorExpressionOpts{
+		expression:    "status",
+		successValues: []string{"done"},
+	}
+	err = obj.readObject(ctx)
+	if err == nil || !strings.Contains(err.Error(), `status 'ok': { "status": "ok"`) {
+		t.Fatalf("api_object_test.go: Expected the read to fail with the whole response but got: %v", err)
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{ "errors": [ { "detail": "name is required" }, { "detail": "port must be a number" } ], "trace": "` + strings.Repeat("x", 1000) + `" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	detailsClient, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	obj, err := NewAPIObject(detailsClient, &apiObjectOpts{
+		path:             "/api/objects",
+		id:               "1",
+		data:             `{ "id": "1" }`,
+		errorDetailsExpr: "errors[].detail",
+	})
+	if err != nil {
+		t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+	}
+	err = obj.createObject(ctx)
+	diags := diagFromErr(err)
+	if len(diags) != 1 || diags[0].Summary != "request failed with status code 400: name is required; port must be a number" {
+		t.Fatalf("api_object_test.go: Expected the details of the error in the summary but got: %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "(590 more bytes)") {
+		t.Fatalf("api_object_test.go: Expected the response to be truncated in the detail but got: %s", diags[0].Detail)
+	}
+
+	obj.errorDetailsExpr = "error.message"
+	err = obj.createObject(ctx)
+	if diags := diagFromErr(err); len(diags) != 1 || !strings.HasPrefix(diags[0].Summary, "unexpected response code '400'") {
+		t.Fatalf("api_object_test.go: Expected the error to be kept when no details are found but got: %v", diags)
+	}
+}
+
+func TestHandleNulls(t *testing.T) {
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       "http://127.0.0.1:8117/",
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	data := `{ "id": "1", "description": null, "spec": { "owner": null }, "tags": ["a", null] }`
+	expected := map[string][2]string{
+		"send":  {`{"description":null,"id":"1","spec":{"owner":null},"tags":["a",null]}`, `{"description":null,"id":"1","spec":{"owner":null},"tags":["a",null]}`},
+		"omit":  {`{"id":"1","spec":{},"tags":["a",null]}`, `{"id":"1","spec":{},"tags":["a",null]}`},
+		"unset": {`{"id":"1","spec":{},"tags":["a",null]}`, `{"description":"__unset__","id":"1","spec":{"owner":"__unset__"},"tags":["a",null]}`},
+	}
+	for mode, bodies := range expected {
+		obj, err := NewAPIObject(client, &apiObjectOpts{
+			path:         "/api/objects",
+			data:         data,
+			nullHandling: mode,
+			nullSentinel: `"__unset__"`,
+		})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+		for i, update := range []bool{false, true} {
+			body, _, err := obj.encodeBody(obj.handleNulls(obj.requestData(), update), true)
+			if err != nil {
+				t.Fatalf("api_object_test.go: Failed to encode the body: %s", err)
+			}
+			if body != bodies[i] {
+				t.Fatalf("api_object_test.go: Expected the body of %s with update=%t to be '%s' but got '%s'", mode, update, bodies[i], body)
+			}
+		}
+	}
+
+	if _, err := NewAPIObject(client, &apiObjectOpts{path: "/api/objects", nullSentinel: `{unset`}); err == nil {
+		t.Fatalf("api_object_test.go: Expected an error for a null_sentinel that is not JSON")
+	}
+}
+
+func TestFindObjectMatching(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{ "id": "1", "name": "web", "tenant": "a", "size": 20, "active": true },
+			{ "id": "2", "name": "web", "tenant": "b", "size": 5, "active": true },
+			{ "id": "3", "name": "db" }
+		]`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	testCases := []struct {
+		criteria []searchCriterion
+		operator string
+		id       string
+	}{
+		{[]searchCriterion{{"name", "web", ""}, {"tenant", "b", ""}}, "and", "2"},
+		{[]searchCriterion{{"name", "db", ""}, {"tenant", "b", ""}}, "or", "2"},
+		{[]searchCriterion{{"tenant", "c", ""}, {"name", "db", ""}}, "or", "3"},
+		{[]searchCriterion{{"name", "db", ""}, {"tenant", "a", ""}}, "and", ""},
+		{[]searchCriterion{{"name", "^w.b$", "regex"}, {"tenant", "b", "suffix"}}, "and", "2"},
+		{[]searchCriterion{{"id", "2", "gt"}}, "and", "3"},
+		{[]searchCriterion{{"size", "10", "le"}, {"active", "true", "bool"}}, "and", "2"},
+	}
+	for _, tc := range testCases {
+		obj, err := NewAPIObject(client, &apiObjectOpts{path: "/api/objects"})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+		_, err = obj.findObjectMatching(ctx, "", tc.criteria, tc.operator, "")
+		if tc.id == "" {
+			if err == nil || !strings.Contains(err.Error(), "the 'name' key = 'db' and the 'tenant' key = 'a'") {
+				t.Fatalf("api_object_test.go: Expected no object to match %v but got: %v", tc.criteria, err)
+			}
+			continue
+		}
+		if err != nil || obj.id != tc.id {
+			t.Fatalf("api_object_test.go: Expected %v %s to find '%s' but got '%s': %v", tc.criteria, tc.operator, tc.id, obj.id, err)
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+
+	/* Five objects, served two at a time */
+	objects := []string{`{"id": "1"}`, `{"id": "2"}`, `{"id": "3"}`, `{"id": "4"}`, `{"id": "5"}`}
+	pageOf := func(page int) string {
+		start, end := page*2, page*2+2
+		if start > len(objects) {
+			start = len(objects)
+		}
+		if end > len(objects) {
+			end = len(objects)
+		}
+		return "[" + strings.Join(objects[start:end], ",") + "]"
+	}
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/pages", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		w.Write([]byte(`{ "items": ` + pageOf(page) + ` }`))
+	})
+	serverMux.HandleFunc("/api/cursors", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		next := ""
+		if page < 2 {
+			next = strconv.Itoa(page + 1)
+		}
+		w.Write([]byte(`{ "items": ` + pageOf(page) + `, "meta": { "next": "` + next + `" } }`))
+	})
+	serverMux.HandleFunc("/api/links", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`</api/links?p=%d>; rel="next"`, page+1))
+		}
+		w.Write([]byte(`{ "items": ` + pageOf(page) + ` }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "id",
+	})
+
+	testCases := map[string]*paginationOpts{
+		"/api/pages":   {mode: "page", pageParam: "page", firstPage: 0, limitParam: "per_page", limit: 2, maxPages: 10},
+		"/api/cursors": {mode: "cursor", cursorField: "meta/next", cursorParam: "cursor", maxPages: 10},
+		"/api/links":   {mode: "link", maxPages: 10},
+	}
+	for path, pagination := range testCases {
+		obj, err := NewAPIObject(client, &apiObjectOpts{path: path, pagination: pagination})
+		if err != nil {
+			t.Fatalf("api_object_test.go: Failed to create new api_object: %s", err)
+		}
+		items, _, err := obj.listObjects(ctx, "", "items")
+		if err != nil || len(items) != 5 {
+			t.Fatalf("api_object_test.go: Expected all 5 objects of '%s' but got %d: %v", path, len(items), err)
+		}
+
+		/* The last object is only found by fetching the last page */
+		if _, err := obj.findObject(ctx, "", "id", "5", "items"); err != nil || obj.id != "5" {
+			t.Fatalf("api_object_test.go: Expected to find the object on the last page of '%s': %v", path, err)
+		}
+
+		pagination.maxPages = 2
+		if _, _, err := obj.listObjects(ctx, "", "items"); err == nil || !strings.Contains(err.Error(), "max_pages") {
+			t.Fatalf("api_object_test.go: Expected '%s' to fail with more than max_pages pages but got: %v", path, err)
+		}
+	}
+}
@@ -1,25 +1,98 @@
 package restapi
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v3"
 )
 
 /* After any operation that returns API data, we'll stuff
    all the k,v pairs into the api_data map so users can
-   consume the values elsewhere if they'd like */
-func setResourceState(obj *APIObject, d *schema.ResourceData) {
+   consume the values elsewhere if they'd like. If values
+   are extracted, only those are kept in the state */
+func setResourceState(obj *APIObject, d *schema.ResourceData) error {
+	if len(obj.extract) > 0 {
+		outputs := make(map[string]string)
+		for name, expression := range obj.extract {
+			value, err := evalExpression(dataOrValue(obj.apiData, obj.apiDataValue), expression)
+			if err != nil {
+				return fmt.Errorf("unable to extract '%s' from the response: %v", name, err)
+			}
+			outputs[name] = value
+		}
+		d.Set("outputs", outputs)
+		d.Set("api_data", map[string]string{})
+		d.Set("api_data_json", "")
+		d.Set("api_response", "")
+		return nil
+	}
+
 	apiData := make(map[string]string)
 	for k, v := range obj.apiData {
 		apiData[k] = fmt.Sprintf("%v", v)
 	}
 	d.Set("api_data", apiData)
 	d.Set("api_response", obj.apiResponse)
+
+	/* Nested values are only usable with jsondecode() */
+	apiDataJSON, _ := json.Marshal(dataOrValue(obj.apiData, obj.apiDataValue))
+	d.Set("api_data_json", string(apiDataJSON))
+	return nil
+}
+
+/* The extract blocks of the data sources, which unlike those of the
+   resource keep api_data and friends */
+func extractSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "The name of the value.",
+				},
+				"expression": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validateExpression,
+					Description:  "A JMESPath expression that finds the value, such as `connection.host`. Supports the same syntax as `id_expression`. Values that are not strings or numbers, such as lists, are encoded as JSON.",
+				},
+			},
+		},
+	}
+}
+
+/* Evaluates the extract blocks of a data source against data */
+func extractValues(data interface{}, extract []interface{}) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, v := range extract {
+		e := v.(map[string]interface{})
+		name := e["name"].(string)
+		value, err := evalExpressionValue(data, e["expression"].(string))
+		if err != nil {
+			return values, fmt.Errorf("unable to extract '%s': %v", name, err)
+		}
+		values[name] = toExpressionString(value).(string)
+	}
+	return values, nil
 }
 
 /*GetStringAtKey uses GetObjectAtKey to verify the resulting
@@ -41,6 +114,28 @@ func GetStringAtKey(data map[string]interface{}, path string, debug bool) (strin
 	}
 }
 
+/* Like GetStringAtKey, but dots also separate the keys of the path,
+   such as metadata.uid, unless there is a key with dots in its name.
+   Every id_attribute and version_field is looked up with this */
+func getStringAtAttributePath(data map[string]interface{}, path string, debug bool) (string, error) {
+	value, err := GetStringAtKey(data, path, debug)
+	if err != nil && strings.Contains(path, ".") {
+		if nested, nestedErr := GetStringAtKey(data, strings.Replace(path, ".", "/", -1), debug); nestedErr == nil {
+			return nested, nil
+		}
+	}
+	return value, err
+}
+
+/* The keys of a path as taken by getStringAtAttributePath. A path
+   without slashes is split on its dots */
+func attributePathKeys(path string) []string {
+	if strings.Contains(path, "/") {
+		return strings.Split(path, "/")
+	}
+	return strings.Split(path, ".")
+}
+
 /*GetObjectAtKey is a handy helper that will dig through a map and find something
  at the defined key. The returned data is not type checked
  Example:
@@ -162,3 +257,397 @@ func expandStringList(configured []interface{}) []string {
 	}
 	return vs
 }
+
+func containsInt(list []int, elem int) bool {
+	for _, a := range list {
+		if a == elem {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, elem string) bool {
+	for _, a := range list {
+		if a == elem {
+			return true
+		}
+	}
+	return false
+}
+
+/* The ways that a search can match the value of a key */
+var searchMatches = []string{"exact", "regex", "prefix", "suffix", "lt", "le", "gt", "ge", "bool"}
+
+/* Whether the value found by a search matches the expected value of
+   a criterion. regex matches a regular expression, prefix and suffix
+   the start and end of the value, lt, le, gt and ge compare numbers
+   and numeric strings and bool compares true and false. Anything else
+   must be equal */
+func matchesSearchValue(match string, value interface{}, expected string) (bool, error) {
+	found := ""
+	if value != nil {
+		found = toExpressionString(value).(string)
+	}
+	switch match {
+	case "regex":
+		regex, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("the search value '%s' is not a valid regular expression: %v", expected, err)
+		}
+		return value != nil && regex.MatchString(found), nil
+	case "prefix":
+		return value != nil && strings.HasPrefix(found, expected), nil
+	case "suffix":
+		return value != nil && strings.HasSuffix(found, expected), nil
+	case "lt", "le", "gt", "ge":
+		expectedNumber, err := strconv.ParseFloat(expected, 64)
+		if err != nil {
+			return false, fmt.Errorf("the search value '%s' is not a number", expected)
+		}
+		number, err := strconv.ParseFloat(found, 64)
+		if err != nil {
+			return false, nil
+		}
+		switch match {
+		case "lt":
+			return number < expectedNumber, nil
+		case "le":
+			return number <= expectedNumber, nil
+		case "gt":
+			return number > expectedNumber, nil
+		}
+		return number >= expectedNumber, nil
+	case "bool":
+		expectedBool, err := strconv.ParseBool(expected)
+		if err != nil {
+			return false, fmt.Errorf("the search value '%s' is not a boolean", expected)
+		}
+		foundBool, err := strconv.ParseBool(found)
+		return err == nil && foundBool == expectedBool, nil
+	}
+	return value != nil && found == expected, nil
+}
+
+/* Adds a parameter to the query string of a path, such as the page
+   of a collection to fetch */
+func withQueryParam(path string, key string, value string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s%s=%s", path, separator, url.QueryEscape(key), url.QueryEscape(value))
+}
+
+/* Finds the URL of the next page in RFC 5988 Link headers, such as
+   <https://api.example.com/objects?page=2>; rel="next" */
+func nextLink(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || strings.ToLower(strings.TrimSpace(name)) != "rel" {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.ToLower(rel) == "next" {
+						return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func expandIntList(configured []interface{}) []int {
+	vs := make([]int, 0, len(configured))
+	for _, v := range configured {
+		if val, ok := v.(int); ok {
+			vs = append(vs, val)
+		}
+	}
+	return vs
+}
+
+/*fileSHA256 returns the hex encoded SHA256 checksum of a file's content */
+func fileSHA256(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+/* The prefix of the hashes that store_data_hash_only keeps in the state instead of data */
+const dataHashPrefix = "sha256:"
+
+/*hashData returns a hash of data with a random salt, in the form
+  sha256:<salt>:<hash>. The data is hashed as compact JSON so that
+  formatting it differently is not a change */
+func hashData(data string, dataFormat string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return saltedDataHash(data, dataFormat, hex.EncodeToString(salt)), nil
+}
+
+func saltedDataHash(data string, dataFormat string, salt string) string {
+	canonical := data
+	if value, ok := decodeDataAttr(data, dataFormat); ok {
+		b, _ := json.Marshal(value)
+		canonical = string(b)
+	}
+	sum := sha256.Sum256([]byte(salt + canonical))
+	return dataHashPrefix + salt + ":" + hex.EncodeToString(sum[:])
+}
+
+/*matchesDataHash reports whether hash, as returned by hashData, is the hash of data */
+func matchesDataHash(hash string, data string, dataFormat string) bool {
+	salt, _, ok := strings.Cut(strings.TrimPrefix(hash, dataHashPrefix), ":")
+	return ok && saltedDataHash(data, dataFormat, salt) == hash
+}
+
+func isDataHash(data string) bool {
+	return strings.HasPrefix(data, dataHashPrefix)
+}
+
+/*yamlToJSON converts a YAML document to its JSON representation */
+func yamlToJSON(in string) (string, error) {
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(in), &data); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+/*jsonToYAML converts a JSON document to its YAML representation */
+func jsonToYAML(in string) (string, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(in), &data); err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+/* Any token is a valid HTTP method, which allows for non-standard
+   methods such as PURGE or PROPFIND */
+var validateHTTPMethod = validation.StringMatch(
+	regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$"),
+	"must be an HTTP method such as GET, POST or PURGE",
+)
+
+/* The longest part of a response body to show in an error */
+const maxErrorBodyLength = 512
+
+/* A failed request with the details found in the response by
+   error_detail_expression, shown as the summary of the diagnostic
+   while the response is left for its detail */
+type apiError struct {
+	summary string
+	detail  string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s\n%s", e.summary, e.detail)
+}
+
+/* A request that failed with a 5xx or without a response, such as on
+   a timeout, and may succeed if it is sent again */
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+func isTransientResponse(resp *apiClientResponse) bool {
+	return resp == nil || resp.statusCode == 0 || resp.statusCode >= 500
+}
+
+/* A search that found no object matching its criteria, which callers
+   such as create_conflict_behavior take to mean the object is absent */
+type notFoundError struct {
+	criteria   string
+	searchPath string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("failed to find an object with %s at %s", e.criteria, e.searchPath)
+}
+
+/* An update that failed with one of replace_on_update_status_codes,
+   which means that the object has to be replaced instead */
+type updateRejectedError struct {
+	statusCode int
+	err        error
+}
+
+func (e *updateRejectedError) Error() string {
+	return fmt.Sprintf("the update was rejected with status code %d, so the object will be replaced on the next apply: %v", e.statusCode, e.err)
+}
+
+func (e *updateRejectedError) Unwrap() error {
+	return e.err
+}
+
+/* A problem that is reported as a warning instead of failing */
+type warningError struct {
+	summary string
+	detail  string
+}
+
+func (e *warningError) Error() string {
+	return fmt.Sprintf("%s\n%s", e.summary, e.detail)
+}
+
+/* Like diag.FromErr, but splits an apiError into a summary and detail
+   and reports a warningError as a warning */
+func diagFromErr(err error) diag.Diagnostics {
+	var warning *warningError
+	if errors.As(err, &warning) {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  warning.summary,
+			Detail:   warning.detail,
+		}}
+	}
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  apiErr.summary,
+			Detail:   apiErr.detail,
+		}}
+	}
+	return diag.FromErr(err)
+}
+
+/* Cuts a response body down to maxErrorBodyLength bytes for an error
+   message, backing up to the start of a character so none is split */
+func truncateBody(body string) string {
+	if len(body) <= maxErrorBodyLength {
+		return body
+	}
+	cut := maxErrorBodyLength
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", body[:cut], len(body)-cut)
+}
+
+/* Parses data as written in the configuration and flattens it into a
+   map of the paths of its fields, such as rules[0].port, to their JSON
+   values. Data that is empty, invalid or not an object or array has no fields */
+func dataFields(data string, dataFormat string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if dataFormat == "yaml" && data != "" {
+		var err error
+		if data, err = yamlToJSON(data); err != nil {
+			return fields
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return fields
+	}
+	return valueFields(value)
+}
+
+/* Like dataFields, but of a value that is already decoded, such as
+   the object read by a data source for api_data_flat */
+func valueFields(value interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		flattenDataFields(value, "", fields)
+	}
+	return fields
+}
+
+func flattenDataFields(value interface{}, path string, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && path != "" {
+			fields[path] = "{}"
+		}
+		for key, sub := range v {
+			if path == "" {
+				flattenDataFields(sub, key, fields)
+			} else {
+				flattenDataFields(sub, path+"."+key, fields)
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 && path != "" {
+			fields[path] = "[]"
+		}
+		for i, sub := range v {
+			flattenDataFields(sub, fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	default:
+		encoded, _ := json.Marshal(v)
+		fields[path] = string(encoded)
+	}
+}
+
+/* Returns the fields of path that value is missing, such as
+   rules[1].port. An index of [*] requires them of every item. */
+func missingDataKeys(value interface{}, components []string, prefix string) []string {
+	if len(components) == 0 {
+		return nil
+	}
+	component, rest := components[0], components[1:]
+	if strings.HasPrefix(component, "[") {
+		list, ok := value.([]interface{})
+		if !ok {
+			return []string{prefix + component}
+		}
+		if component == "[*]" {
+			var missing []string
+			for i, item := range list {
+				missing = append(missing, missingDataKeys(item, rest, fmt.Sprintf("%s[%d]", prefix, i))...)
+			}
+			return missing
+		}
+		index, err := strconv.Atoi(strings.Trim(component, "[]"))
+		if err != nil || index < 0 || index >= len(list) {
+			return []string{prefix + component}
+		}
+		return missingDataKeys(list[index], rest, prefix+component)
+	}
+
+	path := component
+	if prefix != "" {
+		path = prefix + "." + component
+	}
+	hash, ok := value.(map[string]interface{})
+	if !ok {
+		return []string{path}
+	}
+	field, ok := hash[component]
+	if !ok {
+		return []string{path}
+	}
+	return missingDataKeys(field, rest, path)
+}
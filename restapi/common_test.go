@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -164,3 +167,269 @@ func TestGetListStringAtKey(t *testing.T) {
 		t.Fatalf("Error: Expected '2', but got %s", res)
 	}
 }
+
+func TestYAMLToJSON(t *testing.T) {
+	res, err := yamlToJSON("name: foo\ncount: 10\nratio: 1.5\ntags:\n  - a\n  - b\nnested:\n  enabled: true\n")
+	if err != nil {
+		t.Fatalf("Error converting YAML to JSON: %s", err)
+	}
+	expected := `{"count":10,"name":"foo","nested":{"enabled":true},"ratio":1.5,"tags":["a","b"]}`
+	if res != expected {
+		t.Fatalf("Error: Expected '%s', but got '%s'", expected, res)
+	}
+
+	back, err := jsonToYAML(res)
+	if err != nil {
+		t.Fatalf("Error converting JSON to YAML: %s", err)
+	}
+	roundTrip, _ := yamlToJSON(back)
+	if roundTrip != expected {
+		t.Fatalf("Error: Expected round trip to give '%s', but got '%s'", expected, roundTrip)
+	}
+}
+
+func TestDataFields(t *testing.T) {
+	fields := dataFields(`{ "name": "foo", "rules": [ { "port": 80, "tags": [] } ], "meta": {}, "on": true }`, "json")
+	expected := map[string]interface{}{
+		"name":          `"foo"`,
+		"rules[0].port": "80",
+		"rules[0].tags": "[]",
+		"meta":          "{}",
+		"on":            "true",
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("Error: Expected %v, but got %v", expected, fields)
+	}
+	for path, value := range expected {
+		if fields[path] != value {
+			t.Fatalf("Error: Expected '%s' to be '%s', but got '%v'", path, value, fields[path])
+		}
+	}
+
+	if fields := dataFields("name: foo\n", "yaml"); fields["name"] != `"foo"` {
+		t.Fatalf("Error: Expected the fields of YAML data, but got %v", fields)
+	}
+	for _, data := range []string{"", "not json", `"scalar"`} {
+		if fields := dataFields(data, "json"); len(fields) != 0 {
+			t.Fatalf("Error: Expected no fields for '%s', but got %v", data, fields)
+		}
+	}
+}
+
+func TestMissingDataKeys(t *testing.T) {
+	var data interface{}
+	json.Unmarshal([]byte(`{ "name": "foo", "meta": { "owner": null }, "rules": [ { "port": 80 }, { "proto": "tcp" } ] }`), &data)
+
+	for _, path := range []string{"name", "meta.owner", "rules[0].port", "rules[1]"} {
+		if missing := missingDataKeys(data, splitIgnorePath(path), ""); len(missing) != 0 {
+			t.Fatalf("Error: Expected '%s' to be found, but got %v missing", path, missing)
+		}
+	}
+	expected := map[string][]string{
+		"nmae":          {"nmae"},
+		"meta.team":     {"meta.team"},
+		"name.first":    {"name.first"},
+		"rules[2].port": {"rules[2]"},
+		"rules[*].port": {"rules[1].port"},
+	}
+	for path, paths := range expected {
+		if missing := missingDataKeys(data, splitIgnorePath(path), ""); !reflect.DeepEqual(paths, missing) {
+			t.Fatalf("Error: Expected %v to be missing for '%s', but got %v", paths, path, missing)
+		}
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	testCases := map[string][]string{
+		"":                      {},
+		"/api/objects?page=2":   {`</api/objects?page=2>; rel="next", </api/objects?page=9>; rel="last"`},
+		"https://x/objects?p=3": {`<https://x/objects?p=1>; rel="prev first"`, `<https://x/objects?p=3>; rel=next`},
+	}
+	for expected, headers := range testCases {
+		if next := nextLink(headers); next != expected {
+			t.Fatalf("Error: Expected the next link of %v to be '%s', but got '%s'", headers, expected, next)
+		}
+	}
+	if next := nextLink([]string{`</api/objects?page=9>; rel="last"`}); next != "" {
+		t.Fatalf("Error: Expected no next link, but got '%s'", next)
+	}
+}
+
+func TestExtractValues(t *testing.T) {
+	var data interface{}
+	json.Unmarshal([]byte(`{ "name": "foo", "port": 80, "enabled": true, "tags": ["a", "b"], "meta": { "owner": null } }`), &data)
+
+	flat := valueFields(data)
+	expected := map[string]interface{}{"name": `"foo"`, "port": "80", "enabled": "true", "tags[0]": `"a"`, "tags[1]": `"b"`, "meta.owner": "null"}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("Error: Expected the flattened data to be %v, but got %v", expected, flat)
+	}
+
+	values, err := extractValues(data, []interface{}{
+		map[string]interface{}{"name": "port", "expression": "port"},
+		map[string]interface{}{"name": "tags", "expression": "tags"},
+	})
+	if err != nil || !reflect.DeepEqual(values, map[string]string{"port": "80", "tags": `["a","b"]`}) {
+		t.Fatalf("Error: Unexpected extracted values %v: %v", values, err)
+	}
+	if _, err := extractValues(data, []interface{}{map[string]interface{}{"name": "x", "expression": "nope"}}); err == nil {
+		t.Fatalf("Error: Expected extracting a missing value to fail")
+	}
+}
+
+func TestMatchesSearchValue(t *testing.T) {
+	testCases := []struct {
+		match    string
+		value    interface{}
+		expected string
+		matches  bool
+	}{
+		{"exact", "web", "web", true},
+		{"", "web", "we", false},
+		{"regex", "web-7f3a", "^web-[0-9a-f]+$", true},
+		{"regex", "db-1", "^web-", false},
+		{"prefix", "web-7f3a", "web-", true},
+		{"suffix", "web-7f3a", "-prod", false},
+		{"lt", float64(5), "10", true},
+		{"ge", "10", "10", true},
+		{"gt", "ten", "1", false},
+		{"bool", true, "true", true},
+		{"bool", "false", "true", false},
+		{"prefix", nil, "", false},
+	}
+	for _, tc := range testCases {
+		matches, err := matchesSearchValue(tc.match, tc.value, tc.expected)
+		if err != nil || matches != tc.matches {
+			t.Fatalf("Error: Expected %v %s '%s' to be %t, but got %t: %v", tc.value, tc.match, tc.expected, tc.matches, matches, err)
+		}
+	}
+	for _, match := range []string{"regex", "lt", "bool"} {
+		if _, err := matchesSearchValue(match, "x", "(["); err == nil {
+			t.Fatalf("Error: Expected an invalid search value for %s to fail", match)
+		}
+	}
+}
+
+func TestHashData(t *testing.T) {
+	hash, err := hashData(`{ "password": "secret" }`, "json")
+	if err != nil {
+		t.Fatalf("Error: Unable to hash data: %v", err)
+	}
+	if !isDataHash(hash) || strings.Contains(hash, "secret") {
+		t.Fatalf("Error: Expected a hash, but got '%s'", hash)
+	}
+	if !matchesDataHash(hash, "{\n  \"password\": \"secret\"\n}", "json") {
+		t.Fatalf("Error: Expected reformatted data to match its hash")
+	}
+	if matchesDataHash(hash, `{ "password": "other" }`, "json") {
+		t.Fatalf("Error: Expected other data not to match the hash")
+	}
+	if other, _ := hashData(`{ "password": "secret" }`, "json"); other == hash {
+		t.Fatalf("Error: Expected hashes of the same data to be salted differently")
+	}
+	if isDataHash(`{ "password": "secret" }`) {
+		t.Fatalf("Error: Expected data not to be taken for a hash")
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	if body := strings.Repeat("a", maxErrorBodyLength); truncateBody(body) != body {
+		t.Fatalf("Error: Expected a body of %d bytes to be kept as is", maxErrorBodyLength)
+	}
+	/* Each of these takes 3 bytes, so the limit falls inside one of them */
+	body := strings.Repeat("€", maxErrorBodyLength)
+	truncated := truncateBody(body)
+	if !utf8.ValidString(truncated) {
+		t.Fatalf("Error: Expected the truncated body to be valid UTF-8, but got '%q'", truncated)
+	}
+	kept := maxErrorBodyLength / 3 * 3
+	expected := fmt.Sprintf("%s... (%d more bytes)", body[:kept], len(body)-kept)
+	if truncated != expected {
+		t.Fatalf("Error: Expected '%s', but got '%s'", expected, truncated)
+	}
+}
+
+func TestValidateHTTPMethod(t *testing.T) {
+	for _, method := range []string{"GET", "DELETE", "PURGE", "PROPFIND", "VERSION-CONTROL"} {
+		if _, errs := validateHTTPMethod(method, "create_method"); len(errs) > 0 {
+			t.Fatalf("Error: Expected '%s' to be a valid method, but got %v", method, errs)
+		}
+	}
+	for _, method := range []string{"", "GET /", "PUT\n"} {
+		if _, errs := validateHTTPMethod(method, "create_method"); len(errs) == 0 {
+			t.Fatalf("Error: Expected '%s' to be an invalid method", method)
+		}
+	}
+}
+
+func TestSetResourceState(t *testing.T) {
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       "http://127.0.0.1:8080/",
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+	obj, err := NewAPIObject(client, &apiObjectOpts{
+		path: "/api/objects",
+		id:   "1",
+	})
+	if err != nil {
+		t.Fatalf("Error creating api_object: %s", err)
+	}
+	response := `{"connection":{"host":"db.example.com","port":5432},"fingerprint":"ab:cd"}`
+	if err := obj.updateState(response); err != nil {
+		t.Fatalf("Error updating state: %s", err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{"path": "/api/objects"})
+	setResourceState(obj, d)
+
+	if v := d.Get("api_data.fingerprint"); v != "ab:cd" {
+		t.Fatalf("Error: Expected api_data.fingerprint to be 'ab:cd', but got '%v'", v)
+	}
+	if v := d.Get("api_data_json"); v != response {
+		t.Fatalf("Error: Expected api_data_json to be '%s', but got '%v'", response, v)
+	}
+	if v := d.Get("api_response"); v != response {
+		t.Fatalf("Error: Expected api_response to be '%s', but got '%v'", response, v)
+	}
+}
+
+func TestSetResourceStateExtract(t *testing.T) {
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       "http://127.0.0.1:8080/",
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+	obj, err := NewAPIObject(client, &apiObjectOpts{
+		path:    "/api/objects",
+		id:      "1",
+		extract: map[string]string{"host": "connection.host", "port": "connection.port"},
+	})
+	if err != nil {
+		t.Fatalf("Error creating api_object: %s", err)
+	}
+	if err := obj.updateState(`{"connection":{"host":"db.example.com","port":5432},"password":"secret"}`); err != nil {
+		t.Fatalf("Error updating state: %s", err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{"path": "/api/objects"})
+	if err := setResourceState(obj, d); err != nil {
+		t.Fatalf("Error setting state: %s", err)
+	}
+	if v := d.Get("outputs.host"); v != "db.example.com" {
+		t.Fatalf("Error: Expected outputs.host to be 'db.example.com', but got '%v'", v)
+	}
+	if v := d.Get("outputs.port"); v != "5432" {
+		t.Fatalf("Error: Expected outputs.port to be '5432', but got '%v'", v)
+	}
+	if v := d.Get("api_response"); v != "" {
+		t.Fatalf("Error: Expected api_response to be empty when values are extracted, but got '%v'", v)
+	}
+
+	obj.extract = map[string]string{"missing": "connection.user"}
+	if err := setResourceState(obj, d); err == nil {
+		t.Fatalf("Error: Expected an error extracting a value that is not in the response")
+	}
+}
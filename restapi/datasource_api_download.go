@@ -0,0 +1,83 @@
+package restapi
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRestAPIDownload() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRestAPIDownloadRead,
+		Description: "Downloads content from the API server, which may be binary, such as a certificate bundle generated for an object. To write the content to a file, use the `restapi_download` resource.",
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider to download the content from.",
+				Required:    true,
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The headers to send with the request, on top of and taking precedence over the headers set in the provider, such as an `Accept` header.",
+				Optional:    true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"content": {
+				Type:        schema.TypeString,
+				Description: "The content, if it is valid UTF-8 text. Otherwise, an empty string, and the content is only available as `content_base64`.",
+				Computed:    true,
+			},
+			"content_base64": {
+				Type:        schema.TypeString,
+				Description: "The content encoded as base64, which works for binary content as well.",
+				Computed:    true,
+			},
+			"content_sha256": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 checksum of the content, in hex.",
+				Computed:    true,
+			},
+			"content_type": {
+				Type:        schema.TypeString,
+				Description: "The `Content-Type` header of the response.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+func dataSourceRestAPIDownloadRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	path := d.Get("path").(string)
+	client := meta.(*APIClient)
+
+	resp, err := client.sendCachedRequest(ctx, "GET", path, expandHeaders(d.Get("headers").(map[string]interface{})))
+	if err != nil {
+		return diag.Errorf("failed to download '%s': %v", path, err)
+	}
+	if d.Get("debug").(bool) {
+		log.Printf("datasource_api_download.go: Downloaded %d bytes from '%s'", len(resp.body), path)
+	}
+
+	content := ""
+	if utf8.ValidString(resp.body) {
+		content = resp.body
+	}
+
+	d.SetId(path)
+	d.Set("content", content)
+	d.Set("content_base64", base64.StdEncoding.EncodeToString([]byte(resp.body)))
+	d.Set("content_sha256", sha256Hex(resp.body))
+	d.Set("content_type", resp.headers.Get("Content-Type"))
+	return nil
+}
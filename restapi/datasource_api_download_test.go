@@ -0,0 +1,44 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceRestAPIDownloadRead(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1/bundle", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0x00, 0xff, 0x10})
+	})
+	serverMux.HandleFunc("/api/objects/1/cert", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("-----BEGIN CERTIFICATE-----"))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	d := schema.TestResourceDataRaw(t, dataSourceRestAPIDownload().Schema, map[string]interface{}{"path": "/api/objects/1/bundle"})
+	if diags := dataSourceRestAPIDownloadRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("datasource_api_download_test.go: Failed to download: %v", diags)
+	}
+	if d.Get("content") != "" || d.Get("content_base64") != "AP8Q" || d.Get("content_type") != "application/octet-stream" {
+		t.Fatalf("datasource_api_download_test.go: Unexpected binary download: '%s' '%s' '%s'", d.Get("content"), d.Get("content_base64"), d.Get("content_type"))
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceRestAPIDownload().Schema, map[string]interface{}{"path": "/api/objects/1/cert"})
+	if diags := dataSourceRestAPIDownloadRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("datasource_api_download_test.go: Failed to download: %v", diags)
+	}
+	if d.Get("content") != "-----BEGIN CERTIFICATE-----" || d.Get("content_sha256") != sha256Hex("-----BEGIN CERTIFICATE-----") {
+		t.Fatalf("datasource_api_download_test.go: Unexpected text download: '%s' '%s'", d.Get("content"), d.Get("content_sha256"))
+	}
+}
@@ -0,0 +1,147 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRestAPIExists() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRestAPIExistsRead,
+		Description: "Reports whether an object exists on the API server, by its id or by a search, without failing if it does not. Use it to create an object only if it does not exist yet, or to adopt it if it does.",
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server.",
+				Required:    true,
+			},
+			"object_id": {
+				Type:         schema.TypeString,
+				Description:  "The id of the object to look for at `read_path`.",
+				Optional:     true,
+				ExactlyOneOf: []string{"object_id", "search_key"},
+			},
+			"read_path": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `path/{id}`. The API path that represents where to READ (GET) objects of this type on the API server. The string `{id}` will be replaced with `object_id`.",
+				Optional:    true,
+			},
+			"not_found_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes of reads of `object_id` that mean the object does not exist, such as `410` or `403`. Any other failure fails the read. Defaults to `[404]`.",
+			},
+			"search_key": {
+				Type:         schema.TypeString,
+				Description:  "The key of the records at `search_path` to search for `search_value`, instead of reading the object by its id. The same as `search_key` of the `restapi_object` data source.",
+				Optional:     true,
+				RequiredWith: []string{"search_value"},
+			},
+			"search_value": {
+				Type:         schema.TypeString,
+				Description:  "The value of `search_key` of the object to look for.",
+				Optional:     true,
+				RequiredWith: []string{"search_key"},
+			},
+			"search_path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider that represents the location to search for objects of this type on the API server. If not set, defaults to the value of path.",
+				Optional:    true,
+			},
+			"results_key": {
+				Type:        schema.TypeString,
+				Description: "When issuing a GET to the path, this JSON key is used to locate the results array. The format is 'field/field/field'. Example: 'results/values'. If omitted, it is assumed the results coming back are already an array and are to be used exactly as-is.",
+				Optional:    true,
+			},
+			"query_string": {
+				Type:        schema.TypeString,
+				Description: "An optional query string to send when reading or searching for the object.",
+				Optional:    true,
+			},
+			"pagination": paginationSchema(),
+			"id_attribute": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `id_attribute` set on the provider. Allows per-resource override of `id_attribute` (see `id_attribute` provider config documentation)",
+				Optional:    true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"exists": {
+				Type:        schema.TypeBool,
+				Description: "Whether the object exists.",
+				Computed:    true,
+			},
+			"found_id": {
+				Type:        schema.TypeString,
+				Description: "The id of the object if it exists, or else an empty string.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+func dataSourceRestAPIExistsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	path := d.Get("path").(string)
+	queryString := d.Get("query_string").(string)
+	debug := d.Get("debug").(bool)
+	client := meta.(*APIClient)
+
+	pagination, err := expandPagination(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	opts := &apiObjectOpts{
+		path:            path,
+		searchPath:      d.Get("search_path").(string),
+		pagination:      pagination,
+		cacheReads:      true,
+		debug:           debug,
+		id:              d.Get("object_id").(string),
+		idAttribute:     d.Get("id_attribute").(string),
+		readQueryString: queryString,
+		notFoundCodes:   expandIntList(d.Get("not_found_codes").([]interface{})),
+	}
+	if v, ok := d.GetOk("read_path"); ok {
+		opts.getPath = v.(string)
+	}
+	obj, err := NewAPIObject(client, opts)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if obj.id != "" {
+		/* readObject forgets the id if the object is not there */
+		err = obj.readObject(ctx)
+	} else {
+		searchKey := d.Get("search_key").(string)
+		searchValue := d.Get("search_value").(string)
+		_, err = obj.findObject(ctx, queryString, searchKey, searchValue, d.Get("results_key").(string))
+		var notFound *notFoundError
+		if obj.id == "" && errors.As(err, &notFound) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if debug {
+		log.Printf("datasource_api_exists.go: The object at '%s' exists: %t", path, obj.id != "")
+	}
+
+	d.SetId(path)
+	d.Set("exists", obj.id != "")
+	d.Set("found_id", obj.id)
+	return nil
+}
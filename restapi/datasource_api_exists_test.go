@@ -0,0 +1,63 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceRestAPIExistsRead(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[ { "id": "1", "name": "foo" } ]`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1", "name": "foo" }`))
+	})
+	serverMux.HandleFunc("/api/objects/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	serverMux.HandleFunc("/api/objects/3", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "id",
+	})
+
+	testCases := []struct {
+		config  map[string]interface{}
+		exists  bool
+		foundID string
+	}{
+		{map[string]interface{}{"object_id": "1"}, true, "1"},
+		{map[string]interface{}{"object_id": "2"}, false, ""},
+		{map[string]interface{}{"search_key": "name", "search_value": "foo"}, true, "1"},
+		{map[string]interface{}{"search_key": "name", "search_value": "bar"}, false, ""},
+	}
+	for _, tc := range testCases {
+		tc.config["path"] = "/api/objects"
+		d := schema.TestResourceDataRaw(t, dataSourceRestAPIExists().Schema, tc.config)
+		if diags := dataSourceRestAPIExistsRead(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("datasource_api_exists_test.go: Failed to look for %v: %v", tc.config, diags)
+		}
+		if d.Get("exists") != tc.exists || d.Get("found_id") != tc.foundID {
+			t.Fatalf("datasource_api_exists_test.go: Expected %v to exist %t with id '%s' but got %t '%s'", tc.config, tc.exists, tc.foundID, d.Get("exists"), d.Get("found_id"))
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceRestAPIExists().Schema, map[string]interface{}{
+		"path":      "/api/objects",
+		"object_id": "3",
+	})
+	if diags := dataSourceRestAPIExistsRead(context.Background(), d, client); !diags.HasError() {
+		t.Fatalf("datasource_api_exists_test.go: Expected a server error to fail the read")
+	}
+}
@@ -2,10 +2,12 @@ package restapi
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func dataSourceRestAPI() *schema.Resource {
@@ -66,20 +68,57 @@ func dataSourceRestAPI() *schema.Resource {
 				Optional:    true,
 			},
 			"search_key": {
-				Type:        schema.TypeString,
-				Description: "When reading search results from the API, this key is used to identify the specific record to read. This should be a unique record such as 'name'. Similar to results_key, the value may be in the format of 'field/field/field' to search for data deeper in the returned object.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "When reading search results from the API, this key is used to identify the specific record to read. This should be a unique record such as 'name'. Similar to results_key, the value may be in the format of 'field/field/field' to search for data deeper in the returned object.",
+				Optional:     true,
+				ExactlyOneOf: []string{"search_key", "search_criteria"},
+				RequiredWith: []string{"search_value"},
 			},
 			"search_value": {
-				Type:        schema.TypeString,
-				Description: "The value of 'search_key' will be compared to this value to determine if the correct object was found. Example: if 'search_key' is 'name' and 'search_value' is 'foo', the record in the array returned by the API with name=foo will be used.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The value of 'search_key' will be compared to this value to determine if the correct object was found. Example: if 'search_key' is 'name' and 'search_value' is 'foo', the record in the array returned by the API with name=foo will be used.",
+				Optional:     true,
+				RequiredWith: []string{"search_key"},
+			},
+			"search_criteria": {
+				Type:        schema.TypeList,
+				Description: "A key and the value it must hold for the record to be used, for collections where a single key is not unique, such as names that are only unique per tenant. May be repeated, and the criteria are combined with `search_operator`. Use this instead of `search_key` and `search_value`.",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The key of the record to compare, in the same format as `search_key`.",
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The value that `key` must hold.",
+						},
+						"match": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "exact",
+							ValidateFunc: validation.StringInSlice(searchMatches, false),
+							Description:  "How to compare `value`: `exact`, `regex`, `prefix` or `suffix` for names with generated parts, `lt`, `le`, `gt` or `ge` for numbers and `bool` for booleans.",
+						},
+					},
+				},
+			},
+			"search_operator": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `and`. Whether the record must match all of `search_criteria` (`and`) or any of them (`or`). A record that lacks the key of a criterion does not match it.",
+				Optional:     true,
+				Default:      "and",
+				ValidateFunc: validation.StringInSlice([]string{"and", "or"}, false),
 			},
 			"results_key": {
 				Type:        schema.TypeString,
 				Description: "When issuing a GET to the path, this JSON key is used to locate the results array. The format is 'field/field/field'. Example: 'results/values'. If omitted, it is assumed the results coming back are already an array and are to be used exactly as-is.",
 				Optional:    true,
 			},
+			"pagination": paginationSchema(),
 			"id_attribute": {
 				Type:        schema.TypeString,
 				Description: "Defaults to `id_attribute` set on the provider. Allows per-resource override of `id_attribute` (see `id_attribute` provider config documentation)",
@@ -96,6 +135,24 @@ func dataSourceRestAPI() *schema.Resource {
 				Description: "After data from the API server is read, this map will include k/v pairs usable in other terraform resources as readable objects. Currently the value is the golang fmt package's representation of the value (simple primitives are set as expected, but complex types like arrays and maps contain golang formatting).",
 				Computed:    true,
 			},
+			"extract": extractSchema("A value to extract from the object into `outputs`, such as the host of a connection. May be repeated."),
+			"outputs": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The values extracted from the object by the `extract` blocks, by name.",
+				Computed:    true,
+			},
+			"api_data_flat": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The values of the object, including nested ones, by their path with their JSON values, such as `metadata.name` or `tags[0]`, like `data_fields` of `restapi_object`. Unlike `api_data`, nested values can be used without decoding the whole object.",
+				Computed:    true,
+			},
+			"api_data_json": {
+				Type:        schema.TypeString,
+				Description: "The data of the object as read from the API server, encoded as JSON. Use `jsondecode()` to access nested structures that `api_data` cannot represent.",
+				Computed:    true,
+			},
 			"api_response": {
 				Type:        schema.TypeString,
 				Description: "The raw body of the HTTP response from the last read of the object.",
@@ -138,16 +195,33 @@ func dataSourceRestAPIRead(ctx context.Context, d *schema.ResourceData, meta int
 
 	searchKey := d.Get("search_key").(string)
 	searchValue := d.Get("search_value").(string)
+	searchOperator := d.Get("search_operator").(string)
 	resultsKey := d.Get("results_key").(string)
 	idAttribute := d.Get("id_attribute").(string)
 
+	criteria := []searchCriterion{}
+	if searchKey != "" {
+		criteria = append(criteria, searchCriterion{key: searchKey, value: searchValue})
+	}
+	for _, v := range d.Get("search_criteria").([]interface{}) {
+		c := v.(map[string]interface{})
+		criteria = append(criteria, searchCriterion{key: c["key"].(string), value: c["value"].(string), match: c["match"].(string)})
+	}
+
 	if debug {
-		log.Printf("datasource_api_object.go:\npath: %s\nsearch_path: %s\nquery_string: %s\nsearch_key: %s\nsearch_value: %s\nresults_key: %s\nid_attribute: %s", path, searchPath, queryString, searchKey, searchValue, resultsKey, idAttribute)
+		log.Printf("datasource_api_object.go:\npath: %s\nsearch_path: %s\nquery_string: %s\nsearch_criteria: %v\nsearch_operator: %s\nresults_key: %s\nid_attribute: %s", path, searchPath, queryString, criteria, searchOperator, resultsKey, idAttribute)
+	}
+
+	pagination, err := expandPagination(d)
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
 	opts := &apiObjectOpts{
 		path:        path,
 		searchPath:  searchPath,
+		pagination:  pagination,
+		cacheReads:  true,
 		debug:       debug,
 		queryString: readQueryString,
 		idAttribute: idAttribute,
@@ -158,7 +232,7 @@ func dataSourceRestAPIRead(ctx context.Context, d *schema.ResourceData, meta int
 		return diag.FromErr(err)
 	}
 
-	if _, err := obj.findObject(ctx, queryString, searchKey, searchValue, resultsKey); err != nil {
+	if _, err := obj.findObjectMatching(ctx, queryString, criteria, searchOperator, resultsKey); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -174,7 +248,104 @@ func dataSourceRestAPIRead(ctx context.Context, d *schema.ResourceData, meta int
 		/* Setting terraform ID tells terraform the object was created or it exists */
 		log.Printf("datasource_api_object.go: Data resource. Returned id is '%s'\n", obj.id)
 		d.SetId(obj.id)
-		setResourceState(obj, d)
+		err = setResourceState(obj, d)
+	}
+	if err == nil {
+		err = setDataSourceOutputs(obj, d)
 	}
 	return diag.FromErr(err)
 }
+
+/* Sets the values of the object that can be used without jsondecode() */
+func setDataSourceOutputs(obj *APIObject, d *schema.ResourceData) error {
+	data := dataOrValue(obj.apiData, obj.apiDataValue)
+	outputs, err := extractValues(data, d.Get("extract").([]interface{}))
+	if err != nil {
+		return err
+	}
+	d.Set("outputs", outputs)
+	d.Set("api_data_flat", valueFields(data))
+	return nil
+}
+
+/* The pagination block of the data sources */
+func paginationSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Fetch every page of a paginated collection before searching it, for APIs that cap the number of objects in a response.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"mode": {
+					Type:         schema.TypeString,
+					Required:     true,
+					Description:  "How to find the next page. `page` counts pages with `page_param` until a page has fewer than `limit` objects or none at all, `cursor` sends the value of `cursor_field` in the response with `cursor_param` until it is empty and `link` follows the `next` URL of the RFC 5988 `Link` header of the response.",
+					ValidateFunc: validation.StringInSlice([]string{"page", "cursor", "link"}, false),
+				},
+				"page_param": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "page",
+					Description: "The query parameter that holds the number of the page when `mode` is `page`.",
+				},
+				"first_page": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Default:     1,
+					Description: "The number of the first page when `mode` is `page`, such as `0` for APIs that count pages from zero.",
+				},
+				"limit_param": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					RequiredWith: []string{"pagination.0.limit"},
+					Description:  "The query parameter that holds `limit`, such as `per_page`.",
+				},
+				"limit": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Description: "The number of objects to ask for per page with `limit_param`.",
+				},
+				"cursor_field": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The field of the response that holds the cursor of the next page when `mode` is `cursor`. To use a nested field, separate the keys with a slash: 'meta/next_cursor'. If it holds the URL or path of the next page, such as `next` of some APIs, the page is fetched from it as is.",
+				},
+				"cursor_param": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "cursor",
+					Description: "The query parameter that holds the cursor when `mode` is `cursor`.",
+				},
+				"max_pages": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Default:     100,
+					Description: "The number of pages to fetch at most. Collections with more pages fail to read instead of being searched in part.",
+				},
+			},
+		},
+	}
+}
+
+func expandPagination(d *schema.ResourceData) (*paginationOpts, error) {
+	v := d.Get("pagination").([]interface{})
+	if len(v) == 0 || v[0] == nil {
+		return nil, nil
+	}
+	p := v[0].(map[string]interface{})
+	pagination := &paginationOpts{
+		mode:        p["mode"].(string),
+		pageParam:   p["page_param"].(string),
+		firstPage:   p["first_page"].(int),
+		limitParam:  p["limit_param"].(string),
+		limit:       p["limit"].(int),
+		cursorField: p["cursor_field"].(string),
+		cursorParam: p["cursor_param"].(string),
+		maxPages:    p["max_pages"].(int),
+	}
+	if pagination.mode == "cursor" && pagination.cursorField == "" {
+		return nil, fmt.Errorf("pagination cursor_field must be set when mode is 'cursor'")
+	}
+	return pagination, nil
+}
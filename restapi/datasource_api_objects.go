@@ -0,0 +1,244 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRestAPIObjects() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRestAPIObjectsRead,
+		Description: "Reads all of the objects of a collection on the API server, such as to `for_each` over objects that were not created by Terraform.",
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider that lists the objects of the collection.",
+				Required:    true,
+			},
+			"query_string": {
+				Type:        schema.TypeString,
+				Description: "An optional query string to send when listing the objects.",
+				Optional:    true,
+			},
+			"results_key": {
+				Type:        schema.TypeString,
+				Description: "When issuing a GET to the path, this JSON key is used to locate the results array. The format is 'field/field/field'. Example: 'results/values'. If omitted, it is assumed the results coming back are already an array and are to be used exactly as-is.",
+				Optional:    true,
+			},
+			"pagination": paginationSchema(),
+			"id_attribute": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `id_attribute` set on the provider. Allows per-resource override of `id_attribute` (see `id_attribute` provider config documentation)",
+				Optional:    true,
+			},
+			"filter": {
+				Type:        schema.TypeList,
+				Description: "Only return the objects for which `expression` holds one of `values`. Objects without the value, such as those that lack the field, are left out. May be repeated, and the objects must pass all of the filters.",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"expression": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateExpression,
+							Description:  "A JMESPath expression that finds the value of the object to check, such as `status` or `metadata.labels.env`. Supports the same syntax as `id_expression`.",
+						},
+						"values": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Required:    true,
+							Description: "The values of `expression` of the objects to return. Values that are not strings or numbers are compared as JSON, such as `true`.",
+						},
+					},
+				},
+			},
+			"extract": extractSchema("A value to extract from each object into `fields` of the objects, such as the host of a connection. May be repeated."),
+			"key_expression": {
+				Type:         schema.TypeString,
+				ValidateFunc: validateExpression,
+				Description:  "A JMESPath expression that finds the key of each object in `import_ids` and `objects_by_key`, such as `name`, to use as the `for_each` keys of the `restapi_object` resources. The keys must be unique. Supports the same syntax as `id_expression`. Defaults to the id of the object.",
+				Optional:     true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The ids of the objects, in the order of the API server.",
+				Computed:    true,
+			},
+			"objects": {
+				Type:        schema.TypeList,
+				Description: "The objects, in the order of the API server.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The id of the object.",
+						},
+						"api_data_json": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The object as read from the API server, encoded as JSON. Use `jsondecode()` to access its fields.",
+						},
+						"fields": {
+							Type:        schema.TypeMap,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Computed:    true,
+							Description: "The values extracted from the object by the `extract` blocks, by name.",
+						},
+					},
+				},
+			},
+			"objects_json": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The objects encoded as JSON by their id, for `for_each`.",
+				Computed:    true,
+			},
+			"objects_by_key": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The objects encoded as JSON by their key from `key_expression`, for `for_each`.",
+				Computed:    true,
+			},
+			"import_ids": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The ids to import the objects as `restapi_object` resources with, which are `path/{id}`, by their key from `key_expression`. Use it with `for_each` in an `import` block to import all of the objects at once.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+func dataSourceRestAPIObjectsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	path := d.Get("path").(string)
+	queryString := d.Get("query_string").(string)
+	resultsKey := d.Get("results_key").(string)
+	debug := d.Get("debug").(bool)
+	client := meta.(*APIClient)
+	if debug {
+		log.Printf("datasource_api_objects.go: Data routine called.")
+	}
+
+	pagination, err := expandPagination(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	obj, err := NewAPIObject(client, &apiObjectOpts{
+		path:        path,
+		pagination:  pagination,
+		cacheReads:  true,
+		debug:       debug,
+		idAttribute: d.Get("id_attribute").(string),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	results, searchPath, err := obj.listObjects(ctx, queryString, resultsKey)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ids := []string{}
+	objects := []interface{}{}
+	objectsJSON := make(map[string]string)
+	objectsByKey := make(map[string]string)
+	importIDs := make(map[string]string)
+	keyExpression := d.Get("key_expression").(string)
+	for _, item := range results {
+		hash, ok := item.(map[string]interface{})
+		if !ok {
+			return diag.Errorf("the objects at '%s' are not a map of key value pairs", searchPath)
+		}
+
+		if !matchesFilters(hash, d.Get("filter").([]interface{}), debug) {
+			continue
+		}
+
+		id, err := obj.idFromData(hash)
+		if err != nil || id == "" {
+			return diag.Errorf("failed to find the id attribute '%s' of an object at '%s': %v", obj.idAttribute, searchPath, err)
+		}
+		if _, ok := objectsJSON[id]; ok {
+			return diag.Errorf("more than one object at '%s' has the id '%s'", searchPath, id)
+		}
+
+		fields, err := extractValues(hash, d.Get("extract").([]interface{}))
+		if err != nil {
+			return diag.Errorf("object '%s': %v", id, err)
+		}
+
+		key := id
+		if keyExpression != "" {
+			if key, err = evalExpression(hash, keyExpression); err != nil || key == "" {
+				return diag.Errorf("failed to find the key '%s' of object '%s' at '%s': %v", keyExpression, id, searchPath, err)
+			}
+		}
+		if _, ok := objectsByKey[key]; ok {
+			return diag.Errorf("more than one object at '%s' has the key '%s'", searchPath, key)
+		}
+
+		apiDataJSON, _ := json.Marshal(hash)
+		ids = append(ids, id)
+		objectsJSON[id] = string(apiDataJSON)
+		objectsByKey[key] = string(apiDataJSON)
+		importIDs[key] = strings.TrimSuffix(path, "/") + "/" + id
+		objects = append(objects, map[string]interface{}{
+			"id":            id,
+			"api_data_json": string(apiDataJSON),
+			"fields":        fields,
+		})
+	}
+
+	if debug {
+		log.Printf("datasource_api_objects.go: Found %d objects at '%s'", len(ids), searchPath)
+	}
+
+	d.SetId(searchPath)
+	d.Set("ids", ids)
+	d.Set("objects", objects)
+	d.Set("objects_json", objectsJSON)
+	d.Set("objects_by_key", objectsByKey)
+	d.Set("import_ids", importIDs)
+	return nil
+}
+
+/*
+Whether an object passes all of the filter blocks of the data source.
+
+	Objects that the expression of a filter fails on, such as those
+	without the field, do not pass it. Values that are not strings or
+	numbers are compared as JSON, such as `true`.
+*/
+func matchesFilters(hash map[string]interface{}, filters []interface{}, debug bool) bool {
+	for _, v := range filters {
+		f := v.(map[string]interface{})
+		value, err := evalExpressionValue(hash, f["expression"].(string))
+		if err != nil {
+			if debug {
+				log.Printf("datasource_api_objects.go: Filtering out %v: %v", hash, err)
+			}
+			return false
+		}
+		if !containsString(expandStringList(f["values"].([]interface{})), toExpressionString(value).(string)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceRestAPIObjectsRead(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "items": [
+			{ "id": "1", "name": "web", "enabled": true, "meta": { "env": "prod" } },
+			{ "id": "2", "name": "db", "enabled": false, "meta": { "env": "prod" } },
+			{ "id": "3", "name": "cache", "enabled": true },
+			{ "id": "4", "name": "queue", "enabled": true, "meta": { "env": "prod" } }
+		] }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "id",
+	})
+
+	d := schema.TestResourceDataRaw(t, dataSourceRestAPIObjects().Schema, map[string]interface{}{
+		"path":        "/api/objects",
+		"results_key": "items",
+		"filter": []interface{}{
+			map[string]interface{}{"expression": "meta.env", "values": []interface{}{"prod"}},
+			map[string]interface{}{"expression": "enabled", "values": []interface{}{"true"}},
+		},
+		"extract": []interface{}{
+			map[string]interface{}{"name": "name", "expression": "name"},
+		},
+		"key_expression": "name",
+	})
+	if diags := dataSourceRestAPIObjectsRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("datasource_api_objects_test.go: Failed to read the objects: %v", diags)
+	}
+
+	if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, []interface{}{"1", "4"}) {
+		t.Fatalf("datasource_api_objects_test.go: Expected the filtered ids [1 4] but got %v", ids)
+	}
+	if name := d.Get("objects.1.fields.name"); name != "queue" {
+		t.Fatalf("datasource_api_objects_test.go: Expected the extracted name of the second object to be 'queue' but got '%v'", name)
+	}
+	if data := d.Get("objects_json.1"); data != `{"enabled":true,"id":"1","meta":{"env":"prod"},"name":"web"}` {
+		t.Fatalf("datasource_api_objects_test.go: Unexpected JSON of object '1': %v", data)
+	}
+	if importIDs := d.Get("import_ids").(map[string]interface{}); !reflect.DeepEqual(importIDs, map[string]interface{}{"web": "/api/objects/1", "queue": "/api/objects/4"}) {
+		t.Fatalf("datasource_api_objects_test.go: Unexpected import_ids %v", importIDs)
+	}
+	if data := d.Get("objects_by_key.queue"); data != `{"enabled":true,"id":"4","meta":{"env":"prod"},"name":"queue"}` {
+		t.Fatalf("datasource_api_objects_test.go: Unexpected JSON of object 'queue': %v", data)
+	}
+}
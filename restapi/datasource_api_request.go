@@ -0,0 +1,124 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRestAPIRequest() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRestAPIRequestRead,
+		Description: "Sends a request to the API server when the data source is read, for lookups against endpoints that do not represent objects, such as a search or a status endpoint.",
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider to send the request to.",
+				Required:    true,
+			},
+			"method": {
+				Type:         schema.TypeString,
+				Description:  "The HTTP method of the request. Keep in mind that the request is sent on every plan, so it should not change anything on the API server. Default: GET",
+				Optional:     true,
+				Default:      "GET",
+				ValidateFunc: validateHTTPMethod,
+			},
+			"query": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The parameters to add to the query string of the request. They are escaped as needed.",
+				Optional:    true,
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The headers to send with the request, on top of and taking precedence over the headers set in the provider.",
+				Optional:    true,
+			},
+			"body": {
+				Type:        schema.TypeString,
+				Description: "The body of the request. By default, no body is sent.",
+				Optional:    true,
+			},
+			"success_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "The HTTP status codes of responses that do not fail the read, such as `[200, 404]` to look up something that may not exist. Defaults to any 2xx status code.",
+				Optional:    true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"status_code": {
+				Type:        schema.TypeInt,
+				Description: "The HTTP status code of the response.",
+				Computed:    true,
+			},
+			"response_headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The headers of the response. Headers that are repeated are joined with a comma.",
+				Computed:    true,
+			},
+			"response_body": {
+				Type:        schema.TypeString,
+				Description: "The body of the response. Use `jsondecode()` to access the fields of a JSON response.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+func dataSourceRestAPIRequestRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	method := d.Get("method").(string)
+	path := d.Get("path").(string)
+	debug := d.Get("debug").(bool)
+	client := meta.(*APIClient)
+
+	/* Sorted for a stable path */
+	query := d.Get("query").(map[string]interface{})
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path = withQueryParam(path, k, query[k].(string))
+	}
+
+	headers := expandHeaders(d.Get("headers").(map[string]interface{}))
+
+	if debug {
+		log.Printf("datasource_api_request.go: Sending %s %s", method, path)
+	}
+	var resp *apiClientResponse
+	var err error
+	if body := d.Get("body").(string); body != "" {
+		resp, err = client.sendRequestWithResponse(ctx, method, path, body, headers)
+	} else {
+		resp, err = client.sendCachedRequest(ctx, method, path, headers)
+	}
+
+	/* The status code alone decides when success_codes are set */
+	successCodes := expandIntList(d.Get("success_codes").([]interface{}))
+	if resp.statusCode == 0 || (len(successCodes) == 0 && err != nil) {
+		return diag.Errorf("%s %s: %v", method, path, err)
+	}
+	if len(successCodes) > 0 && !containsInt(successCodes, resp.statusCode) {
+		return diag.Errorf("%s %s: unexpected response code '%d': %s", method, path, resp.statusCode, resp.body)
+	}
+
+	d.SetId(fmt.Sprintf("%s %s", method, path))
+	d.Set("status_code", resp.statusCode)
+	d.Set("response_headers", joinHeaders(resp.headers))
+	d.Set("response_body", resp.body)
+	return nil
+}
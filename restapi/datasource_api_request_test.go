@@ -0,0 +1,59 @@
+package restapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceRestAPIRequestRead(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/lookup", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "foo bar" || r.Header.Get("X-Tenant") != "a" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Request-Id", "42")
+		w.Write([]byte(`{ "method": "` + r.Method + `", "body": ` + string(body) + ` }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	read := func(raw map[string]interface{}) (*schema.ResourceData, bool) {
+		d := schema.TestResourceDataRaw(t, dataSourceRestAPIRequest().Schema, raw)
+		return d, !dataSourceRestAPIRequestRead(context.Background(), d, client).HasError()
+	}
+
+	d, ok := read(map[string]interface{}{
+		"path":    "/api/lookup",
+		"method":  "POST",
+		"query":   map[string]interface{}{"name": "foo bar"},
+		"headers": map[string]interface{}{"X-Tenant": "a"},
+		"body":    `{ "q": 1 }`,
+	})
+	if !ok {
+		t.Fatalf("datasource_api_request_test.go: Failed to send the request")
+	}
+	if d.Get("status_code") != 200 || d.Get("response_headers.X-Request-Id") != "42" || d.Get("response_body") != `{ "method": "POST", "body": { "q": 1 } }` {
+		t.Fatalf("datasource_api_request_test.go: Unexpected response: %d %v %s", d.Get("status_code"), d.Get("response_headers"), d.Get("response_body"))
+	}
+
+	if _, ok := read(map[string]interface{}{"path": "/api/lookup"}); ok {
+		t.Fatalf("datasource_api_request_test.go: Expected a 404 to fail the read")
+	}
+
+	d, ok = read(map[string]interface{}{"path": "/api/lookup", "success_codes": []interface{}{200, 404}})
+	if !ok || d.Get("status_code") != 404 {
+		t.Fatalf("datasource_api_request_test.go: Expected a 404 to be accepted with success_codes but got %d", d.Get("status_code"))
+	}
+}
@@ -0,0 +1,112 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceRestAPIResponseHeaders() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceRestAPIResponseHeadersRead,
+		Description: "Sends a HEAD or GET request to the API server and exposes the headers of the response, such as the version of the API or feature flags, to act on the capabilities of the server without parsing a body.",
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider to send the request to.",
+				Required:    true,
+			},
+			"method": {
+				Type:         schema.TypeString,
+				Description:  "The HTTP method of the request, `HEAD` or `GET` for servers that do not answer HEAD requests. Default: HEAD",
+				Optional:     true,
+				Default:      "HEAD",
+				ValidateFunc: validation.StringInSlice([]string{"HEAD", "GET"}, false),
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The headers to send with the request, on top of and taking precedence over the headers set in the provider.",
+				Optional:    true,
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The names of the headers to expose, such as `X-API-Version`. They are matched regardless of case and kept as written here, so that they can be looked up in `response_headers` as they are. By default, all headers are exposed by their canonical name, such as `X-Api-Version`.",
+				Optional:    true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"status_code": {
+				Type:        schema.TypeInt,
+				Description: "The HTTP status code of the response.",
+				Computed:    true,
+			},
+			"response_headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The headers of the response. Headers that are repeated are joined with a comma, and headers of `names` that are missing from the response are left out.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+func dataSourceRestAPIResponseHeadersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	method := d.Get("method").(string)
+	path := d.Get("path").(string)
+	client := meta.(*APIClient)
+
+	if d.Get("debug").(bool) {
+		log.Printf("datasource_api_response_headers.go: Sending %s %s", method, path)
+	}
+	resp, err := client.sendCachedRequest(ctx, method, path, expandHeaders(d.Get("headers").(map[string]interface{})))
+	if err != nil {
+		return diag.Errorf("%s %s: %v", method, path, err)
+	}
+
+	responseHeaders := make(map[string]string)
+	if names := expandStringList(d.Get("names").([]interface{})); len(names) > 0 {
+		for _, name := range names {
+			if values := resp.headers.Values(name); len(values) > 0 {
+				responseHeaders[name] = strings.Join(values, ", ")
+			}
+		}
+	} else {
+		responseHeaders = joinHeaders(resp.headers)
+	}
+
+	d.SetId(fmt.Sprintf("%s %s", method, path))
+	d.Set("status_code", resp.statusCode)
+	d.Set("response_headers", responseHeaders)
+	return nil
+}
+
+/* Converts a map of headers from the configuration for a request */
+func expandHeaders(configured map[string]interface{}) map[string]string {
+	headers := make(map[string]string)
+	for k, v := range configured {
+		headers[k] = v.(string)
+	}
+	return headers
+}
+
+/* Joins repeated headers of a response with a comma */
+func joinHeaders(headers http.Header) map[string]string {
+	joined := make(map[string]string)
+	for k, v := range headers {
+		joined[k] = strings.Join(v, ", ")
+	}
+	return joined
+}
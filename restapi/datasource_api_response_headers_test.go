@@ -0,0 +1,51 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceRestAPIResponseHeadersRead(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("X-API-Version", "2.3")
+		w.Header().Add("X-Feature", "bulk")
+		w.Header().Add("X-Feature", "search")
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	d := schema.TestResourceDataRaw(t, dataSourceRestAPIResponseHeaders().Schema, map[string]interface{}{
+		"path": "/api/version",
+	})
+	if diags := dataSourceRestAPIResponseHeadersRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("datasource_api_response_headers_test.go: Failed to read the headers: %v", diags)
+	}
+	if d.Get("response_headers.X-Api-Version") != "2.3" || d.Get("response_headers.X-Feature") != "bulk, search" {
+		t.Fatalf("datasource_api_response_headers_test.go: Unexpected headers %v", d.Get("response_headers"))
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceRestAPIResponseHeaders().Schema, map[string]interface{}{
+		"path":  "/api/version",
+		"names": []interface{}{"X-API-Version", "X-Missing"},
+	})
+	if diags := dataSourceRestAPIResponseHeadersRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("datasource_api_response_headers_test.go: Failed to read the headers: %v", diags)
+	}
+	if headers := d.Get("response_headers").(map[string]interface{}); len(headers) != 1 || headers["X-API-Version"] != "2.3" {
+		t.Fatalf("datasource_api_response_headers_test.go: Expected only X-API-Version as written in names but got %v", headers)
+	}
+}
@@ -1,16 +1,22 @@
 package restapi
 
 import (
+	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 /*
  * Performs a deep comparison of two maps - the resource as recorded in state, and the resource as returned by the API.
  * Accepts a third argument that is a set of fields that are to be ignored when looking for differences.
+ * listKeys maps the paths of lists of objects to the key that pairs up their items, such as rules=name.
  * Returns 1. the recordedResource overlaid with fields that have been modified in actualResource but not ignored, and 2. a bool true if there were any changes.
  */
-func getDelta(recordedResource map[string]interface{}, actualResource map[string]interface{}, ignoreList []string, driftFields map[string]interface{}) (modifiedResource map[string]interface{}, hasChanges bool) {
+func getDelta(recordedResource map[string]interface{}, actualResource map[string]interface{}, ignoreList []string, driftFields map[string]interface{}, listKeys map[string]string) (modifiedResource map[string]interface{}, hasChanges bool) {
 	modifiedResource = map[string]interface{}{}
 	hasChanges = false
 
@@ -22,7 +28,7 @@ func getDelta(recordedResource map[string]interface{}, actualResource map[string
 		checkedKeys[key] = struct{}{}
 
 		// If the ignore_list contains the current key, don't compare
-		if contains(ignoreList, key) {
+		if ignoresKey(ignoreList, key) {
 			modifiedResource[key] = valRecorded
 			continue
 		}
@@ -36,7 +42,7 @@ func getDelta(recordedResource map[string]interface{}, actualResource map[string
 
 		valActual := actualResource[key]
 		// If valRecorded was a map, assert both values are maps
-		if reflect.TypeOf(valRecorded).Kind() == reflect.Map {
+		if reflect.ValueOf(valRecorded).Kind() == reflect.Map {
 			subMapA, okA := valRecorded.(map[string]interface{})
 			subMapB, okB := valActual.(map[string]interface{})
 			if !okA || !okB {
@@ -57,16 +63,28 @@ func getDelta(recordedResource map[string]interface{}, actualResource map[string
 				}
 				nextIncludeMap = nextMap
 			}
-			if modifiedSubResource, hasChange := getDelta(subMapA, subMapB, deeperIgnoreList, nextIncludeMap); hasChange {
+			if modifiedSubResource, hasChange := getDelta(subMapA, subMapB, deeperIgnoreList, nextIncludeMap, _descendListKeys(key, listKeys)); hasChange {
 				modifiedResource[key] = modifiedSubResource
 				hasChanges = true
 			} else {
 				modifiedResource[key] = valRecorded
 			}
-		} else if reflect.TypeOf(valRecorded).Kind() == reflect.Slice {
-			// Since we don't support ignoring differences in lists (besides ignoring the list as a
-			// whole), it is safe to deep compare the two list values.
-			if !reflect.DeepEqual(valRecorded, valActual) {
+		} else if reflect.ValueOf(valRecorded).Kind() == reflect.Slice {
+			// Lists are compared item by item only if something in them is ignored,
+			// such as with rules[*].updated_at, or if their items are paired up by a key.
+			// Otherwise they are compared as a whole.
+			listA, okA := valRecorded.([]interface{})
+			listB, okB := valActual.([]interface{})
+			deeperIgnoreList := _descendIgnoreList(key, ignoreList)
+			mergeKey := listKeyFor(key, listKeys)
+			if okA && okB && (len(deeperIgnoreList) > 0 || mergeKey != "") {
+				if modifiedList, hasChange := getListDelta(listA, listB, deeperIgnoreList, mergeKey, _descendListKeys(key, listKeys)); hasChange {
+					modifiedResource[key] = modifiedList
+					hasChanges = true
+				} else {
+					modifiedResource[key] = valRecorded
+				}
+			} else if !reflect.DeepEqual(valRecorded, valActual) {
 				modifiedResource[key] = valActual
 				hasChanges = true
 			} else {
@@ -91,7 +109,7 @@ func getDelta(recordedResource map[string]interface{}, actualResource map[string
 
 		// If the ignore_list contains the current key, don't compare.
 		// Don't modify modifiedResource either - we don't want this key to be tracked
-		if contains(ignoreList, key) {
+		if ignoresKey(ignoreList, key) {
 			continue
 		}
 		// if drift_fields does not contain the current key, don't compare
@@ -110,23 +128,265 @@ func getDelta(recordedResource map[string]interface{}, actualResource map[string
 	return modifiedResource, hasChanges
 }
 
+/*
+ * Compares two lists item by item, with an ignoreList and listKeys relative to their items.
+ * Without a mergeKey, items are compared by position and lists of a different length always
+ * differ, as there is no telling which items were added or removed. With a mergeKey, items
+ * are paired up by the value of that key regardless of their order, and items without a
+ * partner differ. Returns the recorded list overlaid with the items that changed, and whether any did.
+ */
+func getListDelta(recordedList []interface{}, actualList []interface{}, ignoreList []string, mergeKey string, listKeys map[string]string) (modifiedList []interface{}, hasChanges bool) {
+	if mergeKey != "" {
+		return getKeyedListDelta(recordedList, actualList, ignoreList, mergeKey, listKeys)
+	}
+	if len(recordedList) != len(actualList) {
+		return actualList, true
+	}
+
+	modifiedList = make([]interface{}, len(recordedList))
+	for i, valRecorded := range recordedList {
+		modifiedItem, hasChange := getItemDelta(i, valRecorded, actualList[i], ignoreList, listKeys)
+		modifiedList[i] = modifiedItem
+		hasChanges = hasChanges || hasChange
+	}
+
+	return modifiedList, hasChanges
+}
+
+func getKeyedListDelta(recordedList []interface{}, actualList []interface{}, ignoreList []string, mergeKey string, listKeys map[string]string) (modifiedList []interface{}, hasChanges bool) {
+	actualByKey := indexListByKey(actualList, mergeKey)
+	paired := map[int]struct{}{}
+
+	modifiedList = []interface{}{}
+	for i, valRecorded := range recordedList {
+		j, ok := -1, false
+		if value, hasKey := itemKeyValue(valRecorded, mergeKey); hasKey {
+			j, ok = actualByKey[value]
+		}
+		if !ok {
+			// The item is gone from the server
+			hasChanges = true
+			continue
+		}
+		paired[j] = struct{}{}
+		modifiedItem, hasChange := getItemDelta(i, valRecorded, actualList[j], ignoreList, listKeys)
+		modifiedList = append(modifiedList, modifiedItem)
+		hasChanges = hasChanges || hasChange
+	}
+	for j, valActual := range actualList {
+		if _, ok := paired[j]; !ok {
+			// The item was added on the server
+			modifiedList = append(modifiedList, valActual)
+			hasChanges = true
+		}
+	}
+
+	return modifiedList, hasChanges
+}
+
+/* Compares the recorded and actual item at index of a list. Returns the item to record and whether it changed */
+func getItemDelta(index int, valRecorded interface{}, valActual interface{}, ignoreList []string, listKeys map[string]string) (interface{}, bool) {
+	itemIgnoreList := _descendIgnoreListToItem(index, []interface{}{valRecorded, valActual}, ignoreList)
+	if containsEmptyPath(itemIgnoreList) {
+		return valRecorded, false
+	}
+	itemListKeys := _descendListKeys("[*]", listKeys)
+
+	subMapA, okA := valRecorded.(map[string]interface{})
+	subMapB, okB := valActual.(map[string]interface{})
+	subListA, okListA := valRecorded.([]interface{})
+	subListB, okListB := valActual.([]interface{})
+	mergeKey := listKeyFor("[*]", listKeys)
+	if okA && okB {
+		if modifiedSubResource, hasChange := getDelta(subMapA, subMapB, itemIgnoreList, nil, itemListKeys); hasChange {
+			return modifiedSubResource, true
+		}
+	} else if okListA && okListB && (len(itemIgnoreList) > 0 || mergeKey != "") {
+		if modifiedSubList, hasChange := getListDelta(subListA, subListB, itemIgnoreList, mergeKey, itemListKeys); hasChange {
+			return modifiedSubList, true
+		}
+	} else if !reflect.DeepEqual(valRecorded, valActual) {
+		return valActual, true
+	}
+	return valRecorded, false
+}
+
+/* Maps the value of key in each item of a list to the index of the item */
+func indexListByKey(list []interface{}, key string) map[string]int {
+	byKey := map[string]int{}
+	for i, item := range list {
+		if value, ok := itemKeyValue(item, key); ok {
+			byKey[value] = i
+		}
+	}
+	return byKey
+}
+
+/* Returns the key that pairs up the items of the list at path, or "" if there is none */
+func listKeyFor(path string, listKeys map[string]string) string {
+	for listPath, key := range listKeys {
+		if components := splitIgnorePath(listPath); len(components) == 1 && components[0] == path {
+			return key
+		}
+	}
+	return ""
+}
+
+/*
+ * Modifies listKeys to be relative to a descended path, like _descendIgnoreList.
+ * Items of lists are descended into with the component "[*]".
+ */
+func _descendListKeys(descendPath string, listKeys map[string]string) map[string]string {
+	var newListKeys map[string]string
+	for listPath, key := range listKeys {
+		components := splitIgnorePath(listPath)
+		if len(components) > 1 && components[0] == descendPath {
+			if newListKeys == nil {
+				newListKeys = map[string]string{}
+			}
+			newListKeys[strings.Join(components[1:], ".")] = key
+		}
+	}
+	return newListKeys
+}
+
+/*
+ * Splits a path of the ignoreList into its components. Keys are separated by dots and list
+ * items are addressed in brackets, so "rules[*].updated_at" gives [rules, [*], updated_at]
+ * and "members[name=a.b].last_seen" gives [members, [name=a.b], last_seen].
+ * Keys may be the wildcard "*" to match any key, and "**" matches any number of keys and items.
+ */
+func splitIgnorePath(ignorePath string) []string {
+	components := []string{}
+	var component strings.Builder
+	flush := func() {
+		if component.Len() > 0 {
+			components = append(components, component.String())
+			component.Reset()
+		}
+	}
+	for i := 0; i < len(ignorePath); i++ {
+		switch ignorePath[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(ignorePath[i:], ']')
+			if end < 0 {
+				end = len(ignorePath) - i - 1
+			}
+			components = append(components, ignorePath[i:i+end+1])
+			i += end
+		default:
+			component.WriteByte(ignorePath[i])
+		}
+	}
+	flush()
+	return components
+}
+
+/*
+ * Descends each path of an ignoreList past the components that match, as decided by matches.
+ * A "**" component may match any number of components, so the path is kept as it is and also
+ * descended as if it matched none.
+ */
+func _descendIgnorePaths(ignoreList []string, matches func(component string) bool) []string {
+	newIgnoreList := []string{}
+
+	var descend func(pathComponents []string)
+	descend = func(pathComponents []string) {
+		if len(pathComponents) == 0 {
+			return
+		}
+		if pathComponents[0] == "**" {
+			newIgnoreList = append(newIgnoreList, strings.Join(pathComponents, "."))
+			if len(pathComponents) == 1 {
+				// A trailing "**" matches everything below
+				newIgnoreList = append(newIgnoreList, "")
+			}
+			descend(pathComponents[1:])
+		} else if matches(pathComponents[0]) {
+			newIgnoreList = append(newIgnoreList, strings.Join(pathComponents[1:], "."))
+		}
+	}
+	for _, ignorePath := range ignoreList {
+		descend(splitIgnorePath(ignorePath))
+	}
+
+	return newIgnoreList
+}
+
 /*
  * Modifies an ignoreList to be relative to a descended path.
  * E.g. given descendPath = "bar", and the ignoreList [foo, bar.alpha, bar.bravo], this returns [alpha, bravo]
+ * An empty path in the result means that the whole descended path is ignored.
  */
 func _descendIgnoreList(descendPath string, ignoreList []string) []string {
-	newIgnoreList := make([]string, len(ignoreList))
+	return _descendIgnorePaths(ignoreList, func(component string) bool {
+		return component == descendPath || component == "*"
+	})
+}
 
-	for _, ignorePath := range ignoreList {
-		pathComponents := strings.Split(ignorePath, ".")
-		// If this ignorePath starts with the descendPath, remove the first component and keep the rest
-		if pathComponents[0] == descendPath {
-			modifiedPath := strings.Join(pathComponents[1:], ".")
-			newIgnoreList = append(newIgnoreList, modifiedPath)
+/*
+ * Like _descendIgnoreList, but descends into the item of a list at index. Besides [*], the item
+ * may be addressed by its index as in [2], or by the value of one of its keys as in [id=42].
+ * The key is matched in both the recorded and the actual item, as either may hold it.
+ */
+func _descendIgnoreListToItem(index int, items []interface{}, ignoreList []string) []string {
+	return _descendIgnorePaths(ignoreList, func(component string) bool {
+		if !strings.HasPrefix(component, "[") || !strings.HasSuffix(component, "]") {
+			return false
+		}
+		selector := component[1 : len(component)-1]
+		if selector == "*" {
+			return true
+		}
+		if key, value, ok := strings.Cut(selector, "="); ok {
+			for _, item := range items {
+				if itemHasValue(item, key, value) {
+					return true
+				}
+			}
+			return false
 		}
+		i, err := strconv.Atoi(selector)
+		return err == nil && i == index
+	})
+}
+
+/* Reports whether item is an object with value at key */
+func itemHasValue(item interface{}, key string, value string) bool {
+	found, ok := itemKeyValue(item, key)
+	return ok && found == value
+}
+
+/* Returns the value of key in item as a string, so [id=42] matches 42 */
+func itemKeyValue(item interface{}, key string) (string, bool) {
+	hash, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
 	}
+	found, ok := hash[key]
+	if !ok {
+		return "", false
+	}
+	switch v := found.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprintf("%v", v), true
+	}
+}
 
-	return newIgnoreList
+/* Reports whether the ignoreList ignores key, either by name or with a wildcard */
+func ignoresKey(ignoreList []string, key string) bool {
+	return containsEmptyPath(_descendIgnoreList(key, ignoreList))
+}
+
+func containsEmptyPath(ignoreList []string) bool {
+	return contains(ignoreList, "")
 }
 
 func contains(list []string, elem string) bool {
@@ -137,3 +397,539 @@ func contains(list []string, elem string) bool {
 	}
 	return false
 }
+
+/*
+ * Reports whether oldValue and newValue differ in any of the fields in triggers, which use the
+ * syntax of ignore_changes_to. A field that is only in one of them is compared against nil.
+ */
+func hasTriggeredChange(oldValue interface{}, newValue interface{}, triggers []string) bool {
+	if len(triggers) == 0 {
+		return false
+	}
+	if containsEmptyPath(triggers) {
+		return !reflect.DeepEqual(oldValue, newValue)
+	}
+
+	subMapA, okA := oldValue.(map[string]interface{})
+	subMapB, okB := newValue.(map[string]interface{})
+	if okA || okB {
+		keys := GetKeys(subMapA)
+		for key := range subMapB {
+			if _, ok := subMapA[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			if hasTriggeredChange(subMapA[key], subMapB[key], _descendIgnoreList(key, triggers)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	listA, _ := oldValue.([]interface{})
+	listB, _ := newValue.([]interface{})
+	for i := 0; i < len(listA) || i < len(listB); i++ {
+		var itemA, itemB interface{}
+		if i < len(listA) {
+			itemA = listA[i]
+		}
+		if i < len(listB) {
+			itemB = listB[i]
+		}
+		if hasTriggeredChange(itemA, itemB, _descendIgnoreListToItem(i, []interface{}{itemA, itemB}, triggers)) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Reports whether a path as listed in drift, such as rules[0].port, is one of the fields in
+ * patterns, which use the syntax of ignore_changes_to. value is the object the path is in, which
+ * is used to address the items of lists by the value of their keys.
+ */
+func pathMatches(path string, value interface{}, patterns []string) bool {
+	for _, component := range splitIgnorePath(path) {
+		if len(patterns) == 0 || containsEmptyPath(patterns) {
+			break
+		}
+		if strings.HasPrefix(component, "[") {
+			index, _ := strconv.Atoi(strings.Trim(component, "[]"))
+			list, _ := value.([]interface{})
+			value = nil
+			if index < len(list) {
+				value = list[index]
+			}
+			patterns = _descendIgnoreListToItem(index, []interface{}{value}, patterns)
+		} else {
+			hash, _ := value.(map[string]interface{})
+			value = hash[component]
+			patterns = _descendIgnoreList(component, patterns)
+		}
+	}
+	return containsEmptyPath(patterns)
+}
+
+/* A field that changed on the server, with its JSON values before and after */
+type driftChange struct {
+	path     string
+	oldValue string
+	newValue string
+}
+
+/*
+ * Lists the fields at which modifiedValue, as returned by getDelta, differs from recordedValue.
+ * Paths use the dot syntax of ignore_changes_to, and the value of a field that was added or
+ * removed is empty. Lists of a different length are reported as a whole.
+ */
+func getDriftChanges(recordedValue interface{}, modifiedValue interface{}, path string) []driftChange {
+	if reflect.DeepEqual(recordedValue, modifiedValue) {
+		return nil
+	}
+
+	subMapA, okA := recordedValue.(map[string]interface{})
+	subMapB, okB := modifiedValue.(map[string]interface{})
+	if okA && okB {
+		keys := GetKeys(subMapA)
+		for key := range subMapB {
+			if _, ok := subMapA[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		changes := []driftChange{}
+		for _, key := range keys {
+			subPath := key
+			if path != "" {
+				subPath = path + "." + key
+			}
+			valRecorded, inRecorded := subMapA[key]
+			valModified, inModified := subMapB[key]
+			if !inRecorded {
+				changes = append(changes, driftChange{subPath, "", jsonString(valModified)})
+			} else if !inModified {
+				changes = append(changes, driftChange{subPath, jsonString(valRecorded), ""})
+			} else {
+				changes = append(changes, getDriftChanges(valRecorded, valModified, subPath)...)
+			}
+		}
+		return changes
+	}
+
+	listA, okA := recordedValue.([]interface{})
+	listB, okB := modifiedValue.([]interface{})
+	if okA && okB && len(listA) == len(listB) {
+		changes := []driftChange{}
+		for i := range listA {
+			changes = append(changes, getDriftChanges(listA[i], listB[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return changes
+	}
+
+	return []driftChange{{path, jsonString(recordedValue), jsonString(modifiedValue)}}
+}
+
+/*
+ * Splits actualResource into the fields that are also in recordedResource and the fields that
+ * only the server has, such as ids and timestamps it adds. Nested objects are split recursively,
+ * while lists and other values are kept whole with the configured fields.
+ */
+func splitServerFields(recordedResource map[string]interface{}, actualResource map[string]interface{}) (configuredFields map[string]interface{}, serverFields map[string]interface{}) {
+	configuredFields = map[string]interface{}{}
+	serverFields = map[string]interface{}{}
+
+	for key, valActual := range actualResource {
+		valRecorded, ok := recordedResource[key]
+		if !ok {
+			serverFields[key] = valActual
+			continue
+		}
+		subMapA, okA := valRecorded.(map[string]interface{})
+		subMapB, okB := valActual.(map[string]interface{})
+		if !okA || !okB {
+			configuredFields[key] = valActual
+			continue
+		}
+		subConfigured, subServer := splitServerFields(subMapA, subMapB)
+		configuredFields[key] = subConfigured
+		if len(subServer) > 0 {
+			serverFields[key] = subServer
+		}
+	}
+
+	return configuredFields, serverFields
+}
+
+/*
+ * Returns a copy of actualResource in which scalar values that only differ from recordedResource
+ * in their type or formatting are replaced by the recorded value, so that getDelta does not see
+ * them as changes. Numbers, numeric strings and booleans are compared by value, so "5" matches 5,
+ * "1.0" matches 1 and 1 or "true" match true. Lists are walked by position, or by their key in listKeys.
+ */
+func normalizeTypes(recordedValue interface{}, actualValue interface{}, listKeys map[string]string) interface{} {
+	return normalizeActual(recordedValue, actualValue, "", listKeys, nil, func(recorded interface{}, actual interface{}, _ []string) bool {
+		return looselyEqual(recorded, actual)
+	})
+}
+
+/*
+ * Like normalizeTypes, but compares the values of the fields in fieldNormalizers after applying
+ * their normalizer, such as "timestamp" for created_at. The paths of the fields use the syntax
+ * of ignore_changes_to, so "**.url" applies to every url.
+ */
+func normalizeFields(recordedValue interface{}, actualValue interface{}, listKeys map[string]string, fieldNormalizers map[string]string) interface{} {
+	pathsByNormalizer := map[string][]string{}
+	for path, normalizer := range fieldNormalizers {
+		pathsByNormalizer[normalizer] = append(pathsByNormalizer[normalizer], path)
+	}
+	return normalizeActual(recordedValue, actualValue, "", listKeys, pathsByNormalizer, func(recorded interface{}, actual interface{}, normalizers []string) bool {
+		for _, normalizer := range normalizers {
+			normalizedRecorded, okA := normalizeField(normalizer, recorded)
+			normalizedActual, okB := normalizeField(normalizer, actual)
+			if okA && okB && normalizedRecorded == normalizedActual {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+/*
+ * Walks actualValue alongside recordedValue and replaces the scalars for which equal is true by
+ * the recorded value. The items of lists are paired up by mergeKey if it is set. pathsByNormalizer
+ * holds the paths of the fields of each normalizer relative to the values, and equal is given the
+ * normalizers whose paths end at the scalar.
+ */
+func normalizeActual(recordedValue interface{}, actualValue interface{}, mergeKey string, listKeys map[string]string, pathsByNormalizer map[string][]string, equal func(recorded interface{}, actual interface{}, normalizers []string) bool) interface{} {
+	descend := func(descendPaths func(paths []string) []string) map[string][]string {
+		var descended map[string][]string
+		for normalizer, paths := range pathsByNormalizer {
+			if deeperPaths := descendPaths(paths); len(deeperPaths) > 0 {
+				if descended == nil {
+					descended = map[string][]string{}
+				}
+				descended[normalizer] = deeperPaths
+			}
+		}
+		return descended
+	}
+
+	switch recorded := recordedValue.(type) {
+	case map[string]interface{}:
+		actual, ok := actualValue.(map[string]interface{})
+		if !ok {
+			return actualValue
+		}
+		normalized := make(map[string]interface{}, len(actual))
+		for key, valActual := range actual {
+			valRecorded, ok := recorded[key]
+			if !ok {
+				normalized[key] = valActual
+				continue
+			}
+			subPaths := descend(func(paths []string) []string {
+				return _descendIgnoreList(key, paths)
+			})
+			normalized[key] = normalizeActual(valRecorded, valActual, listKeyFor(key, listKeys), _descendListKeys(key, listKeys), subPaths, equal)
+		}
+		return normalized
+	case []interface{}:
+		actual, ok := actualValue.([]interface{})
+		if !ok {
+			return actualValue
+		}
+		var recordedByKey map[string]int
+		if mergeKey != "" {
+			recordedByKey = indexListByKey(recorded, mergeKey)
+		}
+		itemMergeKey := listKeyFor("[*]", listKeys)
+		itemListKeys := _descendListKeys("[*]", listKeys)
+		normalized := make([]interface{}, len(actual))
+		for j, valActual := range actual {
+			normalized[j] = valActual
+			i, ok := j, j < len(recorded)
+			if mergeKey != "" {
+				value, hasKey := itemKeyValue(valActual, mergeKey)
+				i, ok = recordedByKey[value]
+				ok = ok && hasKey
+			}
+			if !ok {
+				continue
+			}
+			itemPaths := descend(func(paths []string) []string {
+				return _descendIgnoreListToItem(i, []interface{}{recorded[i], valActual}, paths)
+			})
+			normalized[j] = normalizeActual(recorded[i], valActual, itemMergeKey, itemListKeys, itemPaths, equal)
+		}
+		return normalized
+	default:
+		var normalizers []string
+		for normalizer, paths := range pathsByNormalizer {
+			if containsEmptyPath(paths) {
+				normalizers = append(normalizers, normalizer)
+			}
+		}
+		if equal(recordedValue, actualValue, normalizers) {
+			return recordedValue
+		}
+		return actualValue
+	}
+}
+
+/*
+ * Keeps the recorded value of the fields in ignoredPatterns, which maps fields to a regular
+ * expression, when the actual value matches it. Values are matched as the strings that
+ * to_string() turns them into, such as "true" for true.
+ */
+func ignoreMatchingValues(recordedValue interface{}, actualValue interface{}, listKeys map[string]string, ignoredPatterns map[string]string) interface{} {
+	pathsByPattern := map[string][]string{}
+	for path, pattern := range ignoredPatterns {
+		pathsByPattern[pattern] = append(pathsByPattern[pattern], path)
+	}
+	return normalizeActual(recordedValue, actualValue, "", listKeys, pathsByPattern, func(recorded interface{}, actual interface{}, patterns []string) bool {
+		for _, pattern := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(toExpressionString(actual).(string)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+/* A class of values that equivalent_values takes as the same in the fields it applies to */
+type equivalentValues struct {
+	values []string
+	fields []string
+}
+
+/*
+ * Keeps the recorded value of a field when both it and the actual value are in the same class of
+ * equivalentValues, such as "enabled" and true. Values are compared as in ignoreMatchingValues.
+ */
+func normalizeEquivalentValues(recordedValue interface{}, actualValue interface{}, listKeys map[string]string, classes []equivalentValues) interface{} {
+	pathsByClass := map[string][]string{}
+	for i, class := range classes {
+		pathsByClass[strconv.Itoa(i)] = class.fields
+	}
+	return normalizeActual(recordedValue, actualValue, "", listKeys, pathsByClass, func(recorded interface{}, actual interface{}, names []string) bool {
+		for _, name := range names {
+			i, _ := strconv.Atoi(name)
+			values := classes[i].values
+			if contains(values, toExpressionString(recorded).(string)) && contains(values, toExpressionString(actual).(string)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+/* The normalizers of field_normalizers */
+var fieldNormalizerNames = []string{"timestamp", "lowercase", "trailing_slash"}
+
+/*
+ * Applies a normalizer to a scalar value. "timestamp" turns RFC 3339 timestamps and epoch seconds
+ * into UTC RFC 3339 timestamps, "lowercase" lowercases strings such as UUIDs and "trailing_slash"
+ * removes trailing slashes from strings such as URLs. Reports false if the value does not apply.
+ */
+func normalizeField(normalizer string, value interface{}) (string, bool) {
+	switch normalizer {
+	case "timestamp":
+		var t time.Time
+		switch v := value.(type) {
+		case float64:
+			t = time.Unix(0, int64(v*float64(time.Second)))
+		case string:
+			if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+				t = time.Unix(0, int64(seconds*float64(time.Second)))
+			} else if t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+		return t.UTC().Format(time.RFC3339Nano), true
+	case "lowercase":
+		if v, ok := value.(string); ok {
+			return strings.ToLower(v), true
+		}
+	case "trailing_slash":
+		if v, ok := value.(string); ok {
+			return strings.TrimRight(v, "/"), true
+		}
+	}
+	return "", false
+}
+
+/* Reports whether two scalar values are the same number or boolean, regardless of their types */
+func looselyEqual(a interface{}, b interface{}) bool {
+	if numberA, ok := looseNumber(a); ok {
+		numberB, ok := looseNumber(b)
+		return ok && numberA == numberB
+	}
+	return false
+}
+
+func looseNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, true
+		}
+		if strings.EqualFold(v, "true") || strings.EqualFold(v, "false") {
+			return looseNumber(strings.EqualFold(v, "true"))
+		}
+	}
+	return 0, false
+}
+
+/*
+ * Computes an RFC 7386 merge patch that turns originalResource into modifiedResource.
+ * Keys that were removed are set to null, nested objects are patched recursively and
+ * any other changed value (including lists) is replaced as a whole. Lists with a key in
+ * listKeys are only replaced if their paired up items changed, not if they were reordered.
+ */
+func getMergePatch(originalResource map[string]interface{}, modifiedResource map[string]interface{}, listKeys map[string]string) map[string]interface{} {
+	patch := map[string]interface{}{}
+
+	for key, valOriginal := range originalResource {
+		valModified, ok := modifiedResource[key]
+		if !ok {
+			patch[key] = nil
+			continue
+		}
+
+		subMapA, okA := valOriginal.(map[string]interface{})
+		subMapB, okB := valModified.(map[string]interface{})
+		listA, okListA := valOriginal.([]interface{})
+		listB, okListB := valModified.([]interface{})
+		if okA && okB {
+			if subPatch := getMergePatch(subMapA, subMapB, _descendListKeys(key, listKeys)); len(subPatch) > 0 {
+				patch[key] = subPatch
+			}
+		} else if mergeKey := listKeyFor(key, listKeys); okListA && okListB && mergeKey != "" {
+			if _, hasChange := getListDelta(listA, listB, nil, mergeKey, _descendListKeys(key, listKeys)); hasChange {
+				patch[key] = valModified
+			}
+		} else if !reflect.DeepEqual(valOriginal, valModified) {
+			patch[key] = valModified
+		}
+	}
+
+	for key, valModified := range modifiedResource {
+		if _, ok := originalResource[key]; !ok {
+			patch[key] = valModified
+		}
+	}
+
+	return patch
+}
+
+/*
+ * Computes the RFC 6902 JSON patch operations that turn originalResource into modifiedResource.
+ * Keys are visited in sorted order so the patch is stable. Nested objects are patched
+ * recursively and any other changed value (including lists) is replaced as a whole.
+ * Lists with a key in listKeys are patched item by item instead, see getKeyedListJSONPatch.
+ */
+func getJSONPatch(originalResource map[string]interface{}, modifiedResource map[string]interface{}, pathPrefix string, listKeys map[string]string) []map[string]interface{} {
+	operations := []map[string]interface{}{}
+
+	keys := make([]string, 0, len(originalResource)+len(modifiedResource))
+	for key := range originalResource {
+		keys = append(keys, key)
+	}
+	for key := range modifiedResource {
+		if _, ok := originalResource[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := pathPrefix + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+		valOriginal, inOriginal := originalResource[key]
+		valModified, inModified := modifiedResource[key]
+
+		if !inModified {
+			operations = append(operations, map[string]interface{}{"op": "remove", "path": path})
+			continue
+		}
+		if !inOriginal {
+			operations = append(operations, map[string]interface{}{"op": "add", "path": path, "value": valModified})
+			continue
+		}
+
+		subMapA, okA := valOriginal.(map[string]interface{})
+		subMapB, okB := valModified.(map[string]interface{})
+		listA, okListA := valOriginal.([]interface{})
+		listB, okListB := valModified.([]interface{})
+		if okA && okB {
+			operations = append(operations, getJSONPatch(subMapA, subMapB, path, _descendListKeys(key, listKeys))...)
+		} else if mergeKey := listKeyFor(key, listKeys); okListA && okListB && mergeKey != "" {
+			operations = append(operations, getKeyedListJSONPatch(listA, listB, path, mergeKey, _descendListKeys(key, listKeys))...)
+		} else if !reflect.DeepEqual(valOriginal, valModified) {
+			operations = append(operations, map[string]interface{}{"op": "replace", "path": path, "value": valModified})
+		}
+	}
+
+	return operations
+}
+
+/*
+ * Computes the JSON patch operations for a list whose items are paired up by mergeKey. Items
+ * that are gone are removed from the end of the list first so the indexes of the others stay
+ * valid, paired up items are patched at their index after the removals, and new items are
+ * appended. The order of the items is not changed.
+ */
+func getKeyedListJSONPatch(originalList []interface{}, modifiedList []interface{}, path string, mergeKey string, listKeys map[string]string) []map[string]interface{} {
+	operations := []map[string]interface{}{}
+	modifiedByKey := indexListByKey(modifiedList, mergeKey)
+	itemListKeys := _descendListKeys("[*]", listKeys)
+
+	pairs := make([]int, len(originalList))
+	paired := map[int]struct{}{}
+	for i := len(originalList) - 1; i >= 0; i-- {
+		pairs[i] = -1
+		if value, ok := itemKeyValue(originalList[i], mergeKey); ok {
+			if j, ok := modifiedByKey[value]; ok {
+				pairs[i] = j
+				paired[j] = struct{}{}
+				continue
+			}
+		}
+		operations = append(operations, map[string]interface{}{"op": "remove", "path": fmt.Sprintf("%s/%d", path, i)})
+	}
+
+	index := 0
+	for i, valOriginal := range originalList {
+		if pairs[i] < 0 {
+			continue
+		}
+		itemPath := fmt.Sprintf("%s/%d", path, index)
+		valModified := modifiedList[pairs[i]]
+		subMapA, okA := valOriginal.(map[string]interface{})
+		subMapB, okB := valModified.(map[string]interface{})
+		if okA && okB {
+			operations = append(operations, getJSONPatch(subMapA, subMapB, itemPath, itemListKeys)...)
+		} else if !reflect.DeepEqual(valOriginal, valModified) {
+			operations = append(operations, map[string]interface{}{"op": "replace", "path": itemPath, "value": valModified})
+		}
+		index++
+	}
+
+	for j, valModified := range modifiedList {
+		if _, ok := paired[j]; !ok {
+			operations = append(operations, map[string]interface{}{"op": "add", "path": path + "/-", "value": valModified})
+		}
+	}
+
+	return operations
+}
@@ -182,7 +182,6 @@ var deltaTestCases = []deltaTestCase{
 	},
 
 	// Basic List Changes
-	// Note: we don't support ignoring specific differences to lists - only ignoring the list as a whole
 	{
 		testCase:       "Server adds to list",
 		o1:             MapAny{"list": []string{"foo", "bar"}},
@@ -223,7 +222,6 @@ var deltaTestCases = []deltaTestCase{
 		resultHasDelta: false,
 	},
 
-	// We don't currently support ignoring a change like this, but we could in the future with a syntax like `list[].val` similar to jq
 	{
 		testCase:       "Server changes a sub-value in a list of objects",
 		o1:             MapAny{"list": []MapAny{{"key": "foo", "val": "x"}, {"key": "bar", "val": "x"}}},
@@ -231,6 +229,112 @@ var deltaTestCases = []deltaTestCase{
 		ignoreList:     []string{},
 		resultHasDelta: true,
 	},
+
+	// Wildcards
+	{
+		testCase:       "Server changes a sub-value in a list of objects (ignored)",
+		o1:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "bar", "val": "x"}}},
+		o2:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "Y"}, MapAny{"key": "bar", "val": "Z", "new": "a"}}},
+		ignoreList:     []string{"list[*].val", "list[*].new"},
+		resultHasDelta: false,
+	},
+
+	{
+		testCase:       "Server changes a sub-value in a list of objects (other sub-value ignored)",
+		o1:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "bar", "val": "x"}}},
+		o2:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "BAR", "val": "Z"}}},
+		ignoreList:     []string{"list[*].val"},
+		resultHasDelta: true,
+	},
+
+	{
+		testCase:       "Server adds to a list of objects (sub-value ignored)",
+		o1:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}}},
+		o2:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "bar", "val": "x"}}},
+		ignoreList:     []string{"list[*].val"},
+		resultHasDelta: true,
+	},
+
+	{
+		testCase:       "Server changes every field of an object (wildcard ignored)",
+		o1:             MapAny{"metadata": MapAny{"created": "a", "updated": "a"}, "name": "foo"},
+		o2:             MapAny{"metadata": MapAny{"created": "b", "revision": 2}, "name": "foo"},
+		ignoreList:     []string{"metadata.*"},
+		resultHasDelta: false,
+	},
+
+	{
+		testCase:       "Server changes a field next to an object (wildcard ignored)",
+		o1:             MapAny{"metadata": MapAny{"created": "a"}, "name": "foo"},
+		o2:             MapAny{"metadata": MapAny{"created": "b"}, "name": "bar"},
+		ignoreList:     []string{"metadata.*"},
+		resultHasDelta: true,
+	},
+
+	{
+		testCase:       "Server changes a field at any depth (deep match ignored)",
+		o1:             MapAny{"etag": "a", "spec": MapAny{"etag": "a", "rules": []interface{}{MapAny{"etag": "a", "port": 80}}}},
+		o2:             MapAny{"etag": "b", "spec": MapAny{"etag": "b", "rules": []interface{}{MapAny{"etag": "b", "port": 80}}}},
+		ignoreList:     []string{"**.etag"},
+		resultHasDelta: false,
+	},
+
+	{
+		testCase:       "Server changes a field at any depth (other field deep match ignored)",
+		o1:             MapAny{"spec": MapAny{"etag": "a", "rules": []interface{}{MapAny{"etag": "a", "port": 80}}}},
+		o2:             MapAny{"spec": MapAny{"etag": "b", "rules": []interface{}{MapAny{"etag": "b", "port": 443}}}},
+		ignoreList:     []string{"**.etag"},
+		resultHasDelta: true,
+	},
+
+	// Addressing list items
+	{
+		testCase:       "Server changes a sub-value of a list item (index ignored)",
+		o1:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "bar", "val": "x"}}},
+		o2:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "bar", "val": "Y"}}},
+		ignoreList:     []string{"list[1].val"},
+		resultHasDelta: false,
+	},
+
+	{
+		testCase:       "Server changes a sub-value of a list item (other index ignored)",
+		o1:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "x"}, MapAny{"key": "bar", "val": "x"}}},
+		o2:             MapAny{"list": []interface{}{MapAny{"key": "foo", "val": "Y"}, MapAny{"key": "bar", "val": "x"}}},
+		ignoreList:     []string{"list[1].val"},
+		resultHasDelta: true,
+	},
+
+	{
+		testCase:       "Server changes a sub-value of a list item (key match ignored)",
+		o1:             MapAny{"members": []interface{}{MapAny{"id": float64(7), "last_seen": "a"}, MapAny{"id": float64(42), "last_seen": "a"}}},
+		o2:             MapAny{"members": []interface{}{MapAny{"id": float64(7), "last_seen": "a"}, MapAny{"id": float64(42), "last_seen": "b"}}},
+		ignoreList:     []string{"members[id=42].last_seen"},
+		resultHasDelta: false,
+	},
+
+	{
+		testCase:       "Server changes a sub-value of a list item (other key match ignored)",
+		o1:             MapAny{"members": []interface{}{MapAny{"id": float64(7), "last_seen": "a"}, MapAny{"id": float64(42), "last_seen": "a"}}},
+		o2:             MapAny{"members": []interface{}{MapAny{"id": float64(7), "last_seen": "b"}, MapAny{"id": float64(42), "last_seen": "a"}}},
+		ignoreList:     []string{"members[id=42].last_seen"},
+		resultHasDelta: true,
+	},
+
+	{
+		testCase:       "Server changes a whole list item (key match with dots ignored)",
+		o1:             MapAny{"hosts": []interface{}{MapAny{"name": "a.example.com", "ip": "1"}, "b"}},
+		o2:             MapAny{"hosts": []interface{}{MapAny{"name": "a.example.com", "ip": "2", "up": true}, "b"}},
+		ignoreList:     []string{"hosts[name=a.example.com]"},
+		resultHasDelta: false,
+	},
+
+	{
+		testCase:       "Server changes everything below a field (trailing deep match ignored)",
+		o1:             MapAny{"status": MapAny{"conditions": []interface{}{MapAny{"type": "a"}}}},
+		o2:             MapAny{"status": MapAny{"conditions": []interface{}{}, "phase": "b"}},
+		ignoreList:     []string{"status.**"},
+		resultHasDelta: false,
+	},
 }
 
 /*
@@ -271,7 +375,7 @@ func generateTypeConversionTests() []deltaTestCase {
 func TestHasDelta(t *testing.T) {
 	// Run the main test cases
 	for _, testCase := range deltaTestCases {
-		_, result := getDelta(testCase.o1, testCase.o2, testCase.ignoreList, nil)
+		_, result := getDelta(testCase.o1, testCase.o2, testCase.ignoreList, nil, nil)
 		if result != testCase.resultHasDelta {
 			t.Errorf("delta_checker_test.go: Test Case [%s] wanted [%v] got [%v]", testCase.testCase, testCase.resultHasDelta, result)
 		}
@@ -279,7 +383,7 @@ func TestHasDelta(t *testing.T) {
 
 	// Test type changes
 	for _, testCase := range generateTypeConversionTests() {
-		_, result := getDelta(testCase.o1, testCase.o2, testCase.ignoreList, nil)
+		_, result := getDelta(testCase.o1, testCase.o2, testCase.ignoreList, nil, nil)
 		if result != testCase.resultHasDelta {
 			t.Errorf("delta_checker_test.go: TYPE CONVERSION Test Case [%d:%s] wanted [%v] got [%v]", testCase.testId, testCase.testCase, testCase.resultHasDelta, result)
 		}
@@ -320,8 +424,340 @@ func TestHasDeltaModifiedResource(t *testing.T) {
 
 	ignoreList := []string{"hairball", "hobbies.sleeping", "name"}
 
-	modified, _ := getDelta(recordedInput, actualInput, ignoreList, nil)
+	modified, _ := getDelta(recordedInput, actualInput, ignoreList, nil, nil)
 	if !reflect.DeepEqual(expectedOutput, modified) {
 		t.Errorf("delta_checker_test.go: Unexpected delta: expected %v but got %v", expectedOutput, modified)
 	}
 }
+
+func TestGetListDelta(t *testing.T) {
+	recorded := []interface{}{
+		MapAny{"id": "a", "port": float64(80), "last_seen": "1"},
+		MapAny{"id": "b", "port": float64(443), "last_seen": "1"},
+	}
+	actual := []interface{}{
+		MapAny{"id": "a", "port": float64(8080), "last_seen": "2"},
+		MapAny{"id": "b", "port": float64(443), "last_seen": "2"},
+	}
+	expected := []interface{}{
+		MapAny{"id": "a", "port": float64(8080), "last_seen": "1"},
+		MapAny{"id": "b", "port": float64(443), "last_seen": "1"},
+	}
+
+	modified, hasChanges := getListDelta(recorded, actual, []string{"[*].last_seen"}, "", nil)
+	if !hasChanges || !reflect.DeepEqual(expected, modified) {
+		t.Errorf("delta_checker_test.go: Unexpected delta: expected %v but got %v", expected, modified)
+	}
+
+	if _, hasChanges := getListDelta(recorded, actual, []string{"[*].last_seen", "[id=a].port"}, "", nil); hasChanges {
+		t.Errorf("delta_checker_test.go: Expected no delta when the changed port is ignored by key")
+	}
+}
+
+func TestGetMergePatch(t *testing.T) {
+	original := MapAny{
+		"name":    "foo",
+		"removed": "bar",
+		"tags":    []interface{}{"a", "b"},
+		"inner":   MapAny{"keep": 1, "change": 2, "drop": 3},
+		"same":    MapAny{"foo": "bar"},
+	}
+	modified := MapAny{
+		"name":  "foo",
+		"tags":  []interface{}{"a"},
+		"inner": MapAny{"keep": 1, "change": 4, "add": 5},
+		"same":  MapAny{"foo": "bar"},
+		"added": true,
+	}
+	expected := MapAny{
+		"removed": nil,
+		"tags":    []interface{}{"a"},
+		"inner":   MapAny{"change": 4, "drop": nil, "add": 5},
+		"added":   true,
+	}
+
+	patch := getMergePatch(original, modified, nil)
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("delta_checker_test.go: Unexpected merge patch:\n  got:      %v\n  expected: %v", patch, expected)
+	}
+
+	if patch := getMergePatch(original, original, nil); len(patch) != 0 {
+		t.Errorf("delta_checker_test.go: Expected an empty merge patch for identical objects but got %v", patch)
+	}
+}
+
+func TestGetJSONPatch(t *testing.T) {
+	original := MapAny{
+		"name":    "foo",
+		"removed": "bar",
+		"tags":    []interface{}{"a", "b"},
+		"inner":   MapAny{"keep": 1, "change": 2, "drop": 3},
+		"a/b":     "escaped",
+	}
+	modified := MapAny{
+		"name":  "foo",
+		"tags":  []interface{}{"a"},
+		"inner": MapAny{"keep": 1, "change": 4, "add": 5},
+		"a/b":   "changed",
+		"added": true,
+	}
+	expected := []map[string]interface{}{
+		{"op": "replace", "path": "/a~1b", "value": "changed"},
+		{"op": "add", "path": "/added", "value": true},
+		{"op": "add", "path": "/inner/add", "value": 5},
+		{"op": "replace", "path": "/inner/change", "value": 4},
+		{"op": "remove", "path": "/inner/drop"},
+		{"op": "remove", "path": "/removed"},
+		{"op": "replace", "path": "/tags", "value": []interface{}{"a"}},
+	}
+
+	patch := getJSONPatch(original, modified, "", nil)
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("delta_checker_test.go: Unexpected JSON patch:\n  got:      %v\n  expected: %v", patch, expected)
+	}
+
+	if patch := getJSONPatch(original, original, "", nil); len(patch) != 0 {
+		t.Errorf("delta_checker_test.go: Expected an empty JSON patch for identical objects but got %v", patch)
+	}
+}
+
+func TestKeyedLists(t *testing.T) {
+	listKeys := map[string]string{"rules": "name", "rules[*].ports": "port"}
+	original := MapAny{
+		"rules": []interface{}{
+			MapAny{"name": "a", "action": "allow"},
+			MapAny{"name": "b", "action": "deny", "ports": []interface{}{MapAny{"port": float64(80)}, MapAny{"port": float64(443)}}},
+			MapAny{"name": "c", "action": "allow"},
+		},
+	}
+	reordered := MapAny{
+		"rules": []interface{}{
+			MapAny{"name": "c", "action": "allow"},
+			MapAny{"name": "b", "action": "deny", "ports": []interface{}{MapAny{"port": float64(443)}, MapAny{"port": float64(80)}}},
+			MapAny{"name": "a", "action": "allow"},
+		},
+	}
+	modified := MapAny{
+		"rules": []interface{}{
+			MapAny{"name": "b", "action": "allow", "ports": []interface{}{MapAny{"port": float64(80)}, MapAny{"port": float64(443)}}},
+			MapAny{"name": "c", "action": "allow"},
+			MapAny{"name": "d", "action": "deny"},
+		},
+	}
+
+	if _, hasChanges := getDelta(original, reordered, nil, nil, listKeys); hasChanges {
+		t.Errorf("delta_checker_test.go: Expected no delta when keyed lists are reordered")
+	}
+	if _, hasChanges := getDelta(original, reordered, nil, nil, nil); !hasChanges {
+		t.Errorf("delta_checker_test.go: Expected a delta when lists without keys are reordered")
+	}
+	if _, hasChanges := getDelta(original, modified, nil, nil, listKeys); !hasChanges {
+		t.Errorf("delta_checker_test.go: Expected a delta when items of keyed lists change")
+	}
+
+	if patch := getMergePatch(original, reordered, listKeys); len(patch) != 0 {
+		t.Errorf("delta_checker_test.go: Expected an empty merge patch when keyed lists are reordered but got %v", patch)
+	}
+	if patch := getMergePatch(original, modified, listKeys); !reflect.DeepEqual(patch, map[string]interface{}{"rules": modified["rules"]}) {
+		t.Errorf("delta_checker_test.go: Expected the whole list in the merge patch but got %v", patch)
+	}
+
+	expected := []map[string]interface{}{
+		{"op": "remove", "path": "/rules/0"},
+		{"op": "replace", "path": "/rules/0/action", "value": "allow"},
+		{"op": "add", "path": "/rules/-", "value": MapAny{"name": "d", "action": "deny"}},
+	}
+	if patch := getJSONPatch(original, modified, "", listKeys); !reflect.DeepEqual(patch, expected) {
+		t.Errorf("delta_checker_test.go: Unexpected JSON patch:\n  got:      %v\n  expected: %v", patch, expected)
+	}
+	if patch := getJSONPatch(original, reordered, "", listKeys); len(patch) != 0 {
+		t.Errorf("delta_checker_test.go: Expected an empty JSON patch when keyed lists are reordered but got %v", patch)
+	}
+}
+
+func TestNormalizeTypes(t *testing.T) {
+	recorded := MapAny{
+		"count":   float64(5),
+		"enabled": true,
+		"ratio":   "1.0",
+		"name":    "foo",
+		"rules":   []interface{}{MapAny{"name": "a", "port": float64(80)}, MapAny{"name": "b", "port": float64(443)}},
+	}
+	actual := MapAny{
+		"count":   "5",
+		"enabled": float64(1),
+		"ratio":   float64(1),
+		"name":    "foo",
+		"rules":   []interface{}{MapAny{"name": "b", "port": "443"}, MapAny{"name": "a", "port": "80"}},
+	}
+
+	normalized := normalizeTypes(recorded, actual, map[string]string{"rules": "name"}).(map[string]interface{})
+	if _, hasChanges := getDelta(recorded, normalized, nil, nil, map[string]string{"rules": "name"}); hasChanges {
+		t.Errorf("delta_checker_test.go: Expected no delta after normalizing types but got %v", normalized)
+	}
+	if _, hasChanges := getDelta(recorded, actual, nil, nil, map[string]string{"rules": "name"}); !hasChanges {
+		t.Errorf("delta_checker_test.go: Expected a delta without normalizing types")
+	}
+
+	actual["count"] = "6"
+	actual["enabled"] = "false"
+	normalized = normalizeTypes(recorded, actual, nil).(map[string]interface{})
+	if normalized["count"] != "6" || normalized["enabled"] != "false" {
+		t.Errorf("delta_checker_test.go: Expected different values to be kept but got %v", normalized)
+	}
+}
+
+func TestNormalizeFields(t *testing.T) {
+	fieldNormalizers := map[string]string{
+		"created_at":   "timestamp",
+		"owner":        "lowercase",
+		"links[*].url": "trailing_slash",
+	}
+	recorded := MapAny{
+		"created_at": "2024-01-02T03:04:05Z",
+		"owner":      "6F9619FF-8B86-D011-B42D-00CF4FC964FF",
+		"links":      []interface{}{MapAny{"url": "https://example.com/a/"}},
+		"name":       "Foo",
+	}
+	actual := MapAny{
+		"created_at": float64(1704164645),
+		"owner":      "6f9619ff-8b86-d011-b42d-00cf4fc964ff",
+		"links":      []interface{}{MapAny{"url": "https://example.com/a"}},
+		"name":       "foo",
+	}
+
+	normalized := normalizeFields(recorded, actual, nil, fieldNormalizers).(map[string]interface{})
+	expected := MapAny{
+		"created_at": "2024-01-02T03:04:05Z",
+		"owner":      "6F9619FF-8B86-D011-B42D-00CF4FC964FF",
+		"links":      []interface{}{MapAny{"url": "https://example.com/a/"}},
+		"name":       "foo",
+	}
+	if !reflect.DeepEqual(expected, normalized) {
+		t.Errorf("delta_checker_test.go: Unexpected normalized fields: expected %v but got %v", expected, normalized)
+	}
+
+	actual["created_at"] = "2024-01-02T04:04:05+01:00"
+	normalized = normalizeFields(recorded, actual, nil, fieldNormalizers).(map[string]interface{})
+	if normalized["created_at"] != recorded["created_at"] {
+		t.Errorf("delta_checker_test.go: Expected timestamps in other zones to match but got %v", normalized["created_at"])
+	}
+
+	actual["created_at"] = float64(1704164646)
+	normalized = normalizeFields(recorded, actual, nil, fieldNormalizers).(map[string]interface{})
+	if normalized["created_at"] != float64(1704164646) {
+		t.Errorf("delta_checker_test.go: Expected a different timestamp to be kept but got %v", normalized["created_at"])
+	}
+}
+
+func TestSplitServerFields(t *testing.T) {
+	recorded := MapAny{"name": "foo", "spec": MapAny{"port": float64(80)}, "tags": []interface{}{"a"}}
+	actual := MapAny{
+		"id":   "1",
+		"name": "bar",
+		"spec": MapAny{"port": float64(80), "created": "today"},
+		"tags": []interface{}{"a", "b"},
+	}
+
+	configured, server := splitServerFields(recorded, actual)
+	expectedConfigured := MapAny{"name": "bar", "spec": MapAny{"port": float64(80)}, "tags": []interface{}{"a", "b"}}
+	expectedServer := MapAny{"id": "1", "spec": MapAny{"created": "today"}}
+	if !reflect.DeepEqual(expectedConfigured, configured) {
+		t.Errorf("delta_checker_test.go: Unexpected configured fields: expected %v but got %v", expectedConfigured, configured)
+	}
+	if !reflect.DeepEqual(expectedServer, server) {
+		t.Errorf("delta_checker_test.go: Unexpected server fields: expected %v but got %v", expectedServer, server)
+	}
+}
+
+func TestGetDriftChanges(t *testing.T) {
+	recorded := MapAny{
+		"name":  "foo",
+		"spec":  MapAny{"port": float64(80), "debug": true},
+		"rules": []interface{}{MapAny{"port": float64(22)}},
+		"tags":  []interface{}{"a"},
+	}
+	modified := MapAny{
+		"name":  "foo",
+		"spec":  MapAny{"port": float64(8080), "owner": "ops"},
+		"rules": []interface{}{MapAny{"port": float64(2222)}},
+		"tags":  []interface{}{"a", "b"},
+	}
+
+	changes := getDriftChanges(recorded, modified, "")
+	expected := []driftChange{
+		{"rules[0].port", "22", "2222"},
+		{"spec.debug", "true", ""},
+		{"spec.owner", "", `"ops"`},
+		{"spec.port", "80", "8080"},
+		{"tags", `["a"]`, `["a","b"]`},
+	}
+	if !reflect.DeepEqual(expected, changes) {
+		t.Errorf("delta_checker_test.go: Unexpected drift: expected %v but got %v", expected, changes)
+	}
+
+	if changes := getDriftChanges(recorded, recorded, ""); len(changes) != 0 {
+		t.Errorf("delta_checker_test.go: Expected no drift but got %v", changes)
+	}
+}
+
+func TestIgnoreMatchingValues(t *testing.T) {
+	recorded := MapAny{"message": "hello", "name": "foo", "rules": []interface{}{MapAny{"note": "a"}}}
+	actual := MapAny{"message": "Updated by admin", "name": "bar", "rules": []interface{}{MapAny{"note": "Updated by admin"}}}
+
+	normalized := ignoreMatchingValues(recorded, actual, nil, map[string]string{"message": "^Updated by ", "rules[*].note": "^Updated by ", "name": "^baz$"})
+	expected := MapAny{"message": "hello", "name": "bar", "rules": []interface{}{MapAny{"note": "a"}}}
+	if !reflect.DeepEqual(expected, normalized) {
+		t.Errorf("delta_checker_test.go: Unexpected result: expected %v but got %v", expected, normalized)
+	}
+}
+
+func TestNormalizeEquivalentValues(t *testing.T) {
+	recorded := MapAny{"state": "enabled", "spec": MapAny{"mode": "on", "debug": "yes"}, "other": "enabled"}
+	actual := MapAny{"state": true, "spec": MapAny{"mode": float64(1), "debug": false}, "other": true}
+
+	classes := []equivalentValues{
+		{values: []string{"enabled", "true"}, fields: []string{"state"}},
+		{values: []string{"on", "1", "true"}, fields: []string{"**"}},
+	}
+	normalized := normalizeEquivalentValues(recorded, actual, nil, classes)
+	expected := MapAny{"state": "enabled", "spec": MapAny{"mode": "on", "debug": false}, "other": true}
+	if !reflect.DeepEqual(expected, normalized) {
+		t.Errorf("delta_checker_test.go: Unexpected result: expected %v but got %v", expected, normalized)
+	}
+}
+
+func TestPathMatches(t *testing.T) {
+	value := MapAny{
+		"type":  "disk",
+		"spec":  MapAny{"zone": "a", "size": float64(1)},
+		"rules": []interface{}{MapAny{"id": "web", "port": float64(80)}},
+	}
+	patterns := []string{"type", "spec.zone", "rules[id=web].port"}
+
+	for _, path := range []string{"type", "spec.zone", "rules[0].port"} {
+		if !pathMatches(path, value, patterns) {
+			t.Errorf("delta_checker_test.go: Expected '%s' to match %v", path, patterns)
+		}
+	}
+	for _, path := range []string{"spec.size", "spec", "rules[0].id", "rules"} {
+		if pathMatches(path, value, patterns) {
+			t.Errorf("delta_checker_test.go: Expected '%s' not to match %v", path, patterns)
+		}
+	}
+	if !pathMatches("spec.size", value, []string{"spec"}) || !pathMatches("rules[0].port", value, []string{"**.port"}) {
+		t.Errorf("delta_checker_test.go: Expected fields below a pattern to match it")
+	}
+}
+
+func TestNullFields(t *testing.T) {
+	recorded := MapAny{"name": "foo", "description": nil}
+	for _, actual := range []MapAny{{"name": "foo"}, {"name": "foo", "description": nil}} {
+		if _, hasChanges := getDelta(recorded, actual, nil, nil, nil); hasChanges {
+			t.Errorf("delta_checker_test.go: Expected a null field to match %v", actual)
+		}
+	}
+	if _, hasChanges := getDelta(recorded, MapAny{"name": "foo", "description": "set"}, nil, nil, nil); !hasChanges {
+		t.Errorf("delta_checker_test.go: Expected a null field to differ from a value")
+	}
+}
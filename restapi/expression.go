@@ -0,0 +1,80 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+/*
+evalExpression evaluates a JMESPath expression (https://jmespath.org)
+
+	against data decoded from JSON and returns the result as a string,
+	such as result.items[0].uuid, items[?name=='web'].id | [0] or
+	join('/', [tenant, name]). The result must be a string or a number.
+*/
+func evalExpression(data interface{}, expression string) (string, error) {
+	res, err := evalExpressionValue(data, expression)
+	if err != nil {
+		return "", err
+	}
+	return expressionString(res, expression)
+}
+
+/*
+Like evalExpression, but returns the result as is, such as an object.
+
+	A JMESPath expression gives null when it finds nothing, such as for
+	a missing field, which is reported as an error. go-jmespath panics
+	on some arguments of the wrong type, such as merge(@, 'name'), which
+	is reported as an error too.
+*/
+func evalExpressionValue(data interface{}, expression string) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, fmt.Errorf("unable to evaluate the expression '%s': %v", expression, r)
+		}
+	}()
+	res, err = jmespath.Search(expression, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to evaluate the expression '%s': %v", expression, err)
+	}
+	if res == nil {
+		return nil, fmt.Errorf("the expression '%s' found nothing", expression)
+	}
+	return res, nil
+}
+
+/* Checks the syntax of an expression when planning, before any request is sent */
+func validateExpression(val interface{}, key string) ([]string, []error) {
+	if _, err := jmespath.Compile(val.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s is not a valid JMESPath expression: %v", key, err)}
+	}
+	return nil, nil
+}
+
+func toExpressionString(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+/* Like GetStringAtKey, allow a string OR number as the result */
+func expressionString(res interface{}, expression string) (string, error) {
+	switch v := res.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("result of '%s' is not a JSON string or number - the go fmt package says it is '%T'", expression, res)
+	}
+}
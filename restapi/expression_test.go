@@ -0,0 +1,127 @@
+package restapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEvalExpression(t *testing.T) {
+	var data interface{}
+	err := json.Unmarshal([]byte(`
+    {
+      "tenant": "acme",
+      "name": "web",
+      "result": {
+        "items": [
+          { "uuid": "1111", "port": 80 },
+          { "uuid": "2222", "port": 443 }
+        ]
+      }
+    }`), &data)
+	if err != nil {
+		t.Fatalf("Error unmarshalling JSON: %s", err)
+	}
+
+	valid := map[string]string{
+		"tenant":                    "acme",
+		"result.items[0].uuid":      "1111",
+		"result.items[-1].port":     "443",
+		"'literal'":                 "literal",
+		"join('/', [tenant, name])": "acme/web",
+		"join('-', [ 'svc', result.items[1].uuid ])":         "svc-2222",
+		"result.items[?port==`443`].uuid | [0]":              "2222",
+		"join(':', [name, to_string(result.items[0].port)])": "web:80",
+		"length(result.items)":                               "2",
+	}
+	for expression, expected := range valid {
+		res, err := evalExpression(data, expression)
+		if err != nil {
+			t.Fatalf("Error evaluating '%s': %s", expression, err)
+		}
+		if res != expected {
+			t.Fatalf("Error: Expected '%s' to give '%s', but got '%s'", expression, expected, res)
+		}
+	}
+
+	objects := map[string]string{
+		"@":                                  `{"name":"web","port":"8080","tenant":"acme"}`,
+		"{id: name, owner: tenant}":          `{"id":"web","owner":"acme"}`,
+		"merge(@, {port: to_number(port)})":  `{"name":"web","port":8080,"tenant":"acme"}`,
+		"{port: to_string(to_number(port))}": `{"port":"8080"}`,
+		"{missing: to_number(name)}":         `{"missing":null}`,
+	}
+	var projected interface{}
+	json.Unmarshal([]byte(`{ "errors": [ { "detail": "a" }, { "code": 1 }, { "detail": "b", "source": { "field": "name" } } ] }`), &projected)
+	projections := map[string]string{
+		"errors[].detail":          `["a","b"]`,
+		"errors[].source.field":    `["name"]`,
+		"errors[].missing":         `[]`,
+		"errors[1:].code":          `[1]`,
+		"errors[?detail].detail":   `["a","b"]`,
+		"errors[0].[detail, code]": `["a",null]`,
+		"errors[].source":          `[{"field":"name"}]`,
+		"errors[?code > `5`]":      `[]`,
+		"errors[].missing.field":   `[]`,
+		"nothing":                  "",
+	}
+	for expression, expected := range projections {
+		res, err := evalExpressionValue(projected, expression)
+		if expected == "" {
+			if err == nil {
+				t.Fatalf("Error: Expected '%s' to fail", expression)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Error evaluating '%s': %s", expression, err)
+		}
+		if b, _ := json.Marshal(res); string(b) != expected {
+			t.Fatalf("Error: Expected '%s' to give '%s', but got '%s'", expression, expected, string(b))
+		}
+	}
+
+	var flat interface{}
+	json.Unmarshal([]byte(`{ "tenant": "acme", "name": "web", "port": "8080" }`), &flat)
+	for expression, expected := range objects {
+		res, err := evalExpressionValue(flat, expression)
+		if err != nil {
+			t.Fatalf("Error evaluating '%s': %s", expression, err)
+		}
+		if b, _ := json.Marshal(res); string(b) != expected {
+			t.Fatalf("Error: Expected '%s' to give '%s', but got '%s'", expression, expected, string(b))
+		}
+	}
+
+	invalid := []string{
+		"missing",
+		"result.items[2].uuid",
+		"result.items",
+		"result.items[x]",
+		"tenant name",
+		"join('/', [tenant, name]",
+		"'unterminated",
+		"{id name}",
+		"merge(@, tenant)",
+		"to_number(tenant, name)",
+		"join('/', [tenant, result.items[0].port])",
+		"result.items[?port==`443`",
+	}
+	for _, expression := range invalid {
+		if res, err := evalExpression(data, expression); err == nil {
+			t.Fatalf("Error: Expected '%s' to fail, but got '%s'", expression, res)
+		}
+	}
+}
+
+func TestValidateExpression(t *testing.T) {
+	for _, expression := range []string{"tenant", "result.items[0].uuid", "items[?name=='web'].id | [0]", "\"foo-bar\".id"} {
+		if _, errs := validateExpression(expression, "id_expression"); len(errs) != 0 {
+			t.Fatalf("Error: Expected '%s' to be valid, but got %v", expression, errs)
+		}
+	}
+	for _, expression := range []string{"", "tenant name", "join('/', [tenant, name]", "{id name}", "items[?"} {
+		if _, errs := validateExpression(expression, "id_expression"); len(errs) != 1 {
+			t.Fatalf("Error: Expected '%s' to be invalid", expression)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package restapi
 
 import (
 	"context"
+	"net/http"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/Mastercard/terraform-provider-restapi/fakeserver"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestAccRestApiObject_importBasic(t *testing.T) {
@@ -54,10 +57,161 @@ func TestAccRestApiObject_importBasic(t *testing.T) {
 				ImportStateIdPrefix: "/api/objects/",
 				ImportStateVerify:   true,
 				/* create_response isn't populated during import (we don't know the API response from creation) */
-				ImportStateVerifyIgnore: []string{"debug", "data", "ignore_all_server_changes"},
+				ImportStateVerifyIgnore: []string{"debug", "data", "data_fields", "ignore_all_server_changes", "create_response"},
 			},
 		},
 	})
 
 	svr.Shutdown()
 }
+
+func TestImportBySearch(t *testing.T) {
+	ctx := context.Background()
+	apiServerObjects := map[string]map[string]interface{}{
+		"1234": {"id": "1234", "first": "Foo", "last": "Bar"},
+		"5678": {"id": "5678", "first": "Baz", "last": "Qux"},
+	}
+	svr := newTestServer(t, fakeserver.NewFakeServer(0, apiServerObjects, false, false, "").GetServer().Handler)
+
+	client, err := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	importID := func(id string) ([]*schema.ResourceData, error) {
+		d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{})
+		d.SetId(id)
+		return resourceRestAPIImport(ctx, d, client)
+	}
+
+	imported, err := importID("/api/object_list?search_key=first&search_value=Baz&results_key=list")
+	if err != nil {
+		t.Fatalf("import_api_object_test.go: Failed to import by search: %v", err)
+	}
+	if d := imported[0]; d.Id() != "5678" || d.Get("path") != "/api/object_list" || d.Get("api_data.last") != "Qux" {
+		t.Fatalf("import_api_object_test.go: Unexpected import by search: '%s' '%s' %v", d.Id(), d.Get("path"), d.Get("api_data"))
+	}
+
+	if _, err := importID("/api/object_list?search_key=first&search_value=Nope&results_key=list"); err == nil {
+		t.Fatalf("import_api_object_test.go: Expected an import by a search that finds nothing to fail")
+	}
+	if _, err := importID("/api/objects?search_key=first"); err == nil {
+		t.Fatalf("import_api_object_test.go: Expected an import by a search without search_value to fail")
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	ctx := context.Background()
+	apiServerObjects := map[string]map[string]interface{}{
+		"1234": {"id": "1234", "first": "Foo", "last": "Bar"},
+	}
+	svr := newTestServer(t, fakeserver.NewFakeServer(0, apiServerObjects, false, false, "").GetServer().Handler)
+
+	client, err := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "id",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	importID := func(id string) (*schema.ResourceData, error) {
+		d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{})
+		d.SetId(id)
+		imported, err := resourceRestAPIImport(ctx, d, client)
+		if err != nil {
+			return nil, err
+		}
+		return imported[0], nil
+	}
+
+	/* The object is read from read_path rather than path/{id} */
+	d, err := importID(`{ "path": "/api/tenants/a/objects", "id": "1234", "read_path": "/api/objects/{id}", "query_string": "tenant=a" }`)
+	if err != nil {
+		t.Fatalf("import_api_object_test.go: Failed to import by JSON: %v", err)
+	}
+	if d.Id() != "1234" || d.Get("path") != "/api/tenants/a/objects" || d.Get("read_path") != "/api/objects/{id}" || d.Get("query_string") != "tenant=a" || d.Get("api_data.first") != "Foo" {
+		t.Fatalf("import_api_object_test.go: Unexpected import by JSON: '%s' '%s' '%s' %v", d.Id(), d.Get("path"), d.Get("read_path"), d.Get("api_data"))
+	}
+
+	/* The data is what was read, not just the id */
+	if data := d.Get("data"); data != `{"first":"Foo","id":"1234","last":"Bar"}` || d.Get("data_fields.last") != `"Bar"` || d.Get("server_data") != "{}" {
+		t.Fatalf("import_api_object_test.go: Expected the imported data to be the object but got %v (server_data %v)", data, d.Get("server_data"))
+	}
+
+	d, err = importID(`{ "path": "/api/object_list", "search_key": "last", "search_value": "Bar", "results_key": "list", "read_path": "/api/objects/{id}" }`)
+	if err != nil || d.Id() != "1234" {
+		t.Fatalf("import_api_object_test.go: Failed to import by a search in JSON: %v", err)
+	}
+
+	for _, input := range []string{
+		`{ "path": "/api/objects" }`,
+		`{ "path": "/api/objects", "id": "1234", "search_key": "last" }`,
+		`{ "path": "/api/objects", "id": "1234", "create_path": "/api/new" }`,
+		`{ "path": "/api/objects", "id": 1234 }`,
+	} {
+		if _, err := importID(input); err == nil {
+			t.Fatalf("import_api_object_test.go: Expected the import of '%s' to fail", input)
+		}
+	}
+}
+
+func TestImportIDAttribute(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/things/abc", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "metadata": { "uuid": "abc", "etag": "1" }, "name": "foo", "updated_at": "2024-01-01" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "metadata/uuid",
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{})
+	if data := importData(d, client, "abc"); data != `{"metadata":{"uuid":"abc"}}` {
+		t.Fatalf("import_api_object_test.go: Expected the id at metadata/uuid but got %s", data)
+	}
+	d.Set("id_attribute", "name")
+	if data := importData(d, client, "foo"); data != `{"name":"foo"}` {
+		t.Fatalf("import_api_object_test.go: Expected the id_attribute of the resource to take precedence but got %s", data)
+	}
+	d.Set("id_attribute", "metadata.uuid")
+	if data := importData(d, client, "abc"); data != `{"metadata":{"uuid":"abc"}}` {
+		t.Fatalf("import_api_object_test.go: Expected the id at the dotted metadata.uuid but got %s", data)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{})
+	d.SetId("/api/things/abc")
+	imported, err := resourceRestAPIImport(context.Background(), d, client)
+	if err != nil {
+		t.Fatalf("import_api_object_test.go: Failed to import: %v", err)
+	}
+	if imported[0].Id() != "abc" || imported[0].Get("data") != `{"metadata":{"etag":"1","uuid":"abc"},"name":"foo","updated_at":"2024-01-01"}` {
+		t.Fatalf("import_api_object_test.go: Unexpected import: '%s' %v", imported[0].Id(), imported[0].Get("data"))
+	}
+
+	/* Generated configuration comes from the state, so it should be usable as is */
+	if ignored := imported[0].Get("ignore_changes_to"); !reflect.DeepEqual(ignored, []interface{}{"**.etag", "updated_at"}) || imported[0].Get("debug") != false {
+		t.Fatalf("import_api_object_test.go: Unexpected ignore_changes_to %v or debug %v of an import", ignored, imported[0].Get("debug"))
+	}
+
+	client.idAttribute = "metadata.uuid"
+	d = schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{})
+	d.SetId("/api/things/abc")
+	if imported, err = resourceRestAPIImport(context.Background(), d, client); err != nil || imported[0].Id() != "abc" {
+		t.Fatalf("import_api_object_test.go: Failed to import with the dotted id_attribute metadata.uuid: %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/* The keywords that validateJSONSchema checks */
+var jsonSchemaKeywords = []string{
+	"type", "enum", "const", "required", "properties", "additionalProperties", "items",
+	"minItems", "maxItems", "minLength", "maxLength", "pattern", "minimum", "maximum",
+}
+
+/* Keywords that only describe a schema, so ignoring them changes nothing */
+var jsonSchemaAnnotations = []string{
+	"$schema", "$id", "$comment", "title", "description", "default", "examples",
+	"readOnly", "writeOnly", "deprecated",
+}
+
+/*
+parseJSONSchema parses a JSON Schema given as a string. A schema with
+
+	keywords that validateJSONSchema does not check, such as $ref, allOf
+	or format, is rejected rather than half enforced.
+*/
+func parseJSONSchema(in string) (map[string]interface{}, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(in), &schema); err != nil {
+		return nil, fmt.Errorf("the schema is not a JSON object: %v", err)
+	}
+	if err := checkJSONSchema(schema, ""); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+/* Finds mistakes in a schema up front, so they are not reported as violations */
+func checkJSONSchema(schema map[string]interface{}, path string) error {
+	keywords := GetKeys(schema)
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		if !containsString(jsonSchemaKeywords, keyword) && !containsString(jsonSchemaAnnotations, keyword) {
+			return fmt.Errorf("unsupported keyword '%s' at '%s'. Only %s are supported", keyword, jsonSchemaPath(path), strings.Join(jsonSchemaKeywords, ", "))
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern at '%s', which must be a Go (RE2) regular expression: %v", jsonSchemaPath(path), err)
+		}
+	}
+	if _, ok := schema["items"].([]interface{}); ok {
+		return fmt.Errorf("unsupported list of items at '%s'. items must be a single schema for every item", jsonSchemaPath(path))
+	}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, property := range properties {
+			if sub, ok := property.(map[string]interface{}); ok {
+				if err := checkJSONSchema(sub, path+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		if sub, ok := schema[keyword].(map[string]interface{}); ok {
+			if err := checkJSONSchema(sub, path+"/*"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+validateJSONSchema validates a value decoded from JSON against a
+
+	schema and returns a description of every violation found. The
+	supported keywords are type, enum, const, required, properties,
+	additionalProperties, items, minItems, maxItems, minLength, maxLength,
+	pattern, minimum and maximum. Patterns are Go regular expressions
+	(RE2) rather than ECMA-262 ones, so lookarounds and backreferences
+	are not available.
+*/
+func validateJSONSchema(schema map[string]interface{}, value interface{}) []string {
+	var violations []string
+	validateJSONSchemaAt(schema, value, "", &violations)
+	return violations
+}
+
+func validateJSONSchemaAt(schema map[string]interface{}, value interface{}, path string, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, fmt.Sprintf("%s: %s", jsonSchemaPath(path), fmt.Sprintf(format, args...)))
+	}
+
+	if types, ok := schema["type"]; ok {
+		allowed := []string{}
+		switch t := types.(type) {
+		case string:
+			allowed = append(allowed, t)
+		case []interface{}:
+			for _, v := range t {
+				allowed = append(allowed, fmt.Sprintf("%v", v))
+			}
+		}
+		if actual := jsonSchemaType(value); !jsonSchemaTypeAllowed(actual, allowed) {
+			fail("expected %s but got %s", strings.Join(allowed, " or "), actual)
+			/* The other keywords make no sense for the wrong type */
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, v := range enum {
+			if jsonEqual(v, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%s is not one of %s", jsonString(value), jsonString(enum))
+		}
+	}
+	if constant, ok := schema["const"]; ok && !jsonEqual(constant, value) {
+		fail("%s is not %s", jsonString(value), jsonString(constant))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := v[fmt.Sprintf("%v", name)]; !ok {
+					fail("missing required field '%v'", name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := GetKeys(v)
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := properties[key].(map[string]interface{}); ok {
+				validateJSONSchemaAt(sub, v[key], path+"/"+key, violations)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					fail("unexpected field '%s'", key)
+				}
+			case map[string]interface{}:
+				validateJSONSchemaAt(additional, v[key], path+"/"+key, violations)
+			}
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(v)) < min {
+			fail("expected at least %v items but got %d", min, len(v))
+		}
+		if max, ok := schema["maxItems"].(float64); ok && float64(len(v)) > max {
+			fail("expected at most %v items but got %d", max, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateJSONSchemaAt(items, item, fmt.Sprintf("%s/%d", path, i), violations)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := schema["minLength"].(float64); ok && length < min {
+			fail("expected at least %v characters but got %v", min, length)
+		}
+		if max, ok := schema["maxLength"].(float64); ok && length > max {
+			fail("expected at most %v characters but got %v", max, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("'%s' does not match the pattern '%s'", v, pattern)
+			}
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			fail("%v is less than the minimum of %v", v, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			fail("%v is more than the maximum of %v", v, max)
+		}
+	}
+}
+
+func jsonSchemaPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func jsonSchemaType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+/* An integer is also a number */
+func jsonSchemaTypeAllowed(actual string, allowed []string) bool {
+	for _, t := range allowed {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonString(value interface{}) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return jsonString(a) == jsonString(b)
+}
@@ -0,0 +1,71 @@
+package restapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema, err := parseJSONSchema(`
+    {
+      "type": "object",
+      "required": ["name", "port"],
+      "additionalProperties": false,
+      "properties": {
+        "name": { "type": "string", "minLength": 2, "pattern": "^[a-z]+$" },
+        "port": { "type": "integer", "minimum": 1, "maximum": 65535 },
+        "mode": { "enum": ["active", "passive"] },
+        "tags": { "type": "array", "maxItems": 2, "items": { "type": "string" } }
+      }
+    }`)
+	if err != nil {
+		t.Fatalf("Error parsing the schema: %s", err)
+	}
+
+	tests := map[string]int{
+		`{ "name": "web", "port": 80 }`:                                       0,
+		`{ "name": "web", "port": 80, "mode": "active", "tags": ["a", "b"] }`: 0,
+		`{ "name": "web" }`:                                                   1,
+		`{ "name": "Web", "port": 80.5 }`:                                     2,
+		`{ "name": "w", "port": 70000, "mode": "off" }`:                       3,
+		`{ "name": "web", "port": 80, "tags": ["a", 1, "c"], "extra": true }`: 3,
+		`[]`: 1,
+	}
+	for data, expected := range tests {
+		var value interface{}
+		if err := json.Unmarshal([]byte(data), &value); err != nil {
+			t.Fatalf("Error unmarshalling '%s': %s", data, err)
+		}
+		if violations := validateJSONSchema(schema, value); len(violations) != expected {
+			t.Fatalf("Error: Expected %d violations for '%s', but got %d: %v", expected, data, len(violations), violations)
+		}
+	}
+
+	if _, err := parseJSONSchema(`{ "$schema": "http://json-schema.org/draft-07/schema#", "title": "service", "properties": { "name": { "description": "The name", "default": "web" } } }`); err != nil {
+		t.Fatalf("Error: Expected annotations to be allowed, but got %s", err)
+	}
+
+	invalid := []string{
+		`[]`,
+		`{ "type": `,
+		`{ "properties": { "name": { "pattern": "(" } } }`,
+		`{ "properties": { "name": { "pattern": "^(?!admin)" } } }`,
+		`{ "$ref": "#/definitions/service" }`,
+		`{ "properties": { "name": { "anyOf": [ { "type": "string" } ] } } }`,
+		`{ "items": { "oneOf": [] } }`,
+		`{ "additionalProperties": { "format": "email" } }`,
+		`{ "items": [ { "type": "string" } ] }`,
+		`{ "allOf": [] }`,
+	}
+	for _, invalid := range invalid {
+		if _, err := parseJSONSchema(invalid); err == nil {
+			t.Fatalf("Error: Expected the schema '%s' to be invalid", invalid)
+		}
+	}
+
+	_, err = parseJSONSchema(`{ "properties": { "email": { "type": "string", "format": "email" } } }`)
+	if err == nil || !strings.Contains(err.Error(), "unsupported keyword 'format' at '/email'") {
+		t.Fatalf("Error: Expected format to be rejected by name, but got %v", err)
+	}
+}
@@ -2,11 +2,16 @@ package restapi
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"math"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 /*Provider implements the REST API provider*/
@@ -19,6 +24,24 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("REST_API_URI", nil),
 				Description: "URI of the REST API endpoint. This serves as the base of all requests.",
 			},
+			"failover_uris": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "A list of additional base URIs of replicas of the REST API. If a request to `uri` fails because the server cannot be reached (or it answers with one of `failover_status_codes`), the request is retried against each of these in order.",
+			},
+			"failover_status_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "A list of HTTP status codes that cause a request to be retried against the next URI in `failover_uris`, such as `502` or `503`. Connection errors always cause a failover.",
+			},
+			"trusted_uris": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "A list of base URIs of other servers, such as `https://status.example.com`, that the `headers`, `username` and `password` and the OAuth token are sent to when the API returns absolute URLs on them, such as status URLs of asynchronous operations or `Location` headers. By default, they are only sent to `uri` and `failover_uris`, and requests to other servers are sent without credentials.",
+			},
 			"insecure": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -43,6 +66,12 @@ func Provider() *schema.Provider {
 				Optional:    true,
 				Description: "A map of header names and values to set on all outbound requests. This is useful if you want to use a script via the 'external' provider or provide a pre-approved token or change Content-Type from `application/json`. If `username` and `password` are set and Authorization is one of the headers defined here, the BASIC auth credentials take precedence.",
 			},
+			"default_query_params": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "A map of query parameters and values to add to all outbound requests, such as an `api-version` or a tenant, so they do not have to be repeated in the `query_string` of every resource. Parameters set in the path or `query_string` of a request take precedence. Absolute URLs returned by the API, such as pagination links, are sent unchanged.",
+			},
 			"use_cookies": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -59,31 +88,35 @@ func Provider() *schema.Provider {
 				Type:        schema.TypeString,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("REST_API_ID_ATTRIBUTE", nil),
-				Description: "When set, this key will be used to operate on REST objects. For example, if the ID is set to 'name', changes to the API object will be to http://foo.com/bar/VALUE_OF_NAME. This value may also be a '/'-delimeted path to the id attribute if it is multple levels deep in the data (such as `attributes/id` in the case of an object `{ \"attributes\": { \"id\": 1234 }, \"config\": { \"name\": \"foo\", \"something\": \"bar\"}}`",
+				Description: "When set, this key will be used to operate on REST objects. For example, if the ID is set to 'name', changes to the API object will be to http://foo.com/bar/VALUE_OF_NAME. This value may also be a '/'-delimeted path to the id attribute if it is multple levels deep in the data (such as `attributes/id` in the case of an object `{ \"attributes\": { \"id\": 1234 }, \"config\": { \"name\": \"foo\", \"something\": \"bar\"}}`). The keys of the path may also be separated with dots (such as `attributes.id`) unless a key with that literal name exists.",
 			},
 			"create_method": {
-				Type:        schema.TypeString,
-				DefaultFunc: schema.EnvDefaultFunc("REST_API_CREATE_METHOD", nil),
-				Description: "Defaults to `POST`. The HTTP method used to CREATE objects of this type on the API server.",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				DefaultFunc:  schema.EnvDefaultFunc("REST_API_CREATE_METHOD", nil),
+				Description:  "Defaults to `POST`. The HTTP method used to CREATE objects of this type on the API server.",
+				Optional:     true,
 			},
 			"read_method": {
-				Type:        schema.TypeString,
-				DefaultFunc: schema.EnvDefaultFunc("REST_API_READ_METHOD", nil),
-				Description: "Defaults to `GET`. The HTTP method used to READ objects of this type on the API server.",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				DefaultFunc:  schema.EnvDefaultFunc("REST_API_READ_METHOD", nil),
+				Description:  "Defaults to `GET`. The HTTP method used to READ objects of this type on the API server.",
+				Optional:     true,
 			},
 			"update_method": {
-				Type:        schema.TypeString,
-				DefaultFunc: schema.EnvDefaultFunc("REST_API_UPDATE_METHOD", nil),
-				Description: "Defaults to `PUT`. The HTTP method used to UPDATE objects of this type on the API server.",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				DefaultFunc:  schema.EnvDefaultFunc("REST_API_UPDATE_METHOD", nil),
+				Description:  "Defaults to `PUT`. The HTTP method used to UPDATE objects of this type on the API server.",
+				Optional:     true,
 			},
 			"destroy_method": {
-				Type:        schema.TypeString,
-				DefaultFunc: schema.EnvDefaultFunc("REST_API_DESTROY_METHOD", nil),
-				Description: "Defaults to `DELETE`. The HTTP method used to DELETE objects of this type on the API server.",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				DefaultFunc:  schema.EnvDefaultFunc("REST_API_DESTROY_METHOD", nil),
+				Description:  "Defaults to `DELETE`. The HTTP method used to DELETE objects of this type on the API server.",
+				Optional:     true,
 			},
 			"copy_keys": {
 				Type: schema.TypeList,
@@ -105,6 +138,12 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("REST_API_CRO", nil),
 				Description: "Set this when the API returns the object created only on creation operations (POST). This is used by the provider to refresh internal data structures.",
 			},
+			"cache_data_source_reads": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether identical GET and HEAD requests of the data sources, to the same path with the same headers, are only sent once per plan or apply, such as when many instances of a module look up the same collection. Failed requests are not cached. Resources always read the API server. Default: true",
+			},
 			"xssi_prefix": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -121,7 +160,20 @@ func Provider() *schema.Provider {
 				Type:        schema.TypeString,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("REST_API_TEST_PATH", nil),
-				Description: "If set, the provider will issue a read_method request to this path after instantiation requiring a 200 OK response before proceeding. This is useful if your API provides a no-op endpoint that can signal if this provider is configured correctly. Response data will be ignored.",
+				Description: "If set, the provider will issue a read_method request to this path after instantiation requiring a 2xx response (or one of `test_expected_status`) before proceeding. This is useful if your API provides a no-op endpoint that can signal if this provider is configured correctly. Response data will be ignored.",
+			},
+			"test_expected_status": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes of the response to `test_path` that mean the provider is configured correctly, such as `[200, 204]`. Defaults to any 2xx status code.",
+			},
+			"test_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("REST_API_TEST_RETRIES", 2),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The number of times to retry the request to `test_path` if the API server cannot be reached or answers with a 5xx status code, waiting a second longer each time. Default: 2",
 			},
 			"debug": {
 				Type:        schema.TypeBool,
@@ -198,10 +250,20 @@ func Provider() *schema.Provider {
 			/* Could only get terraform to recognize this resource if
 			         the name began with the provider's name and had at least
 				 one underscore. This is not documented anywhere I could find */
-			"restapi_object": resourceRestAPI(),
+			"restapi_object":       resourceRestAPI(),
+			"restapi_graphql":      resourceRestAPIGraphQL(),
+			"restapi_object_patch": resourceRestAPIObjectPatch(),
+			"restapi_bulk_object":  resourceRestAPIBulkObject(),
+			"restapi_token":        resourceRestAPIToken(),
+			"restapi_download":     resourceRestAPIDownload(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"restapi_object": dataSourceRestAPI(),
+			"restapi_download":         dataSourceRestAPIDownload(),
+			"restapi_exists":           dataSourceRestAPIExists(),
+			"restapi_object":           dataSourceRestAPI(),
+			"restapi_objects":          dataSourceRestAPIObjects(),
+			"restapi_request":          dataSourceRestAPIRequest(),
+			"restapi_response_headers": dataSourceRestAPIResponseHeaders(),
 		},
 		ConfigureContextFunc: configureProvider,
 	}
@@ -224,12 +286,21 @@ func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}
 		}
 	}
 
+	queryParams := make(map[string]string)
+	for k, v := range d.Get("default_query_params").(map[string]interface{}) {
+		queryParams[k] = v.(string)
+	}
+
 	opt := &apiClientOpt{
 		uri:                 d.Get("uri").(string),
+		failoverURIs:        expandStringList(d.Get("failover_uris").([]interface{})),
+		failoverStatusCodes: expandIntList(d.Get("failover_status_codes").([]interface{})),
+		trustedURIs:         expandStringList(d.Get("trusted_uris").([]interface{})),
 		insecure:            d.Get("insecure").(bool),
 		username:            d.Get("username").(string),
 		password:            d.Get("password").(string),
 		headers:             headers,
+		queryParams:         queryParams,
 		useCookies:          d.Get("use_cookies").(bool),
 		timeout:             d.Get("timeout").(int),
 		idAttribute:         d.Get("id_attribute").(string),
@@ -237,6 +308,7 @@ func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}
 		writeReturnsObject:  d.Get("write_returns_object").(bool),
 		createReturnsObject: d.Get("create_returns_object").(bool),
 		xssiPrefix:          d.Get("xssi_prefix").(string),
+		cacheReads:          d.Get("cache_data_source_reads").(bool),
 		rateLimit:           d.Get("rate_limit").(float64),
 		debug:               d.Get("debug").(bool),
 	}
@@ -287,12 +359,55 @@ func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}
 
 	client, err := NewAPIClient(opt)
 
-	if v, ok := d.GetOk("test_path"); ok {
-		testPath := v.(string)
-		_, err := client.sendRequest(ctx, client.readMethod, testPath, "")
-		if err != nil {
-			return client, diag.Errorf("a test request to %v after setting up the provider did not return an OK response - is your configuration correct? %v", testPath, err)
+	if v, ok := d.GetOk("test_path"); ok && err == nil {
+		if diags := testProviderConfig(ctx, client, v.(string), expandIntList(d.Get("test_expected_status").([]interface{})), d.Get("test_retries").(int)); diags.HasError() {
+			return client, diags
 		}
 	}
 	return client, diag.FromErr(err)
 }
+
+/*
+Sends the request to test_path, retrying it while the API server cannot
+
+	be reached or answers with a 5xx status code. Fails with a diagnostic
+	that tells what is wrong with the configuration, rather than leaving
+	each resource to fail later with its own error.
+*/
+func testProviderConfig(ctx context.Context, client *APIClient, testPath string, expected []int, retries int) diag.Diagnostics {
+	var resp *apiClientResponse
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = client.sendRequestWithResponse(ctx, client.readMethod, testPath, "", nil)
+		if len(expected) > 0 {
+			if containsInt(expected, resp.statusCode) {
+				return nil
+			}
+			if err == nil {
+				err = fmt.Errorf("unexpected response code '%d': %s", resp.statusCode, resp.body)
+			}
+		} else if err == nil {
+			return nil
+		}
+
+		if !isTransientResponse(resp) || attempt >= retries {
+			break
+		}
+		wait := time.Duration(attempt+1) * time.Second
+		log.Printf("provider.go: The test request to '%s' failed (%v). Retrying in %s.", testPath, err, wait)
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+
+	summary := fmt.Sprintf("a test request to %v after setting up the provider did not return an OK response - is your configuration correct?", testPath)
+	switch resp.statusCode {
+	case 0:
+		summary = fmt.Sprintf("unable to reach the API server at %s to test the provider configuration - is uri correct?", client.uri)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		summary = fmt.Sprintf("the API server rejected the credentials of the provider with status %d at %v - are the username, password, headers or oauth_client_credentials correct?", resp.statusCode, testPath)
+	}
+	return diag.Diagnostics{{Severity: diag.Error, Summary: summary, Detail: err.Error()}}
+}
@@ -2,10 +2,14 @@ package restapi
 
 import (
 	"context"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/Mastercard/terraform-provider-restapi/fakeserver"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -104,3 +108,51 @@ func TestResourceProvider_RequireTestPath(t *testing.T) {
 
 	svr.Shutdown()
 }
+
+func TestResourceProvider_TestPathRetries(t *testing.T) {
+	var mutex sync.Mutex
+	unavailable := 1
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if unavailable > 0 {
+			unavailable--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	serverMux.HandleFunc("/secret", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	svr := newTestServer(t, serverMux)
+
+	configure := func(raw map[string]interface{}) diag.Diagnostics {
+		raw["uri"] = svr.URL
+		return Provider().Configure(context.TODO(), terraform.NewResourceConfigRaw(raw))
+	}
+
+	/* The server is unavailable on the first request, which is retried */
+	if diags := configure(map[string]interface{}{"test_path": "/health"}); diags.HasError() {
+		t.Fatalf("Provider was expected to retry the test request but it failed: %v", diags)
+	}
+
+	unavailable = 1
+	if diags := configure(map[string]interface{}{"test_path": "/health", "test_retries": 0}); !diags.HasError() {
+		t.Fatalf("Provider was expected to fail without retrying the test request but it did not!")
+	}
+
+	if diags := configure(map[string]interface{}{"test_path": "/health", "test_expected_status": []interface{}{200}}); !diags.HasError() {
+		t.Fatalf("Provider was expected to fail on a status other than test_expected_status but it did not!")
+	}
+
+	diags := configure(map[string]interface{}{"test_path": "/secret"})
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "rejected the credentials") {
+		t.Fatalf("Provider was expected to fail because of the credentials but got: %v", diags)
+	}
+	if diags := configure(map[string]interface{}{"test_path": "/secret", "test_expected_status": []interface{}{401}}); diags.HasError() {
+		t.Fatalf("Provider was expected to accept a status in test_expected_status but it failed: %v", diags)
+	}
+}
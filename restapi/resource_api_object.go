@@ -3,14 +3,21 @@ package restapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"reflect"
+	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceRestAPI() *schema.Resource {
@@ -19,16 +26,16 @@ func resourceRestAPI() *schema.Resource {
 
 	return &schema.Resource{
 		CreateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
-			return diag.FromErr(resourceRestAPICreate(ctx, data, i))
+			return diagFromErr(resourceRestAPICreate(ctx, data, i))
 		},
 		ReadContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
-			return diag.FromErr(resourceRestAPIRead(ctx, data, i))
+			return diagFromErr(resourceRestAPIRead(ctx, data, i))
 		},
 		UpdateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
-			return diag.FromErr(resourceRestAPIUpdate(ctx, data, i))
+			return diagFromErr(resourceRestAPIUpdate(ctx, data, i))
 		},
 		DeleteContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
-			return diag.FromErr(resourceRestAPIDelete(ctx, data, i))
+			return diagFromErr(resourceRestAPIDelete(ctx, data, i))
 		},
 
 		Description: "Acting as a wrapper of cURL, this object supports POST, GET, PUT and DELETE on the specified url",
@@ -37,77 +44,608 @@ func resourceRestAPI() *schema.Resource {
 			StateContext: resourceRestAPIImport,
 		},
 
+		CustomizeDiff: resourceRestAPICustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"path": {
 				Type:        schema.TypeString,
-				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server.",
+				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server. The string `{data.<field>}` will be replaced with the value of `<field>` in `data`, such as `/tenants/{data.tenant_id}/rules`. Changing only this or the other paths, such as when an API moves from `/v1` to `/v2`, reads the object at its new path instead of updating or replacing it.",
 				Required:    true,
 			},
 			"create_path": {
 				Type:        schema.TypeString,
-				Description: "Defaults to `path`. The API path that represents where to CREATE (POST) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object if the data contains the `id_attribute`.",
+				Description: "Defaults to `path`. The API path that represents where to CREATE (POST) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object if the data contains the `id_attribute`. The string `{data.<field>}` will be replaced with the value of `<field>` in `data`, such as `/tenants/{data.tenant_id}/rules`.",
 				Optional:    true,
 			},
 			"read_path": {
 				Type:        schema.TypeString,
-				Description: "Defaults to `path/{id}`. The API path that represents where to READ (GET) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object.",
+				Description: "Defaults to `path/{id}`. The API path that represents where to READ (GET) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object. The string `{data.<field>}` will be replaced with the value of `<field>` in `data`, such as `/tenants/{data.tenant_id}/rules`.",
 				Optional:    true,
 			},
 			"update_path": {
 				Type:        schema.TypeString,
-				Description: "Defaults to `path/{id}`. The API path that represents where to UPDATE (PUT) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object.",
+				Description: "Defaults to `path/{id}`. The API path that represents where to UPDATE (PUT) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object. The string `{data.<field>}` will be replaced with the value of `<field>` in `data`, such as `/tenants/{data.tenant_id}/rules`.",
 				Optional:    true,
 			},
 			"create_method": {
-				Type:        schema.TypeString,
-				Description: "Defaults to `create_method` set on the provider. Allows per-resource override of `create_method` (see `create_method` provider config documentation)",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				Description:  "Defaults to `create_method` set on the provider. Allows per-resource override of `create_method` (see `create_method` provider config documentation)",
+				Optional:     true,
 			},
 			"read_method": {
-				Type:        schema.TypeString,
-				Description: "Defaults to `read_method` set on the provider. Allows per-resource override of `read_method` (see `read_method` provider config documentation)",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				Description:  "Defaults to `read_method` set on the provider. Allows per-resource override of `read_method` (see `read_method` provider config documentation)",
+				Optional:     true,
 			},
 			"update_method": {
-				Type:        schema.TypeString,
-				Description: "Defaults to `update_method` set on the provider. Allows per-resource override of `update_method` (see `update_method` provider config documentation)",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				Description:  "Defaults to `update_method` set on the provider. Allows per-resource override of `update_method` (see `update_method` provider config documentation)",
+				Optional:     true,
 			},
 			"destroy_method": {
-				Type:        schema.TypeString,
-				Description: "Defaults to `destroy_method` set on the provider. Allows per-resource override of `destroy_method` (see `destroy_method` provider config documentation)",
-				Optional:    true,
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				Description:  "Defaults to `destroy_method` set on the provider. Allows per-resource override of `destroy_method` (see `destroy_method` provider config documentation)",
+				Optional:     true,
 			},
 			"destroy_path": {
 				Type:        schema.TypeString,
-				Description: "Defaults to `path/{id}`. The API path that represents where to DESTROY (DELETE) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object.",
+				Description: "Defaults to `path/{id}`. The API path that represents where to DESTROY (DELETE) objects of this type on the API server. The string `{id}` will be replaced with the terraform ID of the object. The string `{data.<field>}` will be replaced with the value of `<field>` in `data`, such as `/tenants/{data.tenant_id}/rules`.",
+				Optional:    true,
+			},
+			"skip_destroy": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to remove the object from the Terraform state without sending a destroy request, leaving it on the API server. This is useful for shared objects that the API forbids deleting or that are owned by others after handoff. Default: false",
 				Optional:    true,
+				Default:     false,
+			},
+			"destroy_precondition": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "A request to send before destroying the object, such as a request for the number of objects that depend on it. The object is only destroyed if `field` or `expression` in the response holds one of `values`, which guards objects that must not be deleted, such as production databases, even if `prevent_destroy` is not set or the resource is removed from the configuration. In `path`, the string `{id}` is replaced with the id of the object.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"method": {
+							Type:         schema.TypeString,
+							ValidateFunc: validateHTTPMethod,
+							Optional:     true,
+							Default:      "GET",
+							Description:  "The HTTP method of the request.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The API path of the request.",
+						},
+						"field": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ExactlyOneOf: []string{"destroy_precondition.0.field", "destroy_precondition.0.expression"},
+							Description:  "The field of the response to check. To use a nested field, separate the keys with a slash: 'meta/dependents'",
+						},
+						"expression": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateExpression,
+							Description:  "A JMESPath expression that finds the value of the response to check, such as `checks[0].state` or `join('/', [state, owner])`. Supports the same syntax as `id_expression`.",
+						},
+						"values": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Required:    true,
+							Description: "The values of `field` or `expression` that allow the object to be destroyed.",
+						},
+						"message": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The error to report if the object cannot be destroyed, such as 'remove the children of this object first'.",
+						},
+					},
+				},
+			},
+			"destroy_success_field": {
+				Type:         schema.TypeString,
+				Description:  "The field of the response to destroy requests that tells whether the destroy succeeded, for soft deletes such as a PATCH of `{\"status\": \"archived\"}` with `destroy_method` and `destroy_data`. To use a nested field, separate the keys with a slash: 'metadata/status'",
+				Optional:     true,
+				RequiredWith: []string{"destroy_success_values"},
+			},
+			"destroy_success_values": {
+				Type:         schema.TypeList,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Optional:     true,
+				Description:  "The values of `destroy_success_field` that mean the destroy succeeded.",
+				RequiredWith: []string{"destroy_success_field"},
 			},
 			"id_attribute": {
 				Type:        schema.TypeString,
 				Description: "Defaults to `id_attribute` set on the provider. Allows per-resource override of `id_attribute` (see `id_attribute` provider config documentation)",
 				Optional:    true,
 			},
+			"id_expression": {
+				Type:          schema.TypeString,
+				ValidateFunc:  validateExpression,
+				Description:   "A JMESPath expression that finds the id of the object, for ids that `id_attribute` cannot express. Any expression of https://jmespath.org is supported, such as `result.items[0].uuid`, `items[?name=='web'].id | [0]` or `join('/', [tenant, name])`. Keys with characters other than letters, digits and underscores must be quoted, such as `\"foo-bar\"`, and `join` only takes strings, so convert numbers with `to_string`. The syntax is checked when planning.",
+				Optional:      true,
+				ConflictsWith: []string{"id_attribute"},
+			},
+			"id_template": {
+				Type:          schema.TypeString,
+				Description:   "A template that composes the id of the object from fields of `data`, for APIs without a single id field, such as `{data.tenant}/{data.name}`. To use a nested field, separate the keys with dots or slashes, as in `id_attribute`: `{data.metadata.name}`. The composed id replaces `{id}` in paths like any other id.",
+				Optional:      true,
+				ConflictsWith: []string{"id_attribute", "id_expression"},
+			},
+			"id_header": {
+				Type:        schema.TypeString,
+				Description: "The response header holding the id of the object in the response to create requests, such as `X-Resource-Id`, for APIs that do not return the id in the body. If the body is empty, the object is read from the API after it is created.",
+				Optional:    true,
+			},
+			"id_header_regex": {
+				Type:         schema.TypeString,
+				Description:  "A regular expression whose first capture group extracts the id of the object from `id_header`. By default, the whole value of the header is the id.",
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				RequiredWith: []string{"id_header"},
+			},
 			"object_id": {
 				Type:        schema.TypeString,
 				Description: "Defaults to the id learned by the provider during normal operations and `id_attribute`. Allows you to set the id manually. This is used in conjunction with the `*_path` attributes.",
 				Optional:    true,
 			},
 			"data": {
+				Type:             schema.TypeString,
+				Description:      "Valid JSON object or array that this provider will manage with the API server. May also be YAML if `data_format` is `yaml`. Changes that only reorder keys or change whitespace or the formatting of numbers are not changes. Changes to an array are detected as a whole, so `ignore_changes_to` and `drift_fields` do not apply to it. For action-style endpoints, `data` may also be a scalar JSON value or be left out to send no body at all; changes made outside of Terraform are not detected for such objects, which are only removed from the state when reading them returns one of `not_found_codes`.",
+				Optional:         true,
+				ConflictsWith:    []string{"data_file", "ndjson_data"},
+				Sensitive:        isDataSensitive,
+				ValidateFunc:     validateDataObject("data"),
+				DiffSuppressFunc: suppressDataChange,
+			},
+			"store_data_hash_only": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to keep a salted SHA256 hash of `data` in the state instead of `data` itself, for secrets that must not be stored in the state even when marked sensitive. Changes are detected by comparing the configuration with the hash. Since the configured data is not known when reading, changes made outside of Terraform are not detected, `data_fields` is empty and the patches of `update_strategy` send all of `data`. Responses kept in attributes such as `api_response` are not hashed. The plan still has `data` while it changes. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"data_file": {
+				Type:          schema.TypeString,
+				Description:   "Path to a local file whose raw content is sent as the body of create and update requests instead of `data`. This allows non-JSON payloads such as archives or PEM bundles. Since there is no JSON data to compare, remote changes are not detected for such objects.",
+				Optional:      true,
+				ConflictsWith: []string{"ndjson_data"},
+			},
+			"data_file_content_type": {
 				Type:        schema.TypeString,
-				Description: "Valid JSON object that this provider will manage with the API server.",
-				Required:    true,
+				Description: "Defaults to `application/octet-stream`. The Content-Type sent along with the content of `data_file`.",
+				Optional:    true,
+			},
+			"data_fields": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The fields of `data` by their path, such as `rules[0].port`, with their JSON values. Changes to `data` are shown field by field in plans as changes to this attribute, which is easier to review than the change to the whole of `data`.",
+				Computed:    true,
 				Sensitive:   isDataSensitive,
-				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
-					v := val.(string)
-					if v != "" {
-						data := make(map[string]interface{})
-						err := json.Unmarshal([]byte(v), &data)
-						if err != nil {
-							errs = append(errs, fmt.Errorf("data attribute is invalid JSON: %v", err))
-						}
-					}
-					return warns, errs
+			},
+			"data_file_sha256": {
+				Type:        schema.TypeString,
+				Description: "The SHA256 checksum of the content of `data_file` last sent to the API server. Used to detect changes to the file.",
+				Computed:    true,
+			},
+			"data_format": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `json`. The format of `data`, `update_data` and `destroy_data`. Set this to `yaml` to provide them as YAML documents, which are converted to JSON before being used.",
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"json", "yaml"}, false),
+			},
+			"body_format": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `json`. The encoding used when sending `data`, `update_data` and `destroy_data` to the API server. Set this to `form` to send the keys of the JSON object as `application/x-www-form-urlencoded` parameters instead, to `multipart` to send them as `multipart/form-data` fields along with any `multipart_file` parts, or to `ndjson` to send `ndjson_data` to a bulk endpoint.",
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"json", "form", "multipart", "ndjson"}, false),
+			},
+			"update_strategy": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `replace`, which sends the full `data` (or `update_data`) on update. Set this to `merge_patch` to send only the keys of `data` that changed since the last apply as an RFC 7386 JSON merge patch with the `application/merge-patch+json` Content-Type, or to `json_patch` to send the changes as RFC 6902 JSON patch operations with the `application/json-patch+json` Content-Type. `update_data` is not used in those cases and `update_method` defaults to `PATCH`.",
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"replace", "merge_patch", "json_patch"}, false),
+			},
+			"create_strategy": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `post`, which creates objects with `create_method` at `create_path`. Set this to `upsert` for key-value style APIs that create objects with a PUT to the path of the object, using the id from `data` or `object_id`. In that case, `create_method` defaults to `PUT` and `create_path` defaults to `update_path`.",
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"post", "upsert"}, false),
+			},
+			"create_returns_object": {
+				Type:        schema.TypeBool,
+				Description: "Defaults to `create_returns_object` set on the provider. Allows per-resource override of `create_returns_object` (see `create_returns_object` provider config documentation)",
+				Optional:    true,
+			},
+			"write_returns_object": {
+				Type:        schema.TypeBool,
+				Description: "Defaults to `write_returns_object` set on the provider. Allows per-resource override of `write_returns_object` (see `write_returns_object` provider config documentation)",
+				Optional:    true,
+			},
+			"copy_keys": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "Defaults to `copy_keys` set on the provider. Allows per-resource override of `copy_keys` (see `copy_keys` provider config documentation). Set it to `[]` to copy no keys for this resource.",
+			},
+			"post_create_request": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A request to send after the object is created, such as a request to activate it. May be repeated, and the requests are sent in order. In `path` and `data`, the string `{id}` is replaced with the id of the object and `{response.<key>}` with the value of `<key>` in the response to the create request or to an earlier `post_create_request`. Nested keys are separated by dots or slashes, such as `{response.lock.token}`. To use a nested key, separate the keys with a slash: `{response.links/activate}`",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"method": {
+							Type:         schema.TypeString,
+							ValidateFunc: validateHTTPMethod,
+							Required:     true,
+							Description:  "The HTTP method of the request.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The API path of the request.",
+						},
+						"data": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   isDataSensitive,
+							Description: "The body of the request. By default, no body is sent.",
+						},
+					},
+				},
+			},
+			"hook": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A request to send before or after the requests of an operation on the object, such as acquiring a lock before an update and releasing it after. May be repeated, and the hooks are sent in order. The hooks after an operation are sent even if it failed. In `path` and `data`, the string `{id}` is replaced with the id of the object and `{response.<key>}` with the value of `<key>` in the response to an earlier hook of the same operation, such as a lock token. Nested keys are separated by dots or slashes, such as `{response.lock.token}`. To use a nested key, separate the keys with a slash: `{response.lock/token}`",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"operation": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "The operation to hook into. One of `create`, `read`, `update` or `destroy`.",
+							ValidateFunc: validation.StringInSlice([]string{"create", "read", "update", "destroy"}, false),
+						},
+						"when": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "Whether to send the request `before` or `after` the operation.",
+							ValidateFunc: validation.StringInSlice([]string{"before", "after"}, false),
+						},
+						"method": {
+							Type:         schema.TypeString,
+							ValidateFunc: validateHTTPMethod,
+							Required:     true,
+							Description:  "The HTTP method of the request.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The API path of the request.",
+						},
+						"data": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   isDataSensitive,
+							Description: "The body of the request. By default, no body is sent.",
+						},
+						"success_field": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The field of the response that tells whether the hook succeeded. By default, any 2xx response is a success. To use a nested field, separate the keys with a slash: 'lock/state'",
+						},
+						"success_values": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Optional:    true,
+							Description: "The values of `success_field` that mean the hook succeeded.",
+						},
+					},
+				},
+			},
+			"read_after_write": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to always read the object from the API after create and update requests, even if the response to the request is used to learn the id of the object. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"create_read_timeout": {
+				Type:        schema.TypeInt,
+				Description: "The number of seconds to keep retrying the read of a newly created object while the API responds that it is not found, for eventually consistent APIs that only list objects a while after they are created. Reads are retried with backoff. Default: 0",
+				Optional:    true,
+				Default:     0,
+			},
+			"read_failure_behavior": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `fail`. What to do when refreshing the object fails with a 5xx status code or without a response, such as on a timeout. `fail` fails the refresh, `retry` retries the read up to `read_retries` times with backoff before failing and `keep_state` keeps the last known state of the object with a warning. Other failures always fail the refresh, and objects are only removed from the state when reading them returns one of `not_found_codes`.",
+				Optional:     true,
+				Default:      "fail",
+				ValidateFunc: validation.StringInSlice([]string{"fail", "retry", "keep_state"}, false),
+			},
+			"read_retries": {
+				Type:        schema.TypeInt,
+				Description: "The number of times to retry a read that failed with a 5xx status code or without a response when `read_failure_behavior` is `retry`. Default: 3",
+				Optional:    true,
+				Default:     3,
+			},
+			"wait_for": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Wait after create and update for a field of the object to hold a value, such as a status that says the object is ready, by reading the object until it does.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ExactlyOneOf: []string{"wait_for.0.field", "wait_for.0.expression"},
+							Description:  "The field of the object to check. To use a nested field, separate the keys with a slash: 'status/phase'",
+						},
+						"expression": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateExpression,
+							Description:  "A JMESPath expression that finds the value of the object to check, for conditions that `field` cannot express, such as `status.conditions[?type=='Ready'].status | [0]`. Supports the same syntax as `id_expression`.",
+						},
+						"value": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ExactlyOneOf: []string{"wait_for.0.value", "wait_for.0.value_regex"},
+							Description:  "The value of `field` or `expression` to wait for.",
+						},
+						"value_regex": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringIsValidRegExp,
+							Description:  "A regular expression that matches the values of `field` or `expression` to wait for.",
+						},
+						"interval": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     5,
+							Description: "The number of seconds to wait between reads.",
+						},
+						"timeout": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     600,
+							Description: "The number of seconds to wait for `field` or `expression` to hold the value.",
+						},
+					},
+				},
+			},
+			"error_expression": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Treat responses as failures by a value in their body, for APIs that report errors with a successful status code such as `200` with `{\"status\": \"error\", \"message\": \"...\"}`. Responses that are not JSON or that lack the field are not checked.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"expression": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateExpression,
+							Description:  "A JMESPath expression that finds the value to check in the response, such as `status`. Supports the same syntax as `id_expression`.",
+						},
+						"error_values": {
+							Type:         schema.TypeList,
+							Elem:         &schema.Schema{Type: schema.TypeString},
+							Optional:     true,
+							ExactlyOneOf: []string{"error_expression.0.error_values", "error_expression.0.success_values"},
+							Description:  "The values of `expression` that mean the request failed.",
+						},
+						"success_values": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Optional:    true,
+							Description: "The values of `expression` that mean the request succeeded. Any other value means it failed.",
+						},
+						"message_expression": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateExpression,
+							Description:  "A JMESPath expression that finds the error message in the response, such as `message`, to show instead of the whole response.",
+						},
+					},
+				},
+			},
+			"error_detail_expression": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateExpression,
+				Description:  "A JMESPath expression that finds the details of the error in the response to a failed request, such as `error.message` or `errors[].detail`. The details are reported as the summary of the error, followed by the response cut to its first 512 bytes. Supports the same syntax as `id_expression`.",
+			},
+			"error_message": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A message to report instead of the response when a request fails with a status code, such as 'quota exceeded' for a 403. May be repeated.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"status_code": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "The HTTP status code of the failed request, such as `403`.",
+						},
+						"message": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The message to report.",
+						},
+						"hint": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "A hint on how to fix the problem to report with the message, such as 'request an increase of the quota from the platform team'.",
+						},
+					},
+				},
+			},
+			"wait_for_deletion": {
+				Type:        schema.TypeInt,
+				Description: "The number of seconds to wait after a destroy for the API to respond that the object is not found, for APIs that delete objects in the background. Reads are retried with backoff. Default: 0",
+				Optional:    true,
+				Default:     0,
+			},
+			"create_if_none_match": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to send an `If-None-Match: *` header on create requests, so that APIs that would otherwise silently overwrite an existing object respond with a 412 instead. The create then fails with an error saying the object already exists. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"create_conflict_behavior": {
+				Type:         schema.TypeString,
+				Description:  "Set this to look for the object before creating it, by its id if it is known from `data` or `object_id` or else by `read_search`. If the object already exists, `fail` fails the create, `adopt` takes the existing object into the state without sending the create request and `update` updates the existing object instead. By default, no check is made.",
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"fail", "adopt", "update"}, false),
+			},
+			"create_success_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes that are accepted as a successful response to create requests, such as `[200, 201, 202]`. Defaults to any 2xx status code.",
+			},
+			"read_success_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes that are accepted as a successful response to read requests, such as `[200]`. Defaults to any 2xx status code.",
+			},
+			"update_success_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes that are accepted as a successful response to update requests, such as `[200, 202, 204]`. Defaults to any 2xx status code.",
+			},
+			"replace_on_update_status_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes of responses to update requests that mean the object cannot be updated, such as `[409, 422]` for changes to immutable fields. The apply still fails, but the object is replaced on the next apply instead of being updated again.",
+			},
+			"update_rejected": {
+				Type:        schema.TypeBool,
+				Description: "Whether the last update was rejected with one of `replace_on_update_status_codes`, in which case the object is replaced on the next apply.",
+				Computed:    true,
+			},
+			"destroy_success_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes that are accepted as a successful response to destroy requests, such as `[200, 202, 204, 404, 410]`. Defaults to any 2xx status code.",
+			},
+			"not_found_codes": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "The HTTP status codes that mean the object is gone. Reading the object with one of these removes it from the state, and destroying it is considered done. Set this for APIs that answer with `410` or `403` for missing objects. Defaults to `[404]`.",
+			},
+			"create_poll": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Wait for create requests that the API accepts with a 201 or 202 to finish by polling the status of the operation.",
+				Elem:        pollSchema("create_poll"),
+			},
+			"update_poll": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Wait for update requests that the API accepts with a 201 or 202 to finish by polling the status of the operation.",
+				Elem:        pollSchema("update_poll"),
+			},
+			"destroy_poll": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Wait for destroy requests that the API accepts with a 201 or 202 to finish by polling the status of the operation.",
+				Elem:        pollSchema("destroy_poll"),
+			},
+			"follow_location": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to learn the id of the object from the `Location` header when the API responds to create requests with a 201 or 202, and to read the object from that URL unless `read_path` is set. The body of the response is not needed, so this works with APIs that respond with an empty body. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"location_id_regex": {
+				Type:         schema.TypeString,
+				Description:  "A regular expression whose first capture group extracts the id of the object from the `Location` header when `follow_location` is set. Defaults to the last segment of the path.",
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"location": {
+				Type:        schema.TypeString,
+				Description: "The URL of the object from the `Location` header of the create response when `follow_location` is set.",
+				Computed:    true,
+			},
+			"use_if_match": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to capture the version of the object when it is read and send it as an `If-Match` header on update and destroy requests. If the object changed remotely in the meantime, the API server is expected to respond with a 412 and the request fails instead of overwriting the changes. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"version_header": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `ETag`. The response header holding the version of the object when `use_if_match` is set.",
+				Optional:    true,
+			},
+			"version_field": {
+				Type:        schema.TypeString,
+				Description: "The field of the object holding its version when `use_if_match` is set, for APIs that do not return it in a header. Takes precedence over `version_header`. To use a nested field, use the dot syntax: 'metadata.resourceVersion'",
+				Optional:    true,
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Description: "The version of the object as last seen when `use_if_match` is set.",
+				Computed:    true,
+			},
+			"ndjson_data": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Sensitive:   isDataSensitive,
+				Description: "Valid JSON documents sent one per line as the body of create and update requests when `body_format` is `ndjson`, such as the action and source lines of an Elasticsearch `_bulk` request. The response is checked per item and any item with an error or a status of 300 or above fails the request. Since a bulk response does not describe the object, `object_id` must be set and remote changes are not detected.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsJSON,
+				},
+			},
+			"multipart_file": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A file part to upload on create and update when `body_format` is `multipart`. May be repeated.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The form field name of the file part.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Path to a local file to upload. Exactly one of `path` or `content` must be set.",
+						},
+						"content": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   isDataSensitive,
+							Description: "The content of the file to upload. Exactly one of `path` or `content` must be set.",
+						},
+						"filename": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The filename reported to the server. Defaults to the base name of `path`, or `name` if `content` is used.",
+						},
+						"content_type": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "application/octet-stream",
+							Description: "The Content-Type of the file part.",
+						},
+					},
 				},
 			},
 			"debug": {
@@ -117,7 +655,7 @@ func resourceRestAPI() *schema.Resource {
 			},
 			"read_search": {
 				Type:        schema.TypeMap,
-				Description: "Custom search for `read_path`. This map will take `search_key`, `search_value`, `results_key` and `query_string` (see datasource config documentation)",
+				Description: "Custom search for `read_path`. This map will take `search_key`, `search_value`, `results_key` and `query_string` (see datasource config documentation), and `match` to compare `search_value` other than by equality: `regex`, `prefix` or `suffix` for names with generated parts, `lt`, `le`, `gt` or `ge` for numbers and `bool` for booleans. Defaults to `exact`.",
 				Optional:    true,
 			},
 			"query_string": {
@@ -140,60 +678,300 @@ func resourceRestAPI() *schema.Resource {
 				Description: "Query string to be included in the path",
 				Optional:    true,
 			},
+			"destroy_query_string": {
+				Type:          schema.TypeString,
+				Description:   "Query string to be included in the path when destroying the resource.",
+				Optional:      true,
+				ConflictsWith: []string{"delete_query_string"},
+			},
 			"delete_query_string": {
 				Type:        schema.TypeString,
 				Description: "Query string to be included in the path",
 				Optional:    true,
+				Deprecated:  "use destroy_query_string instead",
 			},
-			"force_new": {
+			"escape_path_values": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to percent-encode the values that replace `{id}` and `{data.<field>}` in paths, for ids that contain characters such as slashes, spaces or colons. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"api_data": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "After data from the API server is read, this map will include k/v pairs usable in other terraform resources as readable objects. Currently the value is the golang fmt package's representation of the value (simple primitives are set as expected, but complex types like arrays and maps contain golang formatting).",
+				Computed:    true,
+				Sensitive:   isDataSensitive,
+			},
+			"api_data_json": {
+				Type:        schema.TypeString,
+				Description: "The data of the object as read from the API server, encoded as JSON. Use `jsondecode()` to access nested structures that `api_data` cannot represent.",
+				Computed:    true,
+				Sensitive:   isDataSensitive,
+			},
+			"extract": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A value to extract from the response into `outputs`, such as the host of a connection. May be repeated. When set, `api_data`, `api_data_json`, `api_response` and `create_response` are left empty so that only the extracted values are kept in the state.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The key of the value in `outputs`.",
+						},
+						"expression": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateExpression,
+							Description:  "A JMESPath expression that finds the value in the response, such as `connection.host`. Supports the same syntax as `id_expression`.",
+						},
+					},
+				},
+			},
+			"outputs": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The values extracted from the response by the `extract` blocks, by name.",
+				Computed:    true,
+				Sensitive:   isDataSensitive,
+			},
+			"capture_response_headers": {
 				Type:        schema.TypeList,
 				Elem:        &schema.Schema{Type: schema.TypeString},
 				Optional:    true,
-				ForceNew:    true,
-				Description: "Any changes to these values will result in recreating the resource instead of updating.",
+				Description: "The names of response headers to capture into `response_headers`, such as `ETag` or `X-RateLimit-Remaining`.",
 			},
-			"update_data": {
+			"response_headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The values of the headers in `capture_response_headers` as last seen in a response to a create, read or update request, by name.",
+				Computed:    true,
+			},
+			"last_operation": {
+				Type:        schema.TypeString,
+				Description: "The operation of the last request for the object: `create`, `read` or `update`.",
+				Computed:    true,
+			},
+			"last_request_url": {
 				Type:        schema.TypeString,
+				Description: "The URL of the last request for the object.",
+				Computed:    true,
+			},
+			"last_status_code": {
+				Type:        schema.TypeInt,
+				Description: "The HTTP status code of the response to the last request for the object, such as 201 for a create that returned `201 Created`.",
+				Computed:    true,
+			},
+			"keep_create_response": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to keep the body of the response to the create request in `create_response`, for APIs that only return secrets such as API keys when the object is created. The object is then read back after it is created, so that `api_data`, `api_data_json`, `api_response` and `server_data` do not hold the secrets. Default: false",
 				Optional:    true,
-				Description: "Valid JSON object to pass during to update requests.",
-				Sensitive:   isDataSensitive,
-				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
-					v := val.(string)
-					if v != "" {
-						data := make(map[string]interface{})
-						err := json.Unmarshal([]byte(v), &data)
-						if err != nil {
-							errs = append(errs, fmt.Errorf("update_data attribute is invalid JSON: %v", err))
-						}
-					}
-					return warns, errs
-				},
+				Default:     false,
 			},
-			"destroy_data": {
+			"create_response": {
 				Type:        schema.TypeString,
+				Description: "The raw body of the HTTP response to the create request when `keep_create_response` is set. Unlike `api_response`, it is not changed by later reads.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"response_envelope_key": {
+				Type:         schema.TypeString,
+				ValidateFunc: validateExpression,
+				Description:  "A JMESPath expression that finds the object in responses to create, read and update requests, for APIs that wrap every object in an envelope such as `{\"data\": {...}, \"meta\": {...}}`. The id and drift are then taken from the object itself. Supports the same syntax as `id_expression`, such as `data`.",
+				Optional:     true,
+			},
+			"response_transform": {
+				Type:         schema.TypeString,
+				ValidateFunc: validateExpression,
+				Description:  "A JMESPath expression that reshapes the object in responses before it is compared to `data` and exported, after `response_envelope_key` is applied. Use it to rename fields or convert their types without ignoring them, such as `merge(@, {name: display_name, port: to_number(port)})`. Supports the same syntax as `id_expression`, with `@` for the object itself and `{key: expression, ...}` to build an object. The expression must give an object.",
+				Optional:     true,
+			},
+			"request_schema": {
+				Type:         schema.TypeString,
+				Description:  "A JSON Schema that `data` must match, checked when planning so mistakes are reported before the request is sent. Load it from a file with `file(\"schema.json\")`. Supports the keywords `type`, `enum`, `const`, `required`, `properties`, `additionalProperties`, `items`, `minItems`, `maxItems`, `minLength`, `maxLength`, `pattern`, `minimum` and `maximum`, besides annotations such as `title` and `description`. Schemas with other keywords, such as `$ref`, `allOf`, `anyOf`, `oneOf` or `format`, are rejected. Patterns are Go (RE2) regular expressions, so lookarounds and backreferences are not supported.",
+				Optional:     true,
+				ValidateFunc: validateJSONSchemaAttr,
+			},
+			"validate_path": {
+				Type:        schema.TypeString,
+				Description: "The API path of a dry-run endpoint that `data` is sent to when planning new objects or changes to `data`, such as `/schemas/services/validate`. A response that is not a 2xx fails the plan with the response as the reason, so the API server can reject invalid data before anything is changed. The string `{id}` will be replaced with the terraform ID of existing objects and `{data.<field>}` with the value of `<field>` in `data`, escaped as set by `escape_path_values` like in the other paths.",
 				Optional:    true,
-				Description: "Valid JSON object to pass during to destroy requests.",
+			},
+			"validate_method": {
+				Type:         schema.TypeString,
+				ValidateFunc: validateHTTPMethod,
+				Description:  "Defaults to `POST`. The HTTP method of the requests to `validate_path`.",
+				Optional:     true,
+				Default:      "POST",
+			},
+			"required_data_keys": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "Fields that `data` must have, checked when planning so missing or misspelled fields are reported before any request is sent. Fields use the dot syntax of `ignore_changes_to`, such as `metadata.name`, `rules[0].port` or `rules[*].port` for a field of every item of a list.",
+			},
+			"response_schema": {
+				Type:         schema.TypeString,
+				Description:  "A JSON Schema that the object read from the API must match, after `response_envelope_key` and `response_transform` are applied. Supports the same keywords as `request_schema`.",
+				Optional:     true,
+				ValidateFunc: validateJSONSchemaAttr,
+			},
+			"api_response": {
+				Type:        schema.TypeString,
+				Description: "The raw body of the HTTP response from the last create or read of the object.",
+				Computed:    true,
 				Sensitive:   isDataSensitive,
-				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
-					v := val.(string)
-					if v != "" {
-						data := make(map[string]interface{})
-						err := json.Unmarshal([]byte(v), &data)
-						if err != nil {
-							errs = append(errs, fmt.Errorf("destroy_data attribute is invalid JSON: %v", err))
-						}
-					}
-					return warns, errs
-				},
+			},
+			"force_new": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Any changes to these values will result in recreating the resource instead of updating.",
+			},
+			"recreate_on_remote_change_of": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "The fields of `data` that cannot be updated, in the dot syntax of `ignore_changes_to`, such as `type`. If changes made outside of Terraform to one of them are found, the object is replaced instead of updated, for APIs that reject such updates with an error such as a 422.",
+			},
+			"update_triggers": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "The fields of `data` whose changes trigger an update, in the dot syntax of `ignore_changes_to`, such as `spec` or `rules[*].port`. Changes to `data` that touch none of them are not sent to the API server and are kept out of the plan, for documents with informational parts that the API rejects updates of. By default, any change triggers an update.",
+			},
+			"update_data": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "Valid JSON object or array to pass during to update requests.",
+				Sensitive:        isDataSensitive,
+				ValidateFunc:     validateDataObject("update_data"),
+				DiffSuppressFunc: suppressEquivalentData,
+			},
+			"destroy_data": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "Valid JSON object or array to pass during to destroy requests.",
+				Sensitive:        isDataSensitive,
+				ValidateFunc:     validateDataObject("destroy_data"),
+				DiffSuppressFunc: suppressEquivalentData,
 			},
 			"ignore_changes_to": {
 				Type:        schema.TypeList,
 				Elem:        &schema.Schema{Type: schema.TypeString},
 				Optional:    true,
-				Description: "A list of fields to which remote changes will be ignored. For example, an API might add or remove metadata, such as a 'last_modified' field, which Terraform should not attempt to correct. To ignore changes to nested fields, use the dot syntax: 'metadata.timestamp'",
+				Description: "A list of fields to which remote changes will be ignored. For example, an API might add or remove metadata, such as a 'last_modified' field, which Terraform should not attempt to correct. To ignore changes to nested fields, use the dot syntax: 'metadata.timestamp'. Use `*` for any field, `[*]` for any item of a list and `**` for any number of fields and items, such as `metadata.*`, `rules[*].updated_at` or `**.etag`. Items of a list may also be addressed by index or by the value of one of their keys, such as `rules[0].updated_at` or `members[id=42].last_seen`",
 				Sensitive:   isDataSensitive,
 				// TODO ValidateFunc not supported for lists, but should probably validate that the ignore paths are valid
 			},
+			"null_handling": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `send`. How fields of `data` that are `null` are sent to the API server on create and update. `send` sends them as JSON nulls, `omit` leaves them out and `unset` leaves them out of create requests but replaces them with `null_sentinel` in update requests, for APIs that clear fields with a special value. Items of lists are always sent as they are. Does not apply to the patches of `update_strategy`, where a null already removes the field.",
+				Optional:     true,
+				Default:      "send",
+				ValidateFunc: validation.StringInSlice([]string{"send", "omit", "unset"}, false),
+			},
+			"null_sentinel": {
+				Type:         schema.TypeString,
+				Description:  "The JSON value that replaces nulls in update requests when `null_handling` is `unset`, such as `\"__unset__\"` or `{\"$unset\": true}`.",
+				Optional:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"list_merge_keys": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "Maps lists of objects in `data` to the key that identifies their items, such as `{ rules = \"name\" }`. The items of those lists are paired up by that key instead of by their position, both to detect changes made outside of Terraform and for the patches of `update_strategy`. Reordering the items is then not a change. Nested lists use the dot syntax of `ignore_changes_to`, such as `spec.rules` or `rules[*].ports`.",
+			},
+			"field_normalizers": {
+				Type:             schema.TypeMap,
+				Elem:             &schema.Schema{Type: schema.TypeString},
+				Optional:         true,
+				ValidateDiagFunc: validation.MapValueMatch(regexp.MustCompile("^("+strings.Join(fieldNormalizerNames, "|")+")$"), "must be one of "+strings.Join(fieldNormalizerNames, ", ")),
+				Description:      "Maps fields of `data` to a normalizer that is applied to both the configured and the returned value before detecting changes made outside of Terraform, so that they only differ if their normalized values do. `timestamp` compares RFC 3339 timestamps and epoch seconds as points in time, `lowercase` ignores the case of values such as UUIDs and `trailing_slash` ignores trailing slashes of values such as URLs. Fields use the dot syntax of `ignore_changes_to`, such as `{ \"metadata.created_at\" = \"timestamp\", \"**.url\" = \"trailing_slash\" }`.",
+			},
+			"ignore_changes_matching": {
+				Type:         schema.TypeMap,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				Optional:     true,
+				ValidateFunc: validateRegexpMap,
+				Description:  "Maps fields of `data` to a regular expression. Changes made to those fields outside of Terraform are ignored when the new value matches it, for APIs that rewrite values unpredictably. Values are matched as strings, such as `true` for `true`. Fields use the dot syntax of `ignore_changes_to`, such as `{ \"status.message\" = \"^Updated by \" }`.",
+			},
+			"equivalent_values": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "A set of values that are taken as the same when detecting changes made outside of Terraform, for APIs that canonicalize values such as `\"enabled\"` to `true`. May be repeated.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"values": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Required:    true,
+							MinItems:    2,
+							Description: "The equivalent values as strings, such as `[\"enabled\", \"true\", \"1\"]`.",
+						},
+						"fields": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Optional:    true,
+							Description: "The fields of `data` the values are equivalent in, in the dot syntax of `ignore_changes_to`. Defaults to all fields.",
+						},
+					},
+				},
+			},
+			"normalize_types": {
+				Type:        schema.TypeBool,
+				Description: "Set this to 'true' to compare numbers, numeric strings and booleans by value when detecting changes made outside of Terraform, for APIs that return `\"5\"` for `5`, `1` for `true` or `\"1.0\"` for `1`. Default: false",
+				Optional:    true,
+				Default:     false,
+			},
+			"drift_mode": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `rewrite`. How changes made outside of Terraform are shown in plans. With `rewrite`, reads write them to `data` in the state, so the plan shows changing them back to the configuration. With `report`, `data` is left as configured and they are only listed in `drift`, so the plan shows an update of `drift` that sends the configuration to the API server.",
+				Optional:     true,
+				Default:      "rewrite",
+				ValidateFunc: validation.StringInSlice([]string{"rewrite", "report"}, false),
+			},
+			"drift": {
+				Type:        schema.TypeList,
+				Description: "The fields of the object that were changed outside of Terraform as of the last read, so such changes can be reported even when `ignore_all_server_changes` is set. Each entry has the `path` of the field, such as `rules[0].port`, and its configured `old_value` and current `new_value` as JSON, which are empty if the field was added or removed. Fields in `ignore_changes_to` are left out.",
+				Computed:    true,
+				Sensitive:   isDataSensitive,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"path": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The path of the field in the syntax of `ignore_changes_to`, such as `rules[0].port`.",
+						},
+						"old_value": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The configured value of the field as JSON, or empty if the field was added.",
+						},
+						"new_value": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The value of the field on the API server as JSON, or empty if the field was removed.",
+						},
+					},
+				},
+			},
+			"server_data_mode": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `merge`, which treats fields that the API server adds to the object, such as ids and timestamps, as changes to `data` unless they are in `ignore_changes_to`. Set this to `separate` to only keep them in `server_data`, so that `data` keeps matching the configuration and only changes to its own fields are corrected.",
+				Optional:     true,
+				Default:      "merge",
+				ValidateFunc: validation.StringInSlice([]string{"merge", "separate"}, false),
+			},
+			"server_data": {
+				Type:        schema.TypeString,
+				Description: "The fields of the object as read from the API server that are not in `data`, such as ids and timestamps it adds, encoded as JSON.",
+				Computed:    true,
+				Sensitive:   isDataSensitive,
+			},
 			"ignore_all_server_changes": {
 				Type:        schema.TypeBool,
 				Description: "By default Terraform will attempt to revert changes to remote resources. Set this to 'true' to ignore any remote changes. Default: false",
@@ -238,6 +1016,20 @@ Since there is nothing in the ResourceData structure other
 func resourceRestAPIImport(ctx context.Context, d *schema.ResourceData, meta interface{}) (imported []*schema.ResourceData, err error) {
 	input := d.Id()
 
+	/* Objects that cannot be read from /<path>/<id>, such as those with
+	   tenant-scoped or query-parameterized reads, are imported by JSON */
+	if strings.HasPrefix(strings.TrimSpace(input), "{") {
+		return resourceRestAPIImportJSON(ctx, d, meta, input)
+	}
+
+	/* An object whose id is not known can be found by a search instead:
+	   /path/to/objects?search_key=name&search_value=foo */
+	if i := strings.Index(input, "?"); i != -1 {
+		if params, err := url.ParseQuery(input[i+1:]); err == nil && params.Get("search_key") != "" {
+			return resourceRestAPIImportBySearch(ctx, d, meta, input[0:i], params)
+		}
+	}
+
 	hasTrailingSlash := strings.HasSuffix(input, "/")
 	var n int
 	if hasTrailingSlash {
@@ -251,7 +1043,6 @@ func resourceRestAPIImport(ctx context.Context, d *schema.ResourceData, meta int
 	}
 
 	path := input[0:n]
-	d.Set("path", path)
 
 	var id string
 	if hasTrailingSlash {
@@ -260,28 +1051,325 @@ func resourceRestAPIImport(ctx context.Context, d *schema.ResourceData, meta int
 		id = input[n+1:]
 	}
 
-	d.Set("data", fmt.Sprintf(`{ "id": "%s" }`, id))
-	d.SetId(id)
+	return resourceRestAPIImportByID(ctx, d, meta, path, id)
+}
+
+/* Imports the object at path with the given id */
+func resourceRestAPIImportByID(ctx context.Context, d *schema.ResourceData, meta interface{}, path string, id string) (imported []*schema.ResourceData, err error) {
+	d.Set("path", path)
+	d.Set("data", importData(d, meta, id))
+	d.SetId(id)
+
+	obj, err := makeAPIObject(d, meta)
+	if err != nil {
+		return imported, err
+	}
+
+	/* Troubleshooting is hard enough. Emit log messages so TF_LOG
+	   has useful information in case an import isn't working. This is
+	   not kept in the state, which generated configuration comes from */
+	obj.debug = true
+	log.Printf("resource_api_object.go: Import routine called. Object built:\n%s\n", obj.toString())
+
+	err = obj.readObject(ctx)
+	if err == nil {
+		err = setImportedState(obj, d)
+	}
+	if err == nil {
+		/* Data that we set in the state above must be passed along
+		   as an item in the stack of imported data */
+		imported = append(imported, d)
+	}
+
+	return imported, err
+}
+
+/*
+Imports the object at path that has search_value at search_key, as
+
+	read_search finds it. The results_key and query_string of the search
+	may be given as well.
+*/
+func resourceRestAPIImportBySearch(ctx context.Context, d *schema.ResourceData, meta interface{}, path string, params url.Values) (imported []*schema.ResourceData, err error) {
+	searchKey := params.Get("search_key")
+	searchValue := params.Get("search_value")
+	if searchValue == "" {
+		return imported, fmt.Errorf("invalid search to import api_object '%s' - must be /<full path from server root>?search_key=<key>&search_value=<value>", d.Id())
+	}
+
+	d.SetId("")
+	d.Set("path", path)
+
+	obj, err := makeAPIObject(d, meta)
+	if err != nil {
+		return imported, err
+	}
+	obj.debug = true
+	log.Printf("resource_api_object.go: Import routine called with a search for '%s'='%s'. Object built:\n%s\n", searchKey, searchValue, obj.toString())
+
+	if _, err := obj.findObject(ctx, params.Get("query_string"), searchKey, searchValue, params.Get("results_key")); err != nil {
+		return imported, err
+	}
+
+	d.Set("data", importData(d, meta, obj.id))
+	d.SetId(obj.id)
+
+	err = obj.readObject(ctx)
+	if err == nil {
+		err = setImportedState(obj, d)
+	}
+	if err == nil {
+		imported = append(imported, d)
+	}
+
+	return imported, err
+}
+
+/*
+The data an import starts from, which is only the id at id_attribute,
+
+	such as {"metadata": {"uuid": "..."}} for metadata/uuid.
+*/
+func importData(d *schema.ResourceData, meta interface{}, id string) string {
+	idAttribute := d.Get("id_attribute").(string)
+	if idAttribute == "" {
+		idAttribute = meta.(*APIClient).idAttribute
+	}
+	if idAttribute == "" {
+		idAttribute = "id"
+	}
+
+	var data interface{} = id
+	parts := attributePathKeys(idAttribute)
+	for i := len(parts) - 1; i >= 0; i-- {
+		data = map[string]interface{}{parts[i]: data}
+	}
+	encoded, _ := json.Marshal(data)
+	return string(encoded)
+}
+
+/* Fields that servers commonly change on their own, such as timestamps */
+var volatileFieldNames = []string{
+	"created", "created_at", "createdAt", "creationTimestamp", "creation_timestamp",
+	"updated", "updated_at", "updatedAt", "modified", "modified_at", "modifiedAt",
+	"last_modified", "lastModified", "last_updated", "lastUpdated", "etag",
+}
+
+/*
+The ignore_changes_to of an imported object, which are the fields of
+
+	volatileFieldNames it has: by name at the top level, and as **.name
+	anywhere below it.
+*/
+func volatileFields(data map[string]interface{}) []string {
+	fields := []string{}
+	var walk func(value interface{}, nested bool)
+	walk = func(value interface{}, nested bool) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if containsString(volatileFieldNames, k) {
+					field := k
+					if nested {
+						field = "**." + k
+					}
+					if !containsString(fields, field) {
+						fields = append(fields, field)
+					}
+				}
+				walk(child, true)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child, true)
+			}
+		}
+	}
+	walk(data, false)
+	sort.Strings(fields)
+	return fields
+}
+
+/*
+Records an imported object in the state. Its data is what was read
+
+	rather than just its id, so that the plan after the import only
+	shows what the configuration actually changes about the object.
+*/
+func setImportedState(obj *APIObject, d *schema.ResourceData) error {
+	encoded, err := json.Marshal(dataOrValue(obj.apiData, obj.apiDataValue))
+	if err != nil {
+		return err
+	}
+	if obj.apiDataValue == nil {
+		obj.data = obj.apiData
+	}
+	d.Set("data", string(encoded))
+	d.Set("data_fields", dataFields(string(encoded), obj.dataFormat))
+	if _, ok := d.GetOk("ignore_changes_to"); !ok {
+		if fields := volatileFields(obj.apiData); len(fields) > 0 {
+			d.Set("ignore_changes_to", fields)
+		}
+	}
+	d.Set("version", obj.version)
+	d.Set("response_headers", obj.responseHeaders)
+	setLastRequest(obj, d)
+	setServerData(obj, d)
+	return setResourceState(obj, d)
+}
+
+/* The keys of a JSON import id that are set as attributes of the object */
+var importAttributes = []string{"read_path", "query_string", "read_query_string", "id_attribute"}
+
+/*
+Imports the object described by a JSON import id, such as
+
+	{"path": "/api/tenants/a/objects", "id": "42", "query_string": "tenant=a"}.
+	Instead of the id, search_key and search_value (along with the
+	optional results_key and search_query_string) find the object by a
+	search. The other keys are set as the attributes of the same name.
+*/
+func resourceRestAPIImportJSON(ctx context.Context, d *schema.ResourceData, meta interface{}, input string) (imported []*schema.ResourceData, err error) {
+	spec := make(map[string]string)
+	if err := json.Unmarshal([]byte(input), &spec); err != nil {
+		return imported, fmt.Errorf("invalid JSON to import api_object '%s' - must be an object of strings: %v", input, err)
+	}
+
+	allowed := append([]string{"path", "id", "search_key", "search_value", "results_key", "search_query_string"}, importAttributes...)
+	for k := range spec {
+		if !containsString(allowed, k) {
+			return imported, fmt.Errorf("invalid JSON to import api_object '%s' - unknown key '%s', expected one of %s", input, k, strings.Join(allowed, ", "))
+		}
+	}
+	if spec["path"] == "" {
+		return imported, fmt.Errorf("invalid JSON to import api_object '%s' - path is required", input)
+	}
+	if (spec["id"] == "") == (spec["search_key"] == "") {
+		return imported, fmt.Errorf("invalid JSON to import api_object '%s' - exactly one of id or search_key is required", input)
+	}
+
+	for _, attr := range importAttributes {
+		if spec[attr] != "" {
+			d.Set(attr, spec[attr])
+		}
+	}
+
+	if spec["search_key"] != "" {
+		params := url.Values{}
+		params.Set("search_key", spec["search_key"])
+		params.Set("search_value", spec["search_value"])
+		params.Set("results_key", spec["results_key"])
+		params.Set("query_string", spec["search_query_string"])
+		return resourceRestAPIImportBySearch(ctx, d, meta, spec["path"], params)
+	}
+	return resourceRestAPIImportByID(ctx, d, meta, spec["path"], spec["id"])
+}
+
+func resourceRestAPICustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	/* The validators also accept YAML, as they cannot know the
+	   data_format. Enforce JSON here unless YAML was requested */
+	if d.Get("data_format").(string) != "yaml" {
+		for _, attr := range []string{"data", "update_data", "destroy_data"} {
+			if v := d.Get(attr).(string); v != "" && !isDataHash(v) {
+				if _, err := parseDataValue(v, make(map[string]interface{})); err != nil {
+					return fmt.Errorf("%s attribute is invalid JSON: %v", attr, err)
+				}
+			}
+		}
+	}
+
+	if err := validateRequestSchema(d); err != nil {
+		return err
+	}
+	if err := validateRequiredDataKeys(d); err != nil {
+		return err
+	}
+	if err := validateWithAPI(ctx, d, meta); err != nil {
+		return err
+	}
+
+	if d.NewValueKnown("ndjson_data") && d.NewValueKnown("body_format") {
+		_, hasDocuments := d.GetOk("ndjson_data")
+		if isNDJSON := d.Get("body_format").(string) == "ndjson"; isNDJSON != hasDocuments {
+			return fmt.Errorf("ndjson_data must be set if and only if body_format is ndjson")
+		}
+	}
+
+	if d.Get("null_handling").(string) == "unset" && d.NewValueKnown("null_sentinel") && d.Get("null_sentinel").(string) == "" {
+		return fmt.Errorf("null_sentinel must be set when null_handling is unset")
+	}
 
-	/* Troubleshooting is hard enough. Emit log messages so TF_LOG
-	   has useful information in case an import isn't working */
-	d.Set("debug", true)
+	/* With drift_mode report, the drift found by the last read is not
+	   written to data, so plan an update to correct it instead */
+	drift := d.Get("drift").([]interface{})
+	changedKey := "data"
+	if d.Get("drift_mode").(string) == "report" && !d.Get("ignore_all_server_changes").(bool) && d.Id() != "" && len(drift) > 0 && !d.HasChange("data") {
+		if err := d.SetNewComputed("drift"); err != nil {
+			return err
+		}
+		changedKey = "drift"
+	}
 
-	obj, err := makeAPIObject(d, meta)
-	if err != nil {
-		return imported, err
+	/* Replace the object if the last update was rejected as it
+	   cannot be updated */
+	if d.Get("update_rejected").(bool) && d.Id() != "" && d.HasChange(changedKey) {
+		log.Printf("resource_api_object.go: The last update of '%s' was rejected. Replacing the object.\n", d.Id())
+		if err := d.SetNew("update_rejected", false); err != nil {
+			return err
+		}
+		if err := d.ForceNew(changedKey); err != nil {
+			return err
+		}
 	}
-	log.Printf("resource_api_object.go: Import routine called. Object built:\n%s\n", obj.toString())
 
-	err = obj.readObject(ctx)
-	if err == nil {
-		//setResourceState(obj, d)
-		/* Data that we set in the state above must be passed along
-		   as an item in the stack of imported data */
-		imported = append(imported, d)
+	/* Replace the object if the API forbids updating the fields that
+	   were changed outside of Terraform, as reported in drift */
+	if v, ok := d.GetOk("recreate_on_remote_change_of"); ok && d.Id() != "" && d.HasChange(changedKey) {
+		recreateFields := expandStringList(v.([]interface{}))
+		old, _ := d.GetChange("data")
+		if recorded, ok := decodeDataAttr(old.(string), d.Get("data_format").(string)); ok {
+			for _, change := range drift {
+				path := change.(map[string]interface{})["path"].(string)
+				if pathMatches(path, recorded, recreateFields) {
+					log.Printf("resource_api_object.go: '%s' was changed outside of Terraform and is in recreate_on_remote_change_of. Replacing the object.\n", path)
+					if err := d.ForceNew(changedKey); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
 	}
 
-	return imported, err
+	/* Show changes to data field by field in the plan */
+	if d.NewValueKnown("data") && d.NewValueKnown("data_format") {
+		fields := dataFields(d.Get("data").(string), d.Get("data_format").(string))
+		if d.Get("store_data_hash_only").(bool) {
+			fields = map[string]interface{}{}
+		}
+		if !reflect.DeepEqual(fields, d.Get("data_fields").(map[string]interface{})) {
+			if err := d.SetNew("data_fields", fields); err != nil {
+				return err
+			}
+		}
+	} else if err := d.SetNewComputed("data_fields"); err != nil {
+		return err
+	}
+
+	/* Terraform only sees the path of data_file, so track
+	   changes to the content of the file by its checksum */
+	if v, ok := d.GetOk("data_file"); ok {
+		sum, err := fileSHA256(v.(string))
+		if err != nil {
+			/* The file may be created by another resource during apply */
+			log.Printf("resource_api_object.go: Unable to checksum data_file yet: %v", err)
+			return d.SetNewComputed("data_file_sha256")
+		}
+		if sum != d.Get("data_file_sha256").(string) {
+			return d.SetNew("data_file_sha256", sum)
+		}
+	}
+	return nil
 }
 
 func resourceRestAPICreate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
@@ -291,13 +1379,44 @@ func resourceRestAPICreate(ctx context.Context, d *schema.ResourceData, meta int
 	}
 	log.Printf("resource_api_object.go: Create routine called. Object built:\n%s\n", obj.toString())
 
-	err = obj.createObject(ctx)
+	err = obj.withHooks(ctx, "create", func() error {
+		return obj.createObject(ctx)
+	})
+	/* If the object was created but what followed failed, such as reading
+	   it back, keep its id so that it is tainted instead of orphaned */
+	if err != nil && obj.created && obj.id != "" {
+		log.Printf("resource_api_object.go: Object '%s' was created, but the create did not complete. Keeping it in the state as tainted.\n", obj.id)
+		d.SetId(obj.id)
+		setLastRequest(obj, d)
+		if obj.keepCreateResponse && len(obj.extract) == 0 {
+			d.Set("create_response", obj.createResponse)
+		}
+		return fmt.Errorf("object '%s' was created, but the create did not complete; it is kept in the state as tainted and replaced on the next apply unless it is untainted: %v", obj.id, err)
+	}
 	if err == nil {
 		/* Setting terraform ID tells terraform the object was created or it exists */
 		d.SetId(obj.id)
-		//setResourceState(obj, d)
+		/* If a follow-up request fails, the object exists but
+		   is tainted so that it is recreated on the next apply */
+		err = obj.postCreate(ctx)
+		if err == nil {
+			err = obj.waitForState(ctx)
+		}
+		d.Set("data_file_sha256", obj.dataFileSHA256)
+		d.Set("version", obj.version)
+		d.Set("location", obj.location)
+		d.Set("response_headers", obj.responseHeaders)
+		setLastRequest(obj, d)
+		setServerData(obj, d)
+		if stateErr := setResourceState(obj, d); err == nil {
+			err = stateErr
+		}
 		/* Only set during create for APIs that don't return sensitive data on subsequent retrieval */
-		//d.Set("create_response", obj.apiResponse)
+		if obj.keepCreateResponse && len(obj.extract) == 0 {
+			d.Set("create_response", obj.createResponse)
+		}
+		d.Set("data_fields", dataFields(d.Get("data").(string), obj.dataFormat))
+		storeDataHash(d)
 	}
 	return err
 }
@@ -314,16 +1433,36 @@ func resourceRestAPIRead(ctx context.Context, d *schema.ResourceData, meta inter
 	}
 	log.Printf("resource_api_object.go: Read routine called. Object built:\n%s\n", obj.toString())
 
-	err = obj.readObject(ctx)
+	err = obj.withHooks(ctx, "read", func() error {
+		return obj.refreshObject(ctx)
+	})
+	var transient *transientError
+	if errors.As(err, &transient) && obj.readFailureBehavior == "keep_state" {
+		log.Printf("resource_api_object.go: Unable to refresh '%s' (%v). Keeping its last known state.\n", d.Id(), err)
+		return &warningError{
+			summary: fmt.Sprintf("unable to refresh object '%s'; keeping its last known state", d.Id()),
+			detail:  err.Error(),
+		}
+	}
 	if err == nil {
 		/* Setting terraform ID tells terraform the object was created or it exists */
 		log.Printf("resource_api_object.go: Read resource. Returned id is '%s'\n", obj.id)
 		d.SetId(obj.id)
+		d.Set("version", obj.version)
+		d.Set("response_headers", obj.responseHeaders)
+		setLastRequest(obj, d)
+		if err := setResourceState(obj, d); err != nil {
+			return err
+		}
 
-		//setResourceState(obj, d)
+		apiData := setServerData(obj, d)
 
-		// Check whether the remote resource has changed.
-		if !(d.Get("ignore_all_server_changes")).(bool) {
+		// Check whether the remote resource has changed. Objects sent from
+		// data_file or ndjson_data, or with empty or scalar data, have no
+		// JSON data to compare against and only go away when not found.
+		// Changes are reported in drift even when they are not corrected.
+		drift := []interface{}{}
+		if obj.detectsDrift() {
 			ignoreList := []string{}
 			v, ok := d.GetOk("ignore_changes_to")
 			if ok {
@@ -347,26 +1486,98 @@ func resourceRestAPIRead(ctx context.Context, d *schema.ResourceData, meta inter
 
 			// This checks if there were any changes to the remote resource that will need to be corrected
 			// by comparing the current state with the response returned by the api.
-			modifiedResource, hasDifferences := getDelta(obj.data, obj.apiData, ignoreList, driftFields)
+			var modifiedResource interface{}
+			var hasDifferences bool
+			if obj.dataValue != nil {
+				// Arrays have no keys to ignore, so they are compared as a whole
+				modifiedResource = obj.apiDataValue
+				hasDifferences = obj.apiDataValue != nil && !reflect.DeepEqual(obj.dataValue, obj.apiDataValue)
+			} else {
+				if v, ok := d.GetOk("field_normalizers"); ok {
+					fieldNormalizers := make(map[string]string)
+					for path, normalizer := range v.(map[string]interface{}) {
+						fieldNormalizers[path] = normalizer.(string)
+					}
+					apiData, _ = normalizeFields(obj.data, apiData, obj.listMergeKeys, fieldNormalizers).(map[string]interface{})
+				}
+				if d.Get("normalize_types").(bool) {
+					apiData, _ = normalizeTypes(obj.data, apiData, obj.listMergeKeys).(map[string]interface{})
+				}
+				if v, ok := d.GetOk("ignore_changes_matching"); ok {
+					ignoredPatterns := make(map[string]string)
+					for path, pattern := range v.(map[string]interface{}) {
+						ignoredPatterns[path] = pattern.(string)
+					}
+					apiData, _ = ignoreMatchingValues(obj.data, apiData, obj.listMergeKeys, ignoredPatterns).(map[string]interface{})
+				}
+				if v, ok := d.GetOk("equivalent_values"); ok {
+					classes := []equivalentValues{}
+					for _, c := range v.([]interface{}) {
+						class := c.(map[string]interface{})
+						fields := expandStringList(class["fields"].([]interface{}))
+						if len(fields) == 0 {
+							fields = []string{"**"}
+						}
+						classes = append(classes, equivalentValues{values: expandStringList(class["values"].([]interface{})), fields: fields})
+					}
+					apiData, _ = normalizeEquivalentValues(obj.data, apiData, obj.listMergeKeys, classes).(map[string]interface{})
+				}
+				modifiedResource, hasDifferences = getDelta(obj.data, apiData, ignoreList, driftFields, obj.listMergeKeys)
+			}
 
 			if hasDifferences {
+				var recorded interface{} = obj.data
+				if obj.dataValue != nil {
+					recorded = obj.dataValue
+				}
+				for _, change := range getDriftChanges(recorded, modifiedResource, "") {
+					drift = append(drift, map[string]interface{}{
+						"path":      change.path,
+						"old_value": change.oldValue,
+						"new_value": change.newValue,
+					})
+				}
+			}
+
+			if hasDifferences && !d.Get("ignore_all_server_changes").(bool) && d.Get("drift_mode").(string) != "report" {
 				log.Printf("resource_api_object.go: Found differences in remote resource\n")
 				encoded, err := json.Marshal(modifiedResource)
 				if err != nil {
 					return err
 				}
 				jsonString := string(encoded)
+				if obj.dataFormat == "yaml" {
+					/* Keep the state in the format the user writes */
+					if jsonString, err = jsonToYAML(jsonString); err != nil {
+						return err
+					}
+				}
 				if err := d.Set("data", jsonString); err != nil {
 					return err
 				}
 			}
 		}
-
+		d.Set("drift", drift)
+		d.Set("data_fields", dataFields(d.Get("data").(string), obj.dataFormat))
 	}
 	return err
 }
 
 func resourceRestAPIUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	/* Moving the object to other paths, such as when an API is versioned,
+	   only changes where it is found. Read it there instead of updating it */
+	if d.HasChanges(objectPathAttributes...) && !d.HasChangesExcept(objectPathAttributes...) {
+		log.Printf("resource_api_object.go: Only the paths of '%s' changed. Reading it at the new paths instead of updating it.\n", d.Id())
+		id := d.Id()
+		if err := resourceRestAPIRead(ctx, d, meta); err != nil {
+			return err
+		}
+		if d.Id() == "" {
+			return fmt.Errorf("object '%s' was not found at its new path; it may need to be moved on the API server first", id)
+		}
+		return nil
+	}
+
 	obj, err := makeAPIObject(d, meta)
 	if err != nil {
 		return err
@@ -374,38 +1585,71 @@ func resourceRestAPIUpdate(ctx context.Context, d *schema.ResourceData, meta int
 
 	/* If copy_keys is not empty, we have to grab the latest
 	   data so we can copy anything needed before the update */
-	client := meta.(*APIClient)
-	if len(client.copyKeys) > 0 {
+	if len(obj.copyKeys) > 0 {
+		/* The version from the state is kept, or If-Match would not
+		   catch changes made on the server since the last refresh */
+		version := obj.version
 		err = obj.readObject(ctx)
 		if err != nil {
 			return err
 		}
+		obj.version = version
 	}
 
 	log.Printf("resource_api_object.go: Update routine called. Object built:\n%s\n", obj.toString())
 
-	err = obj.updateObject(ctx)
+	err = obj.withHooks(ctx, "update", func() error {
+		return obj.updateObject(ctx)
+	})
+	var rejected *updateRejectedError
+	if errors.As(err, &rejected) {
+		/* Keep the previous data in the state so the next plan still
+		   shows the change and replaces the object */
+		oldData, _ := d.GetChange("data")
+		d.Set("data", oldData)
+		d.Set("update_rejected", true)
+	}
+	if err == nil {
+		err = obj.waitForState(ctx)
+	}
 	if err == nil {
-		//setResourceState(obj, d)
+		d.Set("data_file_sha256", obj.dataFileSHA256)
+		d.Set("version", obj.version)
+		d.Set("response_headers", obj.responseHeaders)
+		setLastRequest(obj, d)
+		d.Set("data_fields", dataFields(d.Get("data").(string), obj.dataFormat))
+		storeDataHash(d)
+		d.Set("update_rejected", false)
+		/* The update corrected any drift */
+		d.Set("drift", []interface{}{})
+		/* Only set if the object was updated from a response */
+		if obj.apiResponse != "" {
+			setServerData(obj, d)
+			err = setResourceState(obj, d)
+		}
 	}
 	return err
 }
 
+/* The attributes that only tell where the object is found on the API server */
+var objectPathAttributes = []string{"path", "create_path", "read_path", "update_path", "destroy_path"}
+
 func resourceRestAPIDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	/* The object stays on the server and is only forgotten by terraform */
+	if d.Get("skip_destroy").(bool) {
+		log.Printf("resource_api_object.go: skip_destroy is set. Removing '%s' from the state without destroying it.\n", d.Id())
+		return nil
+	}
+
 	obj, err := makeAPIObject(d, meta)
 	if err != nil {
 		return err
 	}
 	log.Printf("resource_api_object.go: Delete routine called. Object built:\n%s\n", obj.toString())
 
-	err = obj.deleteObject(ctx)
-	if err != nil {
-		if strings.Contains(err.Error(), "404") {
-			/* 404 means it doesn't exist. Call that good enough */
-			err = nil
-		}
-	}
-	return err
+	return obj.withHooks(ctx, "destroy", func() error {
+		return obj.deleteObject(ctx)
+	})
 }
 
 /*
@@ -435,6 +1679,37 @@ func makeAPIObject(d *schema.ResourceData, meta interface{}) (*APIObject, error)
 	return obj, err
 }
 
+/*
+Records the fields that only the server has in server_data. Returns
+
+	the fields of the object that are also in data, which are all that is
+	compared to data if server_data_mode is separate.
+*/
+func setServerData(obj *APIObject, d *schema.ResourceData) map[string]interface{} {
+	/* Like api_data, it is left empty if only extracted values are kept */
+	if obj.dataValue != nil || obj.apiData == nil || len(obj.extract) > 0 {
+		d.Set("server_data", "")
+		return obj.apiData
+	}
+	configuredFields, serverFields := splitServerFields(obj.data, obj.apiData)
+	encoded, _ := json.Marshal(serverFields)
+	d.Set("server_data", string(encoded))
+	if d.Get("server_data_mode").(string) == "separate" {
+		return configuredFields
+	}
+	return obj.apiData
+}
+
+/* Records the last request for the object, if one was sent */
+func setLastRequest(obj *APIObject, d *schema.ResourceData) {
+	if obj.lastOperation == "" {
+		return
+	}
+	d.Set("last_operation", obj.lastOperation)
+	d.Set("last_request_url", obj.lastRequestURL)
+	d.Set("last_status_code", obj.lastStatusCode)
+}
+
 func buildAPIObjectOpts(d *schema.ResourceData) (*apiObjectOpts, error) {
 	opts := &apiObjectOpts{
 		path: d.Get("path").(string),
@@ -444,6 +1719,12 @@ func buildAPIObjectOpts(d *schema.ResourceData) (*apiObjectOpts, error) {
 	if v, ok := d.GetOk("id_attribute"); ok {
 		opts.idAttribute = v.(string)
 	}
+	if v, ok := d.GetOk("id_expression"); ok {
+		opts.idExpression = v.(string)
+	}
+	if v, ok := d.GetOk("id_template"); ok {
+		opts.idTemplate = v.(string)
+	}
 
 	/* Allow user to specify the ID manually */
 	if v, ok := d.GetOk("object_id"); ok {
@@ -485,6 +1766,9 @@ func buildAPIObjectOpts(d *schema.ResourceData) (*apiObjectOpts, error) {
 	if v, ok := d.GetOk("destroy_path"); ok {
 		opts.deletePath = v.(string)
 	}
+	if v, ok := d.GetOk("destroy_success_field"); ok {
+		opts.destroySuccessField = v.(string)
+	}
 	if v, ok := d.GetOk("query_string"); ok {
 		opts.queryString = v.(string)
 	}
@@ -499,17 +1783,541 @@ func buildAPIObjectOpts(d *schema.ResourceData) (*apiObjectOpts, error) {
 	}
 	if v, ok := d.GetOk("destroy_query_string"); ok {
 		opts.destroyQueryString = v.(string)
+	} else if v, ok := d.GetOk("delete_query_string"); ok {
+		opts.destroyQueryString = v.(string)
+	}
+	if v, ok := d.GetOk("data_format"); ok {
+		opts.dataFormat = v.(string)
+	}
+	if v, ok := d.GetOk("body_format"); ok {
+		opts.bodyFormat = v.(string)
+	}
+	if v, ok := d.GetOk("update_strategy"); ok {
+		opts.updateStrategy = v.(string)
+	}
+	if v, ok := d.GetOk("list_merge_keys"); ok {
+		opts.listMergeKeys = make(map[string]string)
+		for path, key := range v.(map[string]interface{}) {
+			opts.listMergeKeys[path] = key.(string)
+		}
+	}
+	opts.nullHandling = d.Get("null_handling").(string)
+	if v, ok := d.GetOk("null_sentinel"); ok {
+		opts.nullSentinel = v.(string)
+	}
+	if v, ok := d.GetOk("create_strategy"); ok {
+		opts.createStrategy = v.(string)
+	}
+	if v, ok := d.GetOk("create_conflict_behavior"); ok {
+		opts.createConflict = v.(string)
+	}
+	if v, ok := d.GetOk("location_id_regex"); ok {
+		opts.locationIDRegex = v.(string)
+	}
+	if v, ok := d.GetOk("version_header"); ok {
+		opts.versionHeader = v.(string)
+	}
+	if v, ok := d.GetOk("version_field"); ok {
+		opts.versionField = v.(string)
+	}
+	for _, v := range d.Get("post_create_request").([]interface{}) {
+		r := v.(map[string]interface{})
+		opts.postCreateRequests = append(opts.postCreateRequests, extraRequest{
+			method: r["method"].(string),
+			path:   r["path"].(string),
+			data:   r["data"].(string),
+		})
+	}
+	for _, v := range d.Get("hook").([]interface{}) {
+		r := v.(map[string]interface{})
+		opts.hooks = append(opts.hooks, extraRequest{
+			operation:     r["operation"].(string),
+			when:          r["when"].(string),
+			method:        r["method"].(string),
+			path:          r["path"].(string),
+			data:          r["data"].(string),
+			successField:  r["success_field"].(string),
+			successValues: expandStringList(r["success_values"].([]interface{})),
+		})
+	}
+	if v := d.Get("destroy_precondition").([]interface{}); len(v) > 0 && v[0] != nil {
+		p := v[0].(map[string]interface{})
+		opts.destroyPrecondition = &destroyPrecondition{
+			request: extraRequest{
+				method:        p["method"].(string),
+				path:          p["path"].(string),
+				successField:  p["field"].(string),
+				successExpr:   p["expression"].(string),
+				successValues: expandStringList(p["values"].([]interface{})),
+			},
+			message: p["message"].(string),
+		}
+	}
+	for _, v := range d.Get("multipart_file").([]interface{}) {
+		f := v.(map[string]interface{})
+		file := multipartFile{
+			name:        f["name"].(string),
+			path:        f["path"].(string),
+			content:     f["content"].(string),
+			filename:    f["filename"].(string),
+			contentType: f["content_type"].(string),
+		}
+		if (file.path == "") == (file.content == "") {
+			return opts, fmt.Errorf("exactly one of path or content must be set for multipart_file '%s'", file.name)
+		}
+		opts.multipartFiles = append(opts.multipartFiles, file)
 	}
 
 	readSearch := expandReadSearch(d.Get("read_search").(map[string]interface{}))
+	if match := readSearch["match"]; match != "" && !containsString(searchMatches, match) {
+		return opts, fmt.Errorf("read_search match '%s' is not one of %v", match, searchMatches)
+	}
 	opts.readSearch = readSearch
 
 	opts.data = d.Get("data").(string)
+	previousData, _ := d.GetChange("data")
+	opts.previousData = previousData.(string)
+	/* With store_data_hash_only, the state only has a hash of data, so
+	   take data from the configuration when it is available. Reads and
+	   destroys have no configuration and work without data */
+	if isDataHash(opts.data) {
+		opts.data = ""
+		if config := d.GetRawConfig(); !config.IsNull() {
+			if v := config.GetAttr("data"); v.IsKnown() && !v.IsNull() {
+				opts.data = v.AsString()
+			}
+		}
+	}
+	if isDataHash(opts.previousData) {
+		opts.previousData = ""
+	}
+	opts.dataFile = d.Get("data_file").(string)
+	opts.dataFileContentType = d.Get("data_file_content_type").(string)
+	opts.ndjsonData = expandStringList(d.Get("ndjson_data").([]interface{}))
+	opts.createSuccessCodes = expandIntList(d.Get("create_success_codes").([]interface{}))
+	opts.readSuccessCodes = expandIntList(d.Get("read_success_codes").([]interface{}))
+	opts.updateSuccessCodes = expandIntList(d.Get("update_success_codes").([]interface{}))
+	opts.replaceStatusCodes = expandIntList(d.Get("replace_on_update_status_codes").([]interface{}))
+	opts.destroySuccessCodes = expandIntList(d.Get("destroy_success_codes").([]interface{}))
+	opts.destroySuccessVals = expandStringList(d.Get("destroy_success_values").([]interface{}))
+	opts.notFoundCodes = expandIntList(d.Get("not_found_codes").([]interface{}))
+	opts.createPoll = expandPoll(d.Get("create_poll").([]interface{}))
+	opts.updatePoll = expandPoll(d.Get("update_poll").([]interface{}))
+	opts.destroyPoll = expandPoll(d.Get("destroy_poll").([]interface{}))
+	opts.followLocation = d.Get("follow_location").(bool)
+	if v, ok := d.GetOk("id_header"); ok {
+		opts.idHeader = v.(string)
+	}
+	if v, ok := d.GetOk("id_header_regex"); ok {
+		opts.idHeaderRegex = v.(string)
+	}
+	opts.location = d.Get("location").(string)
+	opts.createReturnsObject = getConfiguredBool(d, "create_returns_object")
+	opts.writeReturnsObject = getConfiguredBool(d, "write_returns_object")
+	opts.readAfterWrite = d.Get("read_after_write").(bool)
+	opts.keepCreateResponse = d.Get("keep_create_response").(bool)
+	opts.escapePathValues = d.Get("escape_path_values").(bool)
+	if v, ok := d.GetOk("response_envelope_key"); ok {
+		opts.responseEnvelopeKey = v.(string)
+	}
+	if v, ok := d.GetOk("response_transform"); ok {
+		opts.responseTransform = v.(string)
+	}
+	if v, ok := d.GetOk("response_schema"); ok {
+		opts.responseSchema = v.(string)
+	}
+	opts.captureHeaders = expandStringList(d.Get("capture_response_headers").([]interface{}))
+	opts.responseHeaders = make(map[string]string)
+	for k, v := range d.Get("response_headers").(map[string]interface{}) {
+		opts.responseHeaders[k] = v.(string)
+	}
+	if v := d.Get("extract").([]interface{}); len(v) > 0 {
+		opts.extract = make(map[string]string)
+		for _, e := range v {
+			extract := e.(map[string]interface{})
+			opts.extract[extract["name"].(string)] = extract["expression"].(string)
+		}
+	}
+	opts.createReadTimeout = time.Duration(d.Get("create_read_timeout").(int)) * time.Second
+	opts.readFailureBehavior = d.Get("read_failure_behavior").(string)
+	opts.readRetries = d.Get("read_retries").(int)
+	opts.waitForDeletion = time.Duration(d.Get("wait_for_deletion").(int)) * time.Second
+	if v := d.Get("wait_for").([]interface{}); len(v) > 0 && v[0] != nil {
+		w := v[0].(map[string]interface{})
+		opts.waitFor = &waitForOpts{
+			field:      w["field"].(string),
+			expression: w["expression"].(string),
+			value:      w["value"].(string),
+			interval:   time.Duration(w["interval"].(int)) * time.Second,
+			timeout:    time.Duration(w["timeout"].(int)) * time.Second,
+		}
+		if r := w["value_regex"].(string); r != "" {
+			regex, err := regexp.Compile(r)
+			if err != nil {
+				return opts, fmt.Errorf("wait_for value_regex '%s' is not valid: %v", r, err)
+			}
+			opts.waitFor.valueRegex = regex
+		}
+	}
+	if v := d.Get("error_expression").([]interface{}); len(v) > 0 && v[0] != nil {
+		e := v[0].(map[string]interface{})
+		opts.errorExpression = &errorExpressionOpts{
+			expression:        e["expression"].(string),
+			errorValues:       expandStringList(e["error_values"].([]interface{})),
+			successValues:     expandStringList(e["success_values"].([]interface{})),
+			messageExpression: e["message_expression"].(string),
+		}
+	}
+	opts.errorDetailsExpr = d.Get("error_detail_expression").(string)
+	for _, v := range d.Get("error_message").([]interface{}) {
+		e := v.(map[string]interface{})
+		if opts.errorMessages == nil {
+			opts.errorMessages = make(map[int]errorMessage)
+		}
+		code := e["status_code"].(int)
+		if _, ok := opts.errorMessages[code]; ok {
+			return opts, fmt.Errorf("error_message for status code %d is set more than once", code)
+		}
+		opts.errorMessages[code] = errorMessage{
+			message: e["message"].(string),
+			hint:    e["hint"].(string),
+		}
+	}
+	/* An empty list in the configuration turns off the copy_keys of the provider */
+	if copyKeys := expandStringList(d.Get("copy_keys").([]interface{})); len(copyKeys) > 0 || isConfigured(d, "copy_keys") {
+		opts.copyKeys = copyKeys
+	}
+	opts.createIfNoneMatch = d.Get("create_if_none_match").(bool)
+	opts.useIfMatch = d.Get("use_if_match").(bool)
+	opts.version = d.Get("version").(string)
 	opts.debug = d.Get("debug").(bool)
 
 	return opts, nil
 }
 
+/*
+Validates that an attribute holds a JSON value. YAML is
+
+	also accepted since validation cannot know the data_format, which
+	is instead checked in resourceRestAPICustomizeDiff
+*/
+func validateDataObject(attr string) schema.SchemaValidateFunc {
+	return func(val interface{}, key string) (warns []string, errs []error) {
+		v := val.(string)
+		if v != "" {
+			_, err := parseDataValue(v, make(map[string]interface{}))
+			if err != nil {
+				if _, yamlErr := yamlToJSON(v); yamlErr != nil {
+					errs = append(errs, fmt.Errorf("%s attribute is invalid JSON: %v", attr, err))
+				}
+			}
+		}
+		return warns, errs
+	}
+}
+
+/*
+Suppresses changes to data that only reorder keys, change whitespace
+
+	or format numbers differently, such as between jsonencode() and a
+	heredoc, since the same JSON is sent to the API server either way.
+	Resources without data_format, such as restapi_object_patch, only
+	take JSON.
+*/
+func suppressEquivalentData(k, old, new string, d *schema.ResourceData) bool {
+	if old == "" || new == "" {
+		return old == new
+	}
+	dataFormat, _ := d.Get("data_format").(string)
+	oldValue, okOld := decodeDataAttr(old, dataFormat)
+	newValue, okNew := decodeDataAttr(new, dataFormat)
+	return okOld && okNew && reflect.DeepEqual(oldValue, newValue)
+}
+
+/*
+Like suppressEquivalentData, but also suppresses changes to data of
+
+	an existing object that touch none of the fields in update_triggers,
+	and compares data with the hash that store_data_hash_only keeps.
+*/
+func suppressDataChange(k, old, new string, d *schema.ResourceData) bool {
+	/* With store_data_hash_only, the state only has a hash of data */
+	if isDataHash(old) {
+		return d.Get("store_data_hash_only").(bool) && matchesDataHash(old, new, d.Get("data_format").(string))
+	}
+	if suppressEquivalentData(k, old, new, d) {
+		return true
+	}
+	v, ok := d.GetOk("update_triggers")
+	if !ok || d.Id() == "" || old == "" || new == "" {
+		return false
+	}
+	oldValue, okOld := decodeDataAttr(old, d.Get("data_format").(string))
+	newValue, okNew := decodeDataAttr(new, d.Get("data_format").(string))
+	if !okOld || !okNew || hasTriggeredChange(oldValue, newValue, expandStringList(v.([]interface{}))) {
+		return false
+	}
+	log.Printf("resource_api_object.go: Changes to data touch none of update_triggers. Not updating.\n")
+	return true
+}
+
+/* Replaces data in the state by a salted hash of it if store_data_hash_only is set */
+func storeDataHash(d *schema.ResourceData) {
+	data := d.Get("data").(string)
+	if !d.Get("store_data_hash_only").(bool) || data == "" || isDataHash(data) {
+		return
+	}
+	hash, err := hashData(data, d.Get("data_format").(string))
+	if err != nil {
+		log.Printf("resource_api_object.go: Unable to hash data: %v\n", err)
+		return
+	}
+	d.Set("data", hash)
+	d.Set("data_fields", map[string]interface{}{})
+}
+
+/* Decodes the value of a data attribute, which is YAML if data_format is yaml */
+func decodeDataAttr(in string, dataFormat string) (interface{}, bool) {
+	if dataFormat == "yaml" {
+		var err error
+		if in, err = yamlToJSON(in); err != nil {
+			return nil, false
+		}
+	}
+	var value interface{}
+	if json.Unmarshal([]byte(in), &value) != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+/* Validates that the values of a map attribute are regular expressions */
+func validateRegexpMap(val interface{}, key string) (warns []string, errs []error) {
+	for field, pattern := range val.(map[string]interface{}) {
+		if _, err := regexp.Compile(pattern.(string)); err != nil {
+			errs = append(errs, fmt.Errorf("%s attribute has an invalid regular expression for '%s': %v", key, field, err))
+		}
+	}
+	return warns, errs
+}
+
+/* Validates that an attribute holds a JSON Schema */
+func validateJSONSchemaAttr(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := parseJSONSchema(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%s attribute is invalid: %v", key, err))
+	}
+	return warns, errs
+}
+
+/*
+Checks data against request_schema when planning. Nothing is
+
+	checked while either is unknown, as they are only known during apply.
+*/
+func validateRequestSchema(d *schema.ResourceDiff) error {
+	v, ok := d.GetOk("request_schema")
+	if !ok || !d.NewValueKnown("request_schema") || !d.NewValueKnown("data") {
+		return nil
+	}
+	requestSchema, err := parseJSONSchema(v.(string))
+	if err != nil {
+		return fmt.Errorf("request_schema attribute is invalid: %v", err)
+	}
+
+	data := d.Get("data").(string)
+	if data == "" || isDataHash(data) {
+		return nil
+	}
+	if d.Get("data_format").(string) == "yaml" {
+		if data, err = yamlToJSON(data); err != nil {
+			return fmt.Errorf("data attribute is invalid YAML: %v", err)
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return fmt.Errorf("data attribute is invalid JSON: %v", err)
+	}
+	if violations := validateJSONSchema(requestSchema, value); len(violations) > 0 {
+		return fmt.Errorf("data attribute does not match request_schema:\n  %s", strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+/* Checks that data has the fields in required_data_keys when planning */
+func validateRequiredDataKeys(d *schema.ResourceDiff) error {
+	v, ok := d.GetOk("required_data_keys")
+	if !ok || !d.NewValueKnown("required_data_keys") || !d.NewValueKnown("data") || d.Get("data").(string) == "" {
+		return nil
+	}
+	/* Invalid data is reported by the other checks */
+	value, ok := decodeDataAttr(d.Get("data").(string), d.Get("data_format").(string))
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, path := range expandStringList(v.([]interface{})) {
+		missing = append(missing, missingDataKeys(value, splitIgnorePath(path), "")...)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("data attribute is missing required keys: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+/*
+Sends data to validate_path when planning new objects or changes to
+
+	data, so that the API server can reject it before anything is changed.
+	Nothing is sent while either is unknown, as they are only known during
+	apply.
+*/
+func validateWithAPI(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	v, ok := d.GetOk("validate_path")
+	if !ok || !d.NewValueKnown("validate_path") || !d.NewValueKnown("data") || d.Get("data").(string) == "" {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChange("data") {
+		return nil
+	}
+	client, ok := meta.(*APIClient)
+	if !ok {
+		return nil
+	}
+
+	data := d.Get("data").(string)
+	if d.Get("data_format").(string) == "yaml" {
+		var err error
+		if data, err = yamlToJSON(data); err != nil {
+			return fmt.Errorf("data attribute is invalid YAML: %v", err)
+		}
+	}
+	path, err := expandValidatePath(v.(string), d.Id(), data, d.Get("escape_path_values").(bool), d.Get("debug").(bool))
+	if err != nil {
+		return fmt.Errorf("validate_path attribute is invalid: %v", err)
+	}
+	return sendValidateRequest(ctx, client, d.Get("validate_method").(string), path, data)
+}
+
+/* Fills in the placeholders of validate_path like those of the other paths of the object */
+func expandValidatePath(template string, id string, data string, escape bool, debug bool) (string, error) {
+	dataMap := make(map[string]interface{})
+	if dataPlaceholder.MatchString(template) {
+		if err := json.Unmarshal([]byte(data), &dataMap); err != nil {
+			return "", fmt.Errorf("unable to expand '%s' as data is not a JSON object: %v", template, err)
+		}
+	}
+	path, err := expandDataTemplate(template, dataMap, escape, debug)
+	if err != nil {
+		return "", err
+	}
+	obj := &APIObject{id: id, escapePathValues: escape, debug: debug}
+	return obj.expandTemplate(path, nil, true)
+}
+
+/* Reports a response to a validation request that is not a 2xx as the reason data is invalid */
+func sendValidateRequest(ctx context.Context, client *APIClient, method string, path string, data string) error {
+	log.Printf("resource_api_object.go: Validating data with %s %s\n", method, path)
+	resp, err := client.sendRequestWithResponse(ctx, method, path, data, nil)
+	if err != nil && resp != nil && resp.statusCode != 0 {
+		return fmt.Errorf("data attribute was rejected by %s %s with status code %d: %s", method, path, resp.statusCode, truncateBody(resp.body))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to validate data with %s %s: %v", method, path, err)
+	}
+	return nil
+}
+
+/* The schema of the *_poll blocks that wait for asynchronous operations */
+func pollSchema(attr string) *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"url_header": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The response header holding the URL of the status of the operation. Defaults to `Operation-Location`, or `Location` if that is not set.",
+			},
+			"url_field": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The field of the response holding the URL of the status of the operation, for APIs that do not return it in a header. Takes precedence over `url_header`. To use a nested field, separate the keys with a slash: 'operation/href'",
+			},
+			"status_field": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{attr + ".0.status_field", attr + ".0.status_expression"},
+				Description:  "The field of the status response holding the status of the operation. To use a nested field, separate the keys with a slash: 'properties/provisioningState'",
+			},
+			"status_expression": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateExpression,
+				Description:  "A JMESPath expression that finds the status of the operation in the status response, for conditions that `status_field` cannot express, such as `status.conditions[?type=='Ready'].status | [0]`. Supports the same syntax as `id_expression`.",
+			},
+			"success_values": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Required:    true,
+				Description: "The values of `status_field` or `status_expression` that mean the operation succeeded.",
+			},
+			"failure_values": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "The values of `status_field` or `status_expression` that mean the operation failed.",
+			},
+			"interval": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     5,
+				Description: "The number of seconds to wait between polls.",
+			},
+			"timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     600,
+				Description: "The number of seconds to wait for the operation to finish.",
+			},
+		},
+	}
+}
+
+func expandPoll(configured []interface{}) *pollOpts {
+	if len(configured) == 0 || configured[0] == nil {
+		return nil
+	}
+	p := configured[0].(map[string]interface{})
+	return &pollOpts{
+		urlHeader:     p["url_header"].(string),
+		urlField:      p["url_field"].(string),
+		statusField:   p["status_field"].(string),
+		statusExpr:    p["status_expression"].(string),
+		successValues: expandStringList(p["success_values"].([]interface{})),
+		failureValues: expandStringList(p["failure_values"].([]interface{})),
+		interval:      time.Duration(p["interval"].(int)) * time.Second,
+		timeout:       time.Duration(p["timeout"].(int)) * time.Second,
+	}
+}
+
+/* Returns a boolean attribute only if it is set in the configuration, so an override of a provider setting can tell unset from false */
+func getConfiguredBool(d *schema.ResourceData, key string) *bool {
+	if !isConfigured(d, key) {
+		return nil
+	}
+	b := d.GetRawConfig().GetAttr(key).True()
+	return &b
+}
+
+/* Whether an attribute is set in the configuration, even to false or an empty list, unlike GetOk */
+func isConfigured(d *schema.ResourceData, key string) bool {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return false
+	}
+	v := config.GetAttr(key)
+	return !v.IsNull() && v.IsKnown()
+}
+
 func expandReadSearch(v map[string]interface{}) (readSearch map[string]string) {
 	readSearch = make(map[string]string)
 	for key, val := range v {
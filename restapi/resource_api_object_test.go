@@ -15,11 +15,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Mastercard/terraform-provider-restapi/fakeserver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // example.Widget represents a concrete Go type that represents an API resource
@@ -57,15 +64,15 @@ func TestAccRestApiObject_Basic(t *testing.T) {
 				Config: generateTestResource(
 					"Foo",
 					`{ "id": "1234", "first": "Foo", "last": "Bar" }`,
-					make(map[string]interface{}),
+					map[string]interface{}{"keep_create_response": true},
 				),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckRestapiObjectExists(ctx, "restapi_object.Foo", "1234", client),
 					resource.TestCheckResourceAttr("restapi_object.Foo", "id", "1234"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.first", "Foo"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.last", "Bar"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "api_response", "{\"first\":\"Foo\",\"id\":\"1234\",\"last\":\"Bar\"}"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "create_response", "{\"first\":\"Foo\",\"id\":\"1234\",\"last\":\"Bar\"}"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.first", "Foo"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.last", "Bar"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "api_response", "{\"first\":\"Foo\",\"id\":\"1234\",\"last\":\"Bar\"}"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "create_response", "{\"first\":\"Foo\",\"id\":\"1234\",\"last\":\"Bar\"}"),
 				),
 			},
 			/* Try updating the object and check create_response is unmodified */
@@ -73,15 +80,15 @@ func TestAccRestApiObject_Basic(t *testing.T) {
 				Config: generateTestResource(
 					"Foo",
 					`{ "id": "1234", "first": "Updated", "last": "Value" }`,
-					make(map[string]interface{}),
+					map[string]interface{}{"keep_create_response": true},
 				),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckRestapiObjectExists(ctx, "restapi_object.Foo", "1234", client),
 					resource.TestCheckResourceAttr("restapi_object.Foo", "id", "1234"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.first", "Updated"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.last", "Value"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "api_response", "{\"first\":\"Updated\",\"id\":\"1234\",\"last\":\"Value\"}"),
-					//resource.TestCheckResourceAttr("restapi_object.Foo", "create_response", "{\"first\":\"Foo\",\"id\":\"1234\",\"last\":\"Bar\"}"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.first", "Updated"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "api_data.last", "Value"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "api_response", "{\"first\":\"Updated\",\"id\":\"1234\",\"last\":\"Value\"}"),
+					resource.TestCheckResourceAttr("restapi_object.Foo", "create_response", "{\"first\":\"Foo\",\"id\":\"1234\",\"last\":\"Bar\"}"),
 				),
 			},
 			/* Make a complex object with id_attribute as a child of another key
@@ -99,7 +106,7 @@ func TestAccRestApiObject_Basic(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckRestapiObjectExists(ctx, "restapi_object.Bar", "4321", client),
 					resource.TestCheckResourceAttr("restapi_object.Bar", "id", "4321"),
-					//resource.TestCheckResourceAttrSet("restapi_object.Bar", "api_data.config"),
+					resource.TestCheckResourceAttrSet("restapi_object.Bar", "api_data.config"),
 				),
 			},
 		},
@@ -135,3 +142,585 @@ resource "restapi_object" "%s" {
 }
 `, name, strConfig)
 }
+
+func TestDestroyResponseDiagnostic(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{ "error": "object has children", "children": "` + strings.Repeat("x", 1000) + `" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	r := resourceRestAPI()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"path": "/api/objects",
+		"data": `{ "id": "1" }`,
+	})
+	d.SetId("1")
+
+	diags := r.DeleteContext(context.Background(), d, client)
+	if len(diags) != 1 || diags[0].Summary != "failed to destroy '1' as the API server responded with status code 409" {
+		t.Fatalf("resource_api_object_test.go: Expected the destroy to fail with its status code but got: %v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "object has children") || !strings.Contains(diags[0].Detail, "more bytes)") {
+		t.Fatalf("resource_api_object_test.go: Expected the truncated response in the detail but got: %s", diags[0].Detail)
+	}
+}
+
+func TestDriftMode(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1", "name": "changed" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	expectedData := map[string]string{
+		"rewrite": `{"id":"1","name":"changed"}`,
+		"report":  `{ "id": "1", "name": "foo" }`,
+	}
+	for mode, expected := range expectedData {
+		d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+			"path":       "/api/objects",
+			"data":       `{ "id": "1", "name": "foo" }`,
+			"drift_mode": mode,
+		})
+		d.SetId("1")
+
+		if err := resourceRestAPIRead(context.Background(), d, client); err != nil {
+			t.Fatalf("resource_api_object_test.go: Failed to read the object in %s mode: %v", mode, err)
+		}
+		if v := d.Get("data"); v != expected {
+			t.Fatalf("resource_api_object_test.go: Expected data to be '%s' in %s mode but got '%v'", expected, mode, v)
+		}
+		drift := d.Get("drift").([]interface{})
+		expectedDrift := []interface{}{map[string]interface{}{"path": "name", "old_value": `"foo"`, "new_value": `"changed"`}}
+		if !reflect.DeepEqual(expectedDrift, drift) {
+			t.Fatalf("resource_api_object_test.go: Expected drift to be %v in %s mode but got %v", expectedDrift, mode, drift)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/validate", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || !strings.Contains(string(body), `"name"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{ "message": "name is required" }`))
+			return
+		}
+		w.Write([]byte(`{ "valid": true }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	ctx := context.Background()
+	if err := sendValidateRequest(ctx, client, "POST", "/api/objects/validate", `{ "name": "foo" }`); err != nil {
+		t.Fatalf("resource_api_object_test.go: Expected valid data to pass but got: %v", err)
+	}
+	err := sendValidateRequest(ctx, client, "POST", "/api/objects/validate", `{ "nmae": "foo" }`)
+	if err == nil || !strings.Contains(err.Error(), "status code 400") || !strings.Contains(err.Error(), "name is required") {
+		t.Fatalf("resource_api_object_test.go: Expected invalid data to be rejected with the response but got: %v", err)
+	}
+}
+
+func TestExpandValidatePath(t *testing.T) {
+	data := `{ "tenant": "a b/c", "name": "web" }`
+	tests := []struct {
+		template string
+		id       string
+		escape   bool
+		expected string
+	}{
+		{"/tenants/{data.tenant}/objects/{id}/validate", "x/1", false, "/tenants/a b/c/objects/x/1/validate"},
+		{"/tenants/{data.tenant}/objects/{id}/validate", "x/1", true, "/tenants/a%20b%2Fc/objects/x%2F1/validate"},
+		{"/objects/validate?name={data.name}", "", true, "/objects/validate?name=web"},
+	}
+	for _, test := range tests {
+		path, err := expandValidatePath(test.template, test.id, data, test.escape, false)
+		if err != nil || path != test.expected {
+			t.Fatalf("resource_api_object_test.go: Expected '%s' to give '%s' but got '%s' (%v)", test.template, test.expected, path, err)
+		}
+	}
+	for _, template := range []string{"/tenants/{data.missing}/validate", "/objects/{response.id}/validate"} {
+		if path, err := expandValidatePath(template, "1", data, false, false); err == nil {
+			t.Fatalf("resource_api_object_test.go: Expected '%s' to fail but got '%s'", template, path)
+		}
+	}
+}
+
+func TestPathChangeReadsObject(t *testing.T) {
+	var updates int
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/v2/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			updates++
+		}
+		w.Write([]byte(`{ "id": "1", "name": "foo" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	ctx := context.Background()
+	r := resourceRestAPI()
+	for _, path := range []string{"/v2/objects", "/v3/objects"} {
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"path": "/v1/objects",
+			"data": `{ "id": "1", "name": "foo" }`,
+		})
+		d.SetId("1")
+		d.Set("data_fields", dataFields(d.Get("data").(string), "json"))
+
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"path": path,
+			"data": `{ "id": "1", "name": "foo" }`,
+		})
+		diff, err := r.SimpleDiff(ctx, d.State(), config, client)
+		if err != nil {
+			t.Fatalf("resource_api_object_test.go: Failed to plan the change of path: %v", err)
+		}
+		if diff.RequiresNew() {
+			t.Fatalf("resource_api_object_test.go: Expected a change of path not to replace the object")
+		}
+
+		state, diags := r.Apply(ctx, d.State(), diff, client)
+		if path == "/v3/objects" {
+			if !diags.HasError() {
+				t.Fatalf("resource_api_object_test.go: Expected an error when the object is not found at its new path")
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Fatalf("resource_api_object_test.go: Failed to apply the change of path: %v", diags)
+		}
+		if state.Attributes["path"] != path || updates != 0 {
+			t.Fatalf("resource_api_object_test.go: Expected the object to be read at '%s' without updating it, but got path '%s' and %d updates", path, state.Attributes["path"], updates)
+		}
+	}
+}
+
+func TestCreateKeepsIDWhenReadFails(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1" }`))
+	})
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+		"path": "/api/objects",
+		"data": `{ "id": "1" }`,
+	})
+	err := resourceRestAPICreate(context.Background(), d, client)
+	if err == nil || !strings.Contains(err.Error(), "tainted") {
+		t.Fatalf("resource_api_object_test.go: Expected the create to fail as the object cannot be read back but got: %v", err)
+	}
+	if d.Id() != "1" {
+		t.Fatalf("resource_api_object_test.go: Expected the id of the created object to be kept but got '%s'", d.Id())
+	}
+}
+
+func TestReadFailureBehavior(t *testing.T) {
+	failures := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{ "id": "1", "name": "foo" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	read := func(behavior string) (*schema.ResourceData, diag.Diagnostics) {
+		d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+			"path":                  "/api/objects",
+			"data":                  `{ "id": "1", "name": "foo" }`,
+			"read_failure_behavior": behavior,
+			"read_retries":          2,
+		})
+		d.SetId("1")
+		return d, diagFromErr(resourceRestAPIRead(context.Background(), d, client))
+	}
+
+	failures = 2
+	if _, diags := read("retry"); diags.HasError() {
+		t.Fatalf("resource_api_object_test.go: Expected the read to succeed after retries but got: %v", diags)
+	}
+
+	failures = 1
+	if _, diags := read("fail"); !diags.HasError() {
+		t.Fatalf("resource_api_object_test.go: Expected the read to fail")
+	}
+
+	failures = 1
+	d, diags := read("keep_state")
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("resource_api_object_test.go: Expected the read to warn and keep the state but got: %v", diags)
+	}
+	if d.Id() != "1" {
+		t.Fatalf("resource_api_object_test.go: Expected the object to be kept in the state")
+	}
+}
+
+func TestReplaceOnUpdateStatusCodes(t *testing.T) {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.Write([]byte(`{ "id": "1", "name": "foo" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	ctx := context.Background()
+	r := resourceRestAPI()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"path":                           "/api/objects",
+		"data":                           `{ "id": "1", "name": "foo" }`,
+		"replace_on_update_status_codes": []interface{}{409, 422},
+	})
+	d.SetId("1")
+	d.Set("data_fields", dataFields(d.Get("data").(string), "json"))
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"path":                           "/api/objects",
+		"data":                           `{ "id": "1", "name": "bar" }`,
+		"replace_on_update_status_codes": []interface{}{409, 422},
+	})
+	diff, err := r.SimpleDiff(ctx, d.State(), config, client)
+	if err != nil {
+		t.Fatalf("resource_api_object_test.go: Failed to plan the update: %v", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatalf("resource_api_object_test.go: Expected the object to be updated before any update was rejected")
+	}
+
+	state, diags := r.Apply(ctx, d.State(), diff, client)
+	if !diags.HasError() {
+		t.Fatalf("resource_api_object_test.go: Expected the rejected update to fail")
+	}
+	if state.Attributes["update_rejected"] != "true" || state.Attributes["data"] != `{ "id": "1", "name": "foo" }` {
+		t.Fatalf("resource_api_object_test.go: Expected the rejected update to be recorded with the previous data, but got %v", state.Attributes)
+	}
+
+	diff, err = r.SimpleDiff(ctx, state, config, client)
+	if err != nil {
+		t.Fatalf("resource_api_object_test.go: Failed to plan the replacement: %v", err)
+	}
+	if !diff.RequiresNew() {
+		t.Fatalf("resource_api_object_test.go: Expected the object to be replaced after its update was rejected")
+	}
+}
+
+func TestSuppressEquivalentData(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{})
+	equivalent := [][2]string{
+		{`{"a": 1, "b": [1, 2]}`, "{\n  \"b\": [1, 2],\n  \"a\": 1.0\n}"},
+		{`{"n": 100}`, `{"n": 1e2}`},
+		{"", ""},
+	}
+	for _, pair := range equivalent {
+		if !suppressEquivalentData("data", pair[0], pair[1], d) {
+			t.Fatalf("resource_api_object_test.go: Expected '%s' and '%s' to be equivalent", pair[0], pair[1])
+		}
+	}
+	different := [][2]string{
+		{`{"a": 1}`, `{"a": "1"}`},
+		{`{"b": [1, 2]}`, `{"b": [2, 1]}`},
+		{`{"a": 1}`, ""},
+		{`{"a": 1}`, `not json`},
+	}
+	for _, pair := range different {
+		if suppressEquivalentData("data", pair[0], pair[1], d) {
+			t.Fatalf("resource_api_object_test.go: Expected '%s' and '%s' to be different", pair[0], pair[1])
+		}
+	}
+
+	yaml := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{"data_format": "yaml"})
+	if !suppressEquivalentData("data", "a: 1\nb: x\n", "b: x\na: 1\n", yaml) {
+		t.Fatalf("resource_api_object_test.go: Expected reordered YAML to be equivalent")
+	}
+
+	/* restapi_object_patch has no data_format and only takes JSON */
+	patch := schema.TestResourceDataRaw(t, resourceRestAPIObjectPatch().Schema, map[string]interface{}{})
+	if !suppressEquivalentData("data", `{"a": 1, "b": 2}`, `{ "b": 2, "a": 1 }`, patch) {
+		t.Fatalf("resource_api_object_test.go: Expected reordered JSON to be equivalent without data_format")
+	}
+	if suppressEquivalentData("data", "a: 1\n", `{"a": 1}`, patch) {
+		t.Fatalf("resource_api_object_test.go: Expected YAML not to be taken without data_format")
+	}
+}
+
+func TestUpdateTriggers(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+		"update_triggers": []interface{}{"spec", "rules[*].port"},
+	})
+	old := `{"spec": {"size": 1}, "rules": [{"port": 80, "note": "web"}], "description": "a"}`
+
+	/* Nothing is suppressed before the object is created */
+	if suppressDataChange("data", old, `{"spec": {"size": 1}, "rules": [{"port": 80, "note": "web"}], "description": "b"}`, d) {
+		t.Fatalf("resource_api_object_test.go: Expected changes to be kept for a new object")
+	}
+
+	d.SetId("1")
+	untriggered := []string{
+		`{"spec": {"size": 1}, "rules": [{"port": 80, "note": "web"}], "description": "b"}`,
+		`{"spec": {"size": 1}, "rules": [{"port": 80, "note": "http"}]}`,
+	}
+	for _, new := range untriggered {
+		if !suppressDataChange("data", old, new, d) {
+			t.Fatalf("resource_api_object_test.go: Expected '%s' not to trigger an update", new)
+		}
+	}
+	triggered := []string{
+		`{"spec": {"size": 2}, "rules": [{"port": 80, "note": "web"}], "description": "a"}`,
+		`{"spec": {"size": 1}, "rules": [{"port": 443, "note": "web"}], "description": "a"}`,
+		`{"spec": {"size": 1}, "rules": [{"port": 80, "note": "web"}, {"port": 443}], "description": "a"}`,
+	}
+	for _, new := range triggered {
+		if suppressDataChange("data", old, new, d) {
+			t.Fatalf("resource_api_object_test.go: Expected '%s' to trigger an update", new)
+		}
+	}
+}
+
+func TestStoreDataHashOnly(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+		"path":                 "/api/objects",
+		"data":                 `{ "id": "1", "password": "secret" }`,
+		"store_data_hash_only": true,
+	})
+	d.SetId("1")
+
+	storeDataHash(d)
+	hash := d.Get("data").(string)
+	if !isDataHash(hash) {
+		t.Fatalf("resource_api_object_test.go: Expected a hash of data in the state but got '%s'", hash)
+	}
+	if !suppressDataChange("data", hash, `{"password": "secret", "id": "1"}`, d) {
+		t.Fatalf("resource_api_object_test.go: Expected unchanged data to match its hash")
+	}
+	if suppressDataChange("data", hash, `{ "id": "1", "password": "changed" }`, d) {
+		t.Fatalf("resource_api_object_test.go: Expected changed data not to match its hash")
+	}
+
+	opts, err := buildAPIObjectOpts(d)
+	if err != nil {
+		t.Fatalf("resource_api_object_test.go: Failed to build the options: %v", err)
+	}
+	if isDataHash(opts.data) || isDataHash(opts.previousData) {
+		t.Fatalf("resource_api_object_test.go: Expected the hash not to be used as data")
+	}
+}
+
+func TestServerDataMode(t *testing.T) {
+	for _, mode := range []string{"merge", "separate"} {
+		d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{"server_data_mode": mode})
+		obj := &APIObject{
+			data:    map[string]interface{}{"name": "foo"},
+			apiData: map[string]interface{}{"name": "foo", "id": "1"},
+		}
+
+		apiData := setServerData(obj, d)
+		if server := d.Get("server_data").(string); server != `{"id":"1"}` {
+			t.Fatalf("resource_api_object_test.go: Expected the fields added by the server in server_data but got '%s'", server)
+		}
+		_, hasChanges := getDelta(obj.data, apiData, nil, nil, nil)
+		if hasChanges != (mode == "merge") {
+			t.Fatalf("resource_api_object_test.go: Expected changes to be %t in %s mode", mode == "merge", mode)
+		}
+	}
+}
+
+func TestUpdateCopyKeysKeepsVersion(t *testing.T) {
+	etag := `"v2"`
+	updates := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Method != "GET" {
+			if r.Header.Get("If-Match") != etag {
+				http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+				return
+			}
+			updates++
+		}
+		w.Write([]byte(`{ "id": "1", "name": "changed", "revision": "7" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+		"path":         "/api/objects",
+		"data":         `{ "id": "1", "name": "mine" }`,
+		"copy_keys":    []interface{}{"revision"},
+		"use_if_match": true,
+	})
+	d.SetId("1")
+	/* The object changed on the server since it was planned at v1 */
+	d.Set("version", `"v1"`)
+
+	err := resourceRestAPIUpdate(context.Background(), d, client)
+	if err == nil || !strings.Contains(err.Error(), "changed remotely") || updates != 0 {
+		t.Fatalf("resource_api_object_test.go: Expected the update to fail with a 412 but got: %v", err)
+	}
+}
+
+func TestKeepCreateResponse(t *testing.T) {
+	reads := 0
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/keys", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "id": "1", "name": "ci", "secret": "s3cr3t" }`))
+	})
+	serverMux.HandleFunc("/api/keys/1", func(w http.ResponseWriter, r *http.Request) {
+		reads++
+		w.Write([]byte(`{ "id": "1", "name": "ci" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:                 svr.URL,
+		headers:             make(map[string]string),
+		timeout:             2,
+		rateLimit:           10,
+		createReturnsObject: true,
+	})
+
+	for _, keep := range []bool{false, true} {
+		reads = 0
+		d := schema.TestResourceDataRaw(t, resourceRestAPI().Schema, map[string]interface{}{
+			"path":                 "/api/keys",
+			"data":                 `{ "name": "ci" }`,
+			"keep_create_response": keep,
+		})
+		if err := resourceRestAPICreate(context.Background(), d, client); err != nil {
+			t.Fatalf("resource_api_object_test.go: Failed to create the object: %v", err)
+		}
+
+		if !keep {
+			if d.Get("create_response") != "" || reads != 0 {
+				t.Fatalf("resource_api_object_test.go: Expected the create response not to be kept or read back without keep_create_response")
+			}
+			continue
+		}
+		if d.Get("create_response") != `{ "id": "1", "name": "ci", "secret": "s3cr3t" }` {
+			t.Fatalf("resource_api_object_test.go: Expected the create response to be kept but got '%v'", d.Get("create_response"))
+		}
+		/* Only the sensitive create_response may hold the secret */
+		for _, attr := range []string{"api_response", "api_data_json", "server_data"} {
+			if v := d.Get(attr).(string); reads != 1 || strings.Contains(v, "s3cr3t") {
+				t.Fatalf("resource_api_object_test.go: Expected %s to come from reading the object back but got '%s' after %d reads", attr, v, reads)
+			}
+		}
+	}
+}
+
+func TestDataIsSensitive(t *testing.T) {
+	t.Setenv("API_DATA_IS_SENSITIVE", "true")
+	s := resourceRestAPI().Schema
+	for _, attr := range []string{"data", "data_fields", "drift", "api_data", "api_data_json", "api_response", "outputs", "server_data"} {
+		if !s[attr].Sensitive {
+			t.Fatalf("resource_api_object_test.go: Expected %s to be sensitive with API_DATA_IS_SENSITIVE", attr)
+		}
+	}
+}
+
+func TestSkipDestroy(t *testing.T) {
+	var deletes int
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deletes++
+		}
+		w.Write([]byte(`{ "id": "1" }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	ctx := context.Background()
+	r := resourceRestAPI()
+	for _, skip := range []bool{true, false} {
+		deletes = 0
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"path":         "/api/objects",
+			"data":         `{ "id": "1" }`,
+			"skip_destroy": skip,
+		})
+		d.SetId("1")
+
+		state, diags := r.Apply(ctx, d.State(), &terraform.InstanceDiff{Destroy: true}, client)
+		if diags.HasError() {
+			t.Fatalf("resource_api_object_test.go: Failed to destroy the object with skip_destroy %t: %v", skip, diags)
+		}
+		if state != nil && state.ID != "" {
+			t.Fatalf("resource_api_object_test.go: Expected the object to be removed from state with skip_destroy %t, but got '%s'", skip, state.ID)
+		}
+		if expected := map[bool]int{true: 0, false: 1}[skip]; deletes != expected {
+			t.Fatalf("resource_api_object_test.go: Expected %d DELETE requests with skip_destroy %t, but got %d", expected, skip, deletes)
+		}
+	}
+}
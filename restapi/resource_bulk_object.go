@@ -0,0 +1,284 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceRestAPIBulkObject() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIBulkObjectCreate(ctx, data, i))
+		},
+		ReadContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIBulkObjectRead(ctx, data, i))
+		},
+		UpdateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIBulkObjectUpdate(ctx, data, i))
+		},
+		DeleteContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIBulkObjectDelete(ctx, data, i))
+		},
+
+		Description: "Manages many objects of the same collection on the API server as one resource, which is much faster than a `restapi_object` for each of them. The objects are created, updated and destroyed one by one within the `rate_limit` of the provider, and only the objects that change are sent.",
+
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			if d.HasChange("objects") && d.Id() != "" {
+				if err := d.SetNewComputed("ids"); err != nil {
+					return err
+				}
+				return d.SetNewComputed("api_data_json")
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"objects": {
+				Type:             schema.TypeMap,
+				Elem:             &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringIsJSON},
+				Description:      "The JSON documents of the objects to manage, by a key that stays the same for an object, such as its name. Adding, changing and removing a key creates, updates and destroys only that object. Objects that are gone from the API server are created again.",
+				Required:         true,
+				DiffSuppressFunc: suppressEquivalentData,
+			},
+			"id_attribute": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `id_attribute` set on the provider. Allows per-resource override of `id_attribute` (see `id_attribute` provider config documentation)",
+				Optional:    true,
+			},
+			"query_string": {
+				Type:        schema.TypeString,
+				Description: "Query string to be included in the path",
+				Optional:    true,
+			},
+			"create_method": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `create_method` set on the provider. Allows per-resource override of `create_method` (see `create_method` provider config documentation)",
+				Optional:     true,
+				ValidateFunc: validateHTTPMethod,
+			},
+			"update_method": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `update_method` set on the provider. Allows per-resource override of `update_method` (see `update_method` provider config documentation)",
+				Optional:     true,
+				ValidateFunc: validateHTTPMethod,
+			},
+			"destroy_method": {
+				Type:         schema.TypeString,
+				Description:  "Defaults to `destroy_method` set on the provider. Allows per-resource override of `destroy_method` (see `destroy_method` provider config documentation)",
+				Optional:     true,
+				ValidateFunc: validateHTTPMethod,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"ids": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The ids of the objects on the API server, by their key in `objects`.",
+				Computed:    true,
+			},
+			"api_data_json": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The objects as read from the API server, encoded as JSON, by their key in `objects`. Use `jsondecode()` to access their fields.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+/* Builds the API object of one of the objects */
+func newBulkAPIObject(d *schema.ResourceData, client *APIClient, id string, data string, previousData string) (*APIObject, error) {
+	return NewAPIObject(client, &apiObjectOpts{
+		path:          d.Get("path").(string),
+		queryString:   d.Get("query_string").(string),
+		idAttribute:   d.Get("id_attribute").(string),
+		createMethod:  d.Get("create_method").(string),
+		updateMethod:  d.Get("update_method").(string),
+		destroyMethod: d.Get("destroy_method").(string),
+		debug:         d.Get("debug").(bool),
+		id:            id,
+		data:          data,
+		previousData:  previousData,
+	})
+}
+
+/* The keys of a map of the resource, sorted to work through them in a stable order */
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/*
+Records the objects that were applied so far. If an object fails, the
+
+	objects that did not fail are still tracked, and the rest are tried
+	again by the next apply.
+*/
+func setBulkObjectState(d *schema.ResourceData, objects map[string]interface{}, ids map[string]interface{}, apiData map[string]interface{}) {
+	d.Set("objects", objects)
+	d.Set("ids", ids)
+	d.Set("api_data_json", apiData)
+}
+
+func resourceRestAPIBulkObjectCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	objects := d.Get("objects").(map[string]interface{})
+
+	applied := make(map[string]interface{})
+	ids := make(map[string]interface{})
+	apiData := make(map[string]interface{})
+	d.SetId(d.Get("path").(string))
+	for _, key := range sortedKeys(objects) {
+		obj, err := newBulkAPIObject(d, client, "", objects[key].(string), "")
+		if err == nil {
+			err = obj.createObject(ctx)
+		}
+		if err != nil {
+			setBulkObjectState(d, applied, ids, apiData)
+			return fmt.Errorf("failed to create object '%s': %v", key, err)
+		}
+		applied[key] = objects[key]
+		ids[key] = obj.id
+		apiData[key] = jsonString(dataOrValue(obj.apiData, obj.apiDataValue))
+	}
+
+	log.Printf("resource_bulk_object.go: Created %d objects at '%s'", len(ids), d.Id())
+	setBulkObjectState(d, applied, ids, apiData)
+	return nil
+}
+
+func resourceRestAPIBulkObjectRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	objects := d.Get("objects").(map[string]interface{})
+	ids := d.Get("ids").(map[string]interface{})
+
+	apiData := make(map[string]interface{})
+	for _, key := range sortedKeys(ids) {
+		obj, err := newBulkAPIObject(d, client, ids[key].(string), "", "")
+		if err == nil {
+			err = obj.readObject(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read object '%s': %v", key, err)
+		}
+
+		/* Forget objects that are gone so that they are created again */
+		if obj.id == "" {
+			delete(objects, key)
+			delete(ids, key)
+			continue
+		}
+		apiData[key] = jsonString(dataOrValue(obj.apiData, obj.apiDataValue))
+	}
+
+	setBulkObjectState(d, objects, ids, apiData)
+	return nil
+}
+
+func resourceRestAPIBulkObjectUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	o, n := d.GetChange("objects")
+	oldObjects := o.(map[string]interface{})
+	newObjects := n.(map[string]interface{})
+	o, _ = d.GetChange("ids")
+	ids := o.(map[string]interface{})
+	o, _ = d.GetChange("api_data_json")
+	apiData := o.(map[string]interface{})
+
+	/* The objects as they are on the API server, as far as this update got */
+	applied := make(map[string]interface{})
+	for k, v := range oldObjects {
+		applied[k] = v
+	}
+
+	for _, key := range sortedKeys(oldObjects) {
+		if _, ok := newObjects[key]; ok {
+			continue
+		}
+		obj, err := newBulkAPIObject(d, client, ids[key].(string), "", "")
+		if err == nil {
+			err = obj.deleteObject(ctx)
+		}
+		if err != nil {
+			setBulkObjectState(d, applied, ids, apiData)
+			return fmt.Errorf("failed to destroy object '%s': %v", key, err)
+		}
+		delete(applied, key)
+		delete(ids, key)
+		delete(apiData, key)
+	}
+
+	for _, key := range sortedKeys(newObjects) {
+		data := newObjects[key].(string)
+		previous, exists := oldObjects[key]
+		if exists && suppressEquivalentData(key, previous.(string), data, d) {
+			continue
+		}
+
+		var obj *APIObject
+		var err error
+		if exists {
+			obj, err = newBulkAPIObject(d, client, ids[key].(string), data, previous.(string))
+			if err == nil {
+				err = obj.updateObject(ctx)
+			}
+		} else {
+			obj, err = newBulkAPIObject(d, client, "", data, "")
+			if err == nil {
+				err = obj.createObject(ctx)
+			}
+		}
+		if err != nil {
+			setBulkObjectState(d, applied, ids, apiData)
+			return fmt.Errorf("failed to apply object '%s': %v", key, err)
+		}
+		applied[key] = data
+		ids[key] = obj.id
+		apiData[key] = jsonString(dataOrValue(obj.apiData, obj.apiDataValue))
+	}
+
+	setBulkObjectState(d, applied, ids, apiData)
+	return nil
+}
+
+func resourceRestAPIBulkObjectDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	objects := d.Get("objects").(map[string]interface{})
+	ids := d.Get("ids").(map[string]interface{})
+	apiData := d.Get("api_data_json").(map[string]interface{})
+
+	for _, key := range sortedKeys(ids) {
+		obj, err := newBulkAPIObject(d, client, ids[key].(string), "", "")
+		if err == nil {
+			err = obj.deleteObject(ctx)
+		}
+		if err != nil {
+			setBulkObjectState(d, objects, ids, apiData)
+			return fmt.Errorf("failed to destroy object '%s': %v", key, err)
+		}
+		delete(objects, key)
+		delete(ids, key)
+		delete(apiData, key)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceRestAPIBulkObject(t *testing.T) {
+	var mutex sync.Mutex
+	objects := make(map[string]string)
+	writes := []string{}
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		object := make(map[string]interface{})
+		json.Unmarshal(body, &object)
+		objects[object["id"].(string)] = string(body)
+		writes = append(writes, r.Method+" "+object["id"].(string))
+		w.Write(body)
+	})
+	serverMux.HandleFunc("/api/objects/", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/api/objects/")
+		if _, ok := objects[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			body, _ := io.ReadAll(r.Body)
+			objects[id] = string(body)
+		case "DELETE":
+			delete(objects, id)
+			writes = append(writes, r.Method+" "+id)
+			return
+		}
+		if r.Method != "GET" {
+			writes = append(writes, r.Method+" "+id)
+		}
+		w.Write([]byte(objects[id]))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:                 svr.URL,
+		headers:             make(map[string]string),
+		timeout:             2,
+		rateLimit:           10,
+		idAttribute:         "id",
+		createMethod:        "POST",
+		updateMethod:        "PUT",
+		destroyMethod:       "DELETE",
+		writeReturnsObject:  true,
+		createReturnsObject: true,
+	})
+
+	expectWrites := func(step string, expected []string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !reflect.DeepEqual(writes, expected) {
+			t.Fatalf("resource_bulk_object_test.go: %s: expected the writes %v but got %v", step, expected, writes)
+		}
+		writes = []string{}
+	}
+
+	r := resourceRestAPIBulkObject()
+	config := func(objects map[string]interface{}) *terraform.ResourceConfig {
+		return terraform.NewResourceConfigRaw(map[string]interface{}{
+			"path":    "/api/objects",
+			"objects": objects,
+		})
+	}
+	apply := func(step string, state *terraform.InstanceState, objects map[string]interface{}) *terraform.InstanceState {
+		diff, err := r.Diff(context.Background(), state, config(objects), client)
+		if err != nil {
+			t.Fatalf("resource_bulk_object_test.go: %s: failed to diff: %v", step, err)
+		}
+		state, diags := r.Apply(context.Background(), state, diff, client)
+		if diags.HasError() {
+			t.Fatalf("resource_bulk_object_test.go: %s: failed to apply: %v", step, diags)
+		}
+		return state
+	}
+
+	state := apply("create", nil, map[string]interface{}{
+		"a": `{ "id": "1", "name": "a" }`,
+		"b": `{ "id": "2", "name": "b" }`,
+		"c": `{ "id": "3", "name": "c" }`,
+	})
+	expectWrites("create", []string{"POST 1", "POST 2", "POST 3"})
+	if id := state.Attributes["ids.b"]; id != "2" {
+		t.Fatalf("resource_bulk_object_test.go: Expected the id of 'b' to be '2' but got '%s'", id)
+	}
+
+	/* Only the objects that changed are sent */
+	state = apply("update", state, map[string]interface{}{
+		"a": `{"id":"1","name":"a"}`,
+		"c": `{ "id": "3", "name": "C" }`,
+		"d": `{ "id": "4", "name": "d" }`,
+	})
+	expectWrites("update", []string{"DELETE 2", "PUT 3", "POST 4"})
+
+	/* Objects deleted behind our back are created again */
+	mutex.Lock()
+	delete(objects, "1")
+	mutex.Unlock()
+	d := r.Data(state)
+	if diags := r.ReadContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_bulk_object_test.go: Failed to read: %v", diags)
+	}
+	if _, ok := d.Get("objects").(map[string]interface{})["a"]; ok {
+		t.Fatalf("resource_bulk_object_test.go: Expected 'a' to be dropped from the state as it was deleted")
+	}
+
+	if diags := r.DeleteContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_bulk_object_test.go: Failed to delete: %v", diags)
+	}
+	expectWrites("delete", []string{"DELETE 3", "DELETE 4"})
+	if len(objects) != 0 {
+		t.Fatalf("resource_bulk_object_test.go: Expected all objects to be deleted but %v are left", objects)
+	}
+}
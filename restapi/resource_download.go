@@ -0,0 +1,176 @@
+package restapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceRestAPIDownload() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIDownloadCreate(ctx, data, i))
+		},
+		ReadContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIDownloadRead(ctx, data, i))
+		},
+		/* Only check_remote and debug can change, which take effect on the next refresh */
+		UpdateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return nil
+		},
+		DeleteContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIDownloadDelete(ctx, data, i))
+		},
+
+		Description: "Downloads content from the API server, which may be binary, to a local file, such as a certificate bundle generated for an object. The file is downloaded again if it is changed or removed locally, or if the content on the API server changes.",
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider to download the content from.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The headers to send with the request, on top of and taking precedence over the headers set in the provider, such as an `Accept` header.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"output_path": {
+				Type:        schema.TypeString,
+				Description: "The path of the local file to write the content to. Missing directories are created.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"file_permission": {
+				Type:         schema.TypeString,
+				Description:  "The permissions of the file, in octal. Default: 0644",
+				Optional:     true,
+				Default:      "0644",
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(octalPermission, "must be an octal file mode such as 0600"),
+			},
+			"check_remote": {
+				Type:        schema.TypeBool,
+				Description: "Whether to download the content on every refresh to find out if it changed on the API server. If false, only the local file is checked. Default: true",
+				Optional:    true,
+				Default:     true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"content_sha256": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 checksum of the content, in hex.",
+				Computed:    true,
+			},
+			"content_type": {
+				Type:        schema.TypeString,
+				Description: "The `Content-Type` header of the response.",
+				Computed:    true,
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Description: "The size of the content in bytes.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+var octalPermission = regexp.MustCompile("^0?[0-7]{3}$")
+
+func sha256Hex(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+/* Downloads the content of the resource */
+func downloadContent(ctx context.Context, d *schema.ResourceData, client *APIClient) (*apiClientResponse, error) {
+	path := d.Get("path").(string)
+	resp, err := client.sendRequestWithResponse(ctx, "GET", path, "", expandHeaders(d.Get("headers").(map[string]interface{})))
+	if err != nil {
+		return resp, fmt.Errorf("failed to download '%s': %v", path, err)
+	}
+	if d.Get("debug").(bool) {
+		log.Printf("resource_download.go: Downloaded %d bytes from '%s'", len(resp.body), path)
+	}
+	return resp, nil
+}
+
+func resourceRestAPIDownloadCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	outputPath := d.Get("output_path").(string)
+
+	resp, err := downloadContent(ctx, d, client)
+	if err != nil {
+		return err
+	}
+
+	mode, _ := strconv.ParseUint(d.Get("file_permission").(string), 8, 32)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, []byte(resp.body), os.FileMode(mode)); err != nil {
+		return fmt.Errorf("failed to write '%s': %v", outputPath, err)
+	}
+
+	checksum := sha256Hex(resp.body)
+	d.SetId(checksum)
+	d.Set("content_sha256", checksum)
+	d.Set("content_type", resp.headers.Get("Content-Type"))
+	d.Set("size", len(resp.body))
+	return nil
+}
+
+func resourceRestAPIDownloadRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	outputPath := d.Get("output_path").(string)
+	checksum := d.Get("content_sha256").(string)
+
+	/* A file that was changed or removed is downloaded again */
+	content, err := os.ReadFile(outputPath)
+	if err != nil || sha256Hex(string(content)) != checksum {
+		log.Printf("resource_download.go: '%s' was changed or removed. Removing from state to download it again.", outputPath)
+		d.SetId("")
+		return nil
+	}
+
+	if !d.Get("check_remote").(bool) {
+		return nil
+	}
+	resp, err := downloadContent(ctx, d, meta.(*APIClient))
+	if resp.statusCode == 404 {
+		log.Printf("resource_download.go: '%s' is gone from the API server. Removing from state.", d.Get("path").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if sha256Hex(resp.body) != checksum {
+		log.Printf("resource_download.go: The content of '%s' changed on the API server. Removing from state to download it again.", d.Get("path").(string))
+		d.SetId("")
+	}
+	return nil
+}
+
+func resourceRestAPIDownloadDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	outputPath := d.Get("output_path").(string)
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceRestAPIDownload(t *testing.T) {
+	var mutex sync.Mutex
+	content := "bundle v1"
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1/bundle", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		w.Write([]byte(content))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	outputPath := filepath.Join(t.TempDir(), "certs", "bundle.pem")
+	r := resourceRestAPIDownload()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"path":            "/api/objects/1/bundle",
+		"output_path":     outputPath,
+		"file_permission": "0600",
+	})
+	if diags := r.CreateContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_download_test.go: Failed to download: %v", diags)
+	}
+	info, err := os.Stat(outputPath)
+	if err != nil || info.Mode().Perm() != 0600 || info.Size() != int64(len("bundle v1")) {
+		t.Fatalf("resource_download_test.go: Unexpected file after the download: %v %v", info, err)
+	}
+
+	read := func() bool {
+		if diags := r.ReadContext(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("resource_download_test.go: Failed to read: %v", diags)
+		}
+		return d.Id() != ""
+	}
+	if !read() {
+		t.Fatalf("resource_download_test.go: Expected an unchanged download to be kept")
+	}
+
+	/* New content on the API server is downloaded again */
+	mutex.Lock()
+	content = "bundle v2"
+	mutex.Unlock()
+	if read() {
+		t.Fatalf("resource_download_test.go: Expected changed content to be downloaded again")
+	}
+
+	/* So is a file that was changed locally */
+	if diags := r.CreateContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_download_test.go: Failed to download: %v", diags)
+	}
+	os.WriteFile(outputPath, []byte("tampered"), 0600)
+	if read() {
+		t.Fatalf("resource_download_test.go: Expected a changed file to be downloaded again")
+	}
+
+	if diags := r.DeleteContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_download_test.go: Failed to delete: %v", diags)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Fatalf("resource_download_test.go: Expected the file to be removed")
+	}
+}
@@ -0,0 +1,263 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceRestAPIGraphQL() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIGraphQLCreate(ctx, data, i))
+		},
+		ReadContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIGraphQLRead(ctx, data, i))
+		},
+		UpdateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIGraphQLUpdate(ctx, data, i))
+		},
+		DeleteContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIGraphQLDelete(ctx, data, i))
+		},
+
+		Description: "Manages an object on a GraphQL API using a mutation to create it and optional queries to read, update and delete it.",
+
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			/* Without an update_query, the only way to apply changes is to start over */
+			if d.Get("update_query").(string) == "" {
+				for _, attr := range []string{"query", "variables"} {
+					if d.HasChange(attr) && d.Id() != "" {
+						if err := d.ForceNew(attr); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The path of the GraphQL endpoint on top of the base URL set in the provider.",
+				Optional:    true,
+				Default:     "/graphql",
+			},
+			"query": {
+				Type:        schema.TypeString,
+				Description: "The GraphQL mutation used to create the object.",
+				Required:    true,
+			},
+			"variables": {
+				Type:         schema.TypeString,
+				Description:  "A JSON object of variables to send along with `query` (and `update_query`).",
+				Optional:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"id_path": {
+				Type:        schema.TypeString,
+				Description: "The '/'-delimited path to the id of the object in the response to `query`, such as `data/createUser/user/id`.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"read_query": {
+				Type:        schema.TypeString,
+				Description: "The GraphQL query used to read the object. The id of the object is passed to it as the variable `id`. If not set, the object is not refreshed.",
+				Optional:    true,
+			},
+			"read_result_path": {
+				Type:        schema.TypeString,
+				Description: "The '/'-delimited path to the object in the response to `read_query`, such as `data/user`. If the value found there is null or its last key is missing, the object is considered deleted. Any other failure to find it is an error.",
+				Optional:    true,
+			},
+			"update_query": {
+				Type:        schema.TypeString,
+				Description: "The GraphQL mutation used to update the object. It receives `variables` as well as the id of the object as the variable `id`. If not set, changes to `query` or `variables` recreate the object.",
+				Optional:    true,
+			},
+			"delete_query": {
+				Type:        schema.TypeString,
+				Description: "The GraphQL mutation used to delete the object. The id of the object is passed to it as the variable `id`. If not set, the object is only removed from the state.",
+				Optional:    true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"api_response": {
+				Type:        schema.TypeString,
+				Description: "The raw body of the HTTP response from the last create or read of the object.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+/*
+Sends a GraphQL operation and returns the decoded response. GraphQL
+
+	servers generally answer with 200 OK even if the operation failed,
+	so the errors member of the response is checked as well.
+*/
+func sendGraphQLRequest(ctx context.Context, client *APIClient, path string, query string, variables map[string]interface{}, debug bool) (string, map[string]interface{}, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if debug {
+		log.Printf("resource_graphql.go: Sending GraphQL request to '%s': %s", path, string(body))
+	}
+
+	resultString, err := client.sendRequest(ctx, "POST", path, string(body))
+	if err != nil {
+		return resultString, nil, err
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(resultString), &result); err != nil {
+		return resultString, nil, fmt.Errorf("resource_graphql.go: unable to parse GraphQL response: %v", err)
+	}
+
+	if errs, ok := result["errors"].([]interface{}); ok && len(errs) > 0 {
+		return resultString, result, fmt.Errorf("GraphQL request returned errors: %s", resultString)
+	}
+
+	return resultString, result, nil
+}
+
+/*
+Finds the object at read_result_path in the response to read_query.
+
+	Only a null or a missing last key means the object is gone. Any
+	other problem, such as a wrong path, is an error so that a mistake
+	does not silently remove the object from the state.
+*/
+func graphQLReadResult(result map[string]interface{}, path string, debug bool) (interface{}, error) {
+	path = strings.Trim(path, "/")
+	parent := result
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		obj, err := GetObjectAtKey(result, path[:i], debug)
+		if err != nil {
+			return nil, fmt.Errorf("resource_graphql.go: unable to find read_result_path '%s' in the response: %v", path, err)
+		}
+		hash, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("resource_graphql.go: the value at '%s' of read_result_path is not an object but %T", path[:i], obj)
+		}
+		parent, path = hash, path[i+1:]
+	}
+	return parent[path], nil
+}
+
+/* Builds the variables for an operation, optionally including the id */
+func graphQLVariables(d *schema.ResourceData, withID bool) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+	if v := d.Get("variables").(string); v != "" {
+		if err := json.Unmarshal([]byte(v), &variables); err != nil {
+			return nil, fmt.Errorf("variables attribute is invalid JSON: %v", err)
+		}
+	}
+	if withID {
+		variables["id"] = d.Id()
+	}
+	return variables, nil
+}
+
+func resourceRestAPIGraphQLCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	debug := d.Get("debug").(bool)
+
+	variables, err := graphQLVariables(d, false)
+	if err != nil {
+		return err
+	}
+
+	resultString, result, err := sendGraphQLRequest(ctx, client, d.Get("path").(string), d.Get("query").(string), variables, debug)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetStringAtKey(result, d.Get("id_path").(string), debug)
+	if err != nil {
+		return fmt.Errorf("resource_graphql.go: unable to find the id of the created object: %v", err)
+	}
+
+	d.SetId(id)
+	d.Set("api_response", resultString)
+
+	return resourceRestAPIGraphQLRead(ctx, d, meta)
+}
+
+func resourceRestAPIGraphQLRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	readQuery := d.Get("read_query").(string)
+	if readQuery == "" {
+		return nil
+	}
+
+	client := meta.(*APIClient)
+	debug := d.Get("debug").(bool)
+
+	resultString, result, err := sendGraphQLRequest(ctx, client, d.Get("path").(string), readQuery, map[string]interface{}{"id": d.Id()}, debug)
+	if err != nil {
+		return err
+	}
+
+	if resultPath := d.Get("read_result_path").(string); resultPath != "" {
+		obj, err := graphQLReadResult(result, resultPath, debug)
+		if err != nil {
+			return err
+		}
+		if obj == nil {
+			log.Printf("resource_graphql.go: Object '%s' not found at '%s'. Removing from state.", d.Id(), resultPath)
+			d.SetId("")
+			return nil
+		}
+	}
+
+	d.Set("api_response", resultString)
+	return nil
+}
+
+func resourceRestAPIGraphQLUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	updateQuery := d.Get("update_query").(string)
+	if updateQuery == "" {
+		return resourceRestAPIGraphQLRead(ctx, d, meta)
+	}
+
+	client := meta.(*APIClient)
+	variables, err := graphQLVariables(d, true)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := sendGraphQLRequest(ctx, client, d.Get("path").(string), updateQuery, variables, d.Get("debug").(bool)); err != nil {
+		return err
+	}
+
+	return resourceRestAPIGraphQLRead(ctx, d, meta)
+}
+
+func resourceRestAPIGraphQLDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	deleteQuery := d.Get("delete_query").(string)
+	if deleteQuery == "" {
+		log.Printf("resource_graphql.go: No delete_query set. Only removing '%s' from state.", d.Id())
+		return nil
+	}
+
+	client := meta.(*APIClient)
+	_, _, err := sendGraphQLRequest(ctx, client, d.Get("path").(string), deleteQuery, map[string]interface{}{"id": d.Id()}, d.Get("debug").(bool))
+	return err
+}
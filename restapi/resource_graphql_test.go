@@ -0,0 +1,145 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestSendGraphQLRequest(t *testing.T) {
+	ctx := context.Background()
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		req := make(map[string]interface{})
+		json.Unmarshal(b, &req)
+
+		if strings.Contains(req["query"].(string), "broken") {
+			w.Write([]byte(`{ "data": null, "errors": [{ "message": "Cannot query field 'broken'" }] }`))
+			return
+		}
+		variables := req["variables"].(map[string]interface{})
+		w.Write([]byte(`{ "data": { "createUser": { "user": { "id": "42", "name": "` + variables["name"].(string) + `" } } } }`))
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	_, result, err := sendGraphQLRequest(ctx, client, "/graphql", "mutation($name: String!) { createUser(name: $name) { user { id } } }", map[string]interface{}{"name": "foo"}, false)
+	if err != nil {
+		t.Fatalf("resource_graphql_test.go: GraphQL request failed: %s", err)
+	}
+	id, err := GetStringAtKey(result, "data/createUser/user/id", false)
+	if err != nil || id != "42" {
+		t.Fatalf("resource_graphql_test.go: Expected id '42' but got '%s' (%v)", id, err)
+	}
+
+	if _, _, err = sendGraphQLRequest(ctx, client, "/graphql", "query { broken }", nil, false); err == nil {
+		t.Fatalf("resource_graphql_test.go: Expected errors in a GraphQL response to fail the request")
+	}
+}
+
+func TestResourceGraphQLLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	users := map[string]string{}
+	failReads := false
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		req := make(map[string]interface{})
+		json.Unmarshal(b, &req)
+		query := req["query"].(string)
+		variables, _ := req["variables"].(map[string]interface{})
+
+		switch {
+		case strings.Contains(query, "createUser"):
+			users["42"] = variables["name"].(string)
+			w.Write([]byte(`{ "data": { "createUser": { "user": { "id": "42" } } } }`))
+		case strings.Contains(query, "deleteUser"):
+			delete(users, variables["id"].(string))
+			w.Write([]byte(`{ "data": { "deleteUser": { "id": "42" } } }`))
+		case failReads:
+			w.Write([]byte(`{ "data": null, "errors": [{ "message": "internal error" }] }`))
+		default:
+			name, ok := users[variables["id"].(string)]
+			if !ok {
+				w.Write([]byte(`{ "data": { "user": null } }`))
+				return
+			}
+			w.Write([]byte(`{ "data": { "user": { "id": "42", "name": "` + name + `" } } }`))
+		}
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	config := map[string]interface{}{
+		"path":             "/graphql",
+		"query":            "mutation($name: String!) { createUser(name: $name) { user { id } } }",
+		"variables":        `{ "name": "foo" }`,
+		"id_path":          "data/createUser/user/id",
+		"read_query":       "query($id: ID!) { user(id: $id) { id name } }",
+		"read_result_path": "data/user",
+		"delete_query":     "mutation($id: ID!) { deleteUser(id: $id) { id } }",
+	}
+	d := schema.TestResourceDataRaw(t, resourceRestAPIGraphQL().Schema, config)
+
+	if err := resourceRestAPIGraphQLCreate(ctx, d, client); err != nil {
+		t.Fatalf("resource_graphql_test.go: Create failed: %s", err)
+	}
+	if d.Id() != "42" || !strings.Contains(d.Get("api_response").(string), `"name": "foo"`) {
+		t.Fatalf("resource_graphql_test.go: Expected the created user to be read back, but got id '%s' and '%s'", d.Id(), d.Get("api_response"))
+	}
+
+	/* Errors and wrong paths must not be mistaken for a deleted object */
+	failReads = true
+	if err := resourceRestAPIGraphQLRead(ctx, d, client); err == nil || d.Id() != "42" {
+		t.Fatalf("resource_graphql_test.go: Expected GraphQL errors to fail the read and keep the object, but got '%v' and id '%s'", err, d.Id())
+	}
+	failReads = false
+	d.Set("read_result_path", "data/usr/node")
+	if err := resourceRestAPIGraphQLRead(ctx, d, client); err == nil || d.Id() != "42" {
+		t.Fatalf("resource_graphql_test.go: Expected a wrong read_result_path to fail the read and keep the object, but got '%v' and id '%s'", err, d.Id())
+	}
+	d.Set("read_result_path", "data/user/name/first")
+	if err := resourceRestAPIGraphQLRead(ctx, d, client); err == nil || d.Id() != "42" {
+		t.Fatalf("resource_graphql_test.go: Expected a path through a string to fail the read and keep the object, but got '%v' and id '%s'", err, d.Id())
+	}
+
+	/* A missing last key means the object is gone */
+	d.Set("read_result_path", "data/account")
+	if err := resourceRestAPIGraphQLRead(ctx, d, client); err != nil || d.Id() != "" {
+		t.Fatalf("resource_graphql_test.go: Expected a missing object to be removed from state, but got '%v' and id '%s'", err, d.Id())
+	}
+	d.SetId("42")
+	d.Set("read_result_path", "data/user")
+
+	if err := resourceRestAPIGraphQLDelete(ctx, d, client); err != nil {
+		t.Fatalf("resource_graphql_test.go: Delete failed: %s", err)
+	}
+	if len(users) != 0 {
+		t.Fatalf("resource_graphql_test.go: Expected delete_query to delete the user, but got %v", users)
+	}
+
+	/* An explicit null means the object is gone */
+	if err := resourceRestAPIGraphQLRead(ctx, d, client); err != nil || d.Id() != "" {
+		t.Fatalf("resource_graphql_test.go: Expected a deleted object to be removed from state, but got '%v' and id '%s'", err, d.Id())
+	}
+}
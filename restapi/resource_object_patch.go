@@ -0,0 +1,333 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceRestAPIObjectPatch() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIObjectPatchCreate(ctx, data, i))
+		},
+		ReadContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIObjectPatchRead(ctx, data, i))
+		},
+		UpdateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIObjectPatchUpdate(ctx, data, i))
+		},
+		DeleteContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPIObjectPatchDelete(ctx, data, i))
+		},
+
+		Description: "Sets some fields of an existing object on the API server that is owned by something else, such as a label on an object another system creates and deletes. The original values of the fields are captured when they are first set, and put back when the fields are removed from `data` or the resource is destroyed.",
+
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			/* Fields added to data have their original values captured */
+			if d.HasChange("data") && d.Id() != "" {
+				return d.SetNewComputed("original_data")
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider that represents objects of this type on the API server.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"object_id": {
+				Type:        schema.TypeString,
+				Description: "The id of the object to set the fields of. The object is read and updated at `path/{id}`.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"data": {
+				Type:             schema.TypeString,
+				Description:      "A JSON object of the fields to set on the object. Nested objects only set the fields they contain, and a field set to null is removed from the object.",
+				Required:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentData,
+			},
+			"update_method": {
+				Type:         schema.TypeString,
+				Description:  "The HTTP method used to set the fields. With `PATCH`, only the fields are sent, as a JSON merge patch. With any other method, such as `PUT`, the object is read and sent back whole with the fields changed. Default: PATCH",
+				Optional:     true,
+				Default:      "PATCH",
+				ValidateFunc: validateHTTPMethod,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"original_data": {
+				Type:        schema.TypeString,
+				Description: "A JSON object of the values the fields of `data` had before they were first set, with null for the fields the object did not have. These are sent back when the resource is destroyed.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+func decodeJSONObject(attr string, value string) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	if value == "" {
+		return obj, nil
+	}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON object: %v", attr, err)
+	}
+	return obj, nil
+}
+
+/*
+Reads the object that the fields are set on. A nil object with no
+
+	error means the object does not exist (any more).
+*/
+func readPatchedObject(ctx context.Context, client *APIClient, path string) (map[string]interface{}, error) {
+	resp, err := client.sendRequestWithResponse(ctx, "GET", path, "", nil)
+	if resp.statusCode == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONObject(fmt.Sprintf("the object at '%s'", path), resp.body)
+}
+
+/*
+Sends fields to the object as a JSON merge patch with PATCH, or merged
+
+	into the whole object with any other update_method.
+*/
+func sendObjectPatch(ctx context.Context, d *schema.ResourceData, client *APIClient, object map[string]interface{}, fields map[string]interface{}) error {
+	path := d.Id()
+	method := d.Get("update_method").(string)
+	body := fields
+	if method != "PATCH" {
+		body = applyMergePatch(object, fields)
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if d.Get("debug").(bool) {
+		log.Printf("resource_object_patch.go: Sending %s %s: %s", method, path, string(b))
+	}
+	_, err = client.sendRequest(ctx, method, path, string(b))
+	return err
+}
+
+func resourceRestAPIObjectPatchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	path := strings.TrimSuffix(d.Get("path").(string), "/") + "/" + d.Get("object_id").(string)
+
+	fields, err := decodeJSONObject("data", d.Get("data").(string))
+	if err != nil {
+		return err
+	}
+
+	object, err := readPatchedObject(ctx, client, path)
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		return fmt.Errorf("there is no object at '%s' to set the fields of", path)
+	}
+
+	original, _ := json.Marshal(captureFields(object, fields, nil))
+	d.SetId(path)
+	if err := sendObjectPatch(ctx, d, client, object, fields); err != nil {
+		d.SetId("")
+		return err
+	}
+	d.Set("original_data", string(original))
+
+	return resourceRestAPIObjectPatchRead(ctx, d, meta)
+}
+
+func resourceRestAPIObjectPatchRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+
+	object, err := readPatchedObject(ctx, client, d.Id())
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		log.Printf("resource_object_patch.go: Object '%s' not found. Removing from state.", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	/* Only the fields this resource sets are compared, to show drift */
+	fields, err := decodeJSONObject("data", d.Get("data").(string))
+	if err != nil {
+		return err
+	}
+	current, _ := json.Marshal(captureFields(object, fields, nil))
+	if !suppressEquivalentData("data", d.Get("data").(string), string(current), d) {
+		if d.Get("debug").(bool) {
+			log.Printf("resource_object_patch.go: The fields of '%s' were changed on the API server: %s", d.Id(), string(current))
+		}
+		d.Set("data", string(current))
+	}
+	return nil
+}
+
+func resourceRestAPIObjectPatchUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+
+	newFields, err := decodeJSONObject("data", d.Get("data").(string))
+	if err != nil {
+		return err
+	}
+	o, _ := d.GetChange("original_data")
+	original, err := decodeJSONObject("original_data", o.(string))
+	if err != nil {
+		return err
+	}
+
+	object, err := readPatchedObject(ctx, client, d.Id())
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		return fmt.Errorf("there is no object at '%s' to set the fields of", d.Id())
+	}
+
+	/* Fields dropped from data are put back the way they were */
+	reverted := droppedFields(original, newFields)
+	if len(reverted) > 0 && d.Get("debug").(bool) {
+		log.Printf("resource_object_patch.go: Reverting the fields of '%s' that are no longer set: %v", d.Id(), reverted)
+	}
+
+	newOriginal, _ := json.Marshal(captureFields(object, newFields, original))
+	if err := sendObjectPatch(ctx, d, client, object, mergeFields(reverted, newFields)); err != nil {
+		return err
+	}
+	d.Set("original_data", string(newOriginal))
+
+	return resourceRestAPIObjectPatchRead(ctx, d, meta)
+}
+
+func resourceRestAPIObjectPatchDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+
+	original, err := decodeJSONObject("original_data", d.Get("original_data").(string))
+	if err != nil {
+		return err
+	}
+
+	object, err := readPatchedObject(ctx, client, d.Id())
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		log.Printf("resource_object_patch.go: Object '%s' is already gone. Nothing to revert.", d.Id())
+		return nil
+	}
+
+	return sendObjectPatch(ctx, d, client, object, original)
+}
+
+/*
+Captures the values that object has at the fields, so that sending
+
+	them back as a JSON merge patch reverts the fields. Fields that the
+	object lacks are captured as null, which removes them again. Fields
+	in captured, which were captured earlier, keep their earlier value.
+*/
+func captureFields(object map[string]interface{}, fields map[string]interface{}, captured map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range fields {
+		sub, isMap := v.(map[string]interface{})
+		if prev, ok := captured[k]; ok {
+			prevMap, prevIsMap := prev.(map[string]interface{})
+			if !isMap || !prevIsMap {
+				result[k] = prev
+				continue
+			}
+			current, _ := object[k].(map[string]interface{})
+			result[k] = captureFields(current, sub, prevMap)
+			continue
+		}
+
+		if current, ok := object[k].(map[string]interface{}); ok && isMap {
+			result[k] = captureFields(current, sub, nil)
+		} else {
+			result[k] = object[k]
+		}
+	}
+	return result
+}
+
+/* The parts of captured that are not among the fields */
+func droppedFields(captured map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range captured {
+		field, ok := fields[k]
+		if !ok {
+			result[k] = v
+			continue
+		}
+		prevMap, prevIsMap := v.(map[string]interface{})
+		sub, isMap := field.(map[string]interface{})
+		if prevIsMap && isMap {
+			if dropped := droppedFields(prevMap, sub); len(dropped) > 0 {
+				result[k] = dropped
+			}
+		}
+	}
+	return result
+}
+
+/* Merges fields into base, keeping nulls so that they still remove fields when sent */
+func mergeFields(base map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range fields {
+		baseMap, baseIsMap := result[k].(map[string]interface{})
+		sub, isMap := v.(map[string]interface{})
+		if baseIsMap && isMap {
+			result[k] = mergeFields(baseMap, sub)
+		} else {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+/* Applies a JSON merge patch (RFC 7386) to a copy of target */
+func applyMergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range target {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			current, _ := result[k].(map[string]interface{})
+			result[k] = applyMergePatch(current, sub)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceRestAPIObjectPatch(t *testing.T) {
+	var mutex sync.Mutex
+	object := map[string]interface{}{
+		"id":     "1",
+		"name":   "web",
+		"owner":  "platform",
+		"labels": map[string]interface{}{"env": "prod"},
+	}
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/objects/1", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Method == "PATCH" {
+			body, _ := io.ReadAll(r.Body)
+			patch := make(map[string]interface{})
+			json.Unmarshal(body, &patch)
+			object = applyMergePatch(object, patch)
+		}
+		b, _ := json.Marshal(object)
+		w.Write(b)
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:       svr.URL,
+		headers:   make(map[string]string),
+		timeout:   2,
+		rateLimit: 10,
+	})
+
+	expect := func(step string, expected map[string]interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !reflect.DeepEqual(object, expected) {
+			t.Fatalf("resource_object_patch_test.go: %s: expected %v but the object is %v", step, expected, object)
+		}
+	}
+
+	r := resourceRestAPIObjectPatch()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"path":      "/api/objects",
+		"object_id": "1",
+		"data":      `{ "owner": "team-a", "labels": { "team": "a" } }`,
+	})
+	if diags := r.CreateContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_object_patch_test.go: Failed to create: %v", diags)
+	}
+	expect("create", map[string]interface{}{
+		"id":     "1",
+		"name":   "web",
+		"owner":  "team-a",
+		"labels": map[string]interface{}{"env": "prod", "team": "a"},
+	})
+	if original := d.Get("original_data"); original != `{"labels":{"team":null},"owner":"platform"}` {
+		t.Fatalf("resource_object_patch_test.go: Unexpected original_data: %v", original)
+	}
+
+	/* Drop owner from data and set name instead */
+	state := d.State()
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"path":      "/api/objects",
+		"object_id": "1",
+		"data":      `{ "name": "www", "labels": { "team": "a" } }`,
+	}), client)
+	if err != nil {
+		t.Fatalf("resource_object_patch_test.go: Failed to diff: %v", err)
+	}
+	state, diags := r.Apply(context.Background(), state, diff, client)
+	if diags.HasError() {
+		t.Fatalf("resource_object_patch_test.go: Failed to update: %v", diags)
+	}
+	expect("update", map[string]interface{}{
+		"id":     "1",
+		"name":   "www",
+		"owner":  "platform",
+		"labels": map[string]interface{}{"env": "prod", "team": "a"},
+	})
+
+	d = r.Data(state)
+	if diags := r.DeleteContext(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("resource_object_patch_test.go: Failed to delete: %v", diags)
+	}
+	expect("delete", map[string]interface{}{
+		"id":     "1",
+		"name":   "web",
+		"owner":  "platform",
+		"labels": map[string]interface{}{"env": "prod"},
+	})
+}
@@ -0,0 +1,244 @@
+package restapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceRestAPIToken() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPITokenCreate(ctx, data, i))
+		},
+		ReadContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return nil
+		},
+		UpdateContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPITokenUpdate(ctx, data, i))
+		},
+		DeleteContext: func(ctx context.Context, data *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return diag.FromErr(resourceRestAPITokenDelete(ctx, data, i))
+		},
+
+		Description: "Creates an API token or key through an endpoint of the API server, rotates it after `rotation_interval` or when `rotation_triggers` change, and revokes it when it is destroyed or rotated. Rotation revokes the old token before the new one is created unless the resource has `create_before_destroy` set in its `lifecycle`.",
+
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			if d.Id() == "" {
+				return nil
+			}
+
+			rotateAt := d.Get("rotate_at").(string)
+			if d.HasChange("rotation_interval") {
+				rotateAt = tokenRotateAt(d.Get("created_at").(string), d.Get("rotation_interval").(int))
+				if err := d.SetNew("rotate_at", rotateAt); err != nil {
+					return err
+				}
+			}
+
+			/* The schedule can only be checked when planning, so a token
+			   is rotated by the first apply after it is due */
+			if due, err := time.Parse(time.RFC3339, rotateAt); err == nil && !time.Now().Before(due) {
+				log.Printf("resource_token.go: The token '%s' was due for rotation at %s. Replacing it.\n", d.Id(), rotateAt)
+				if err := d.SetNewComputed("rotate_at"); err != nil {
+					return err
+				}
+				return d.ForceNew("rotate_at")
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The API path on top of the base URL set in the provider to send the request that creates the token to.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"data": {
+				Type:         schema.TypeString,
+				Description:  "The JSON body of the request that creates the token, such as its name and scopes. By default, an empty JSON object is sent.",
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"create_method": {
+				Type:         schema.TypeString,
+				Description:  "The HTTP method of the request that creates the token. Default: POST",
+				Optional:     true,
+				Default:      "POST",
+				ForceNew:     true,
+				ValidateFunc: validateHTTPMethod,
+			},
+			"token_key": {
+				Type:        schema.TypeString,
+				Description: "The '/'-delimited path to the token in the response, such as `token` or `data/key`.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"id_attribute": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `id_attribute` set on the provider. The path to the id of the token in the response, with keys separated by '/' or dots. If the response has no id, the id is the SHA-256 hash of the token.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"revoke": {
+				Type:        schema.TypeBool,
+				Description: "Whether to revoke the token when it is destroyed or rotated. If false, the token is only removed from the state. Default: true",
+				Optional:    true,
+				Default:     true,
+			},
+			"revoke_path": {
+				Type:        schema.TypeString,
+				Description: "Defaults to `path/{id}`. The API path to send the request that revokes the token to. The strings `{id}` and `{token}` are replaced with the id and the token.",
+				Optional:    true,
+			},
+			"revoke_method": {
+				Type:         schema.TypeString,
+				Description:  "The HTTP method of the request that revokes the token. Default: DELETE",
+				Optional:     true,
+				Default:      "DELETE",
+				ValidateFunc: validateHTTPMethod,
+			},
+			"revoke_data": {
+				Type:        schema.TypeString,
+				Description: "The body of the request that revokes the token, for APIs that take the token to revoke in the body. The strings `{id}` and `{token}` are replaced with the id and the token. By default, no body is sent.",
+				Optional:    true,
+			},
+			"rotation_interval": {
+				Type:         schema.TypeInt,
+				Description:  "The number of seconds after which the token is rotated, such as `2592000` for 30 days. The token is rotated by the first apply after it is due. By default, the token is never rotated on a schedule.",
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"rotation_triggers": {
+				Type:        schema.TypeMap,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that rotate the token when they change, such as the version of the service that uses it.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"debug": {
+				Type:        schema.TypeBool,
+				Description: "Whether to emit verbose debug output while working with the API object on the server.",
+				Optional:    true,
+			},
+			"token": {
+				Type:        schema.TypeString,
+				Description: "The token.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"api_response": {
+				Type:        schema.TypeString,
+				Description: "The raw body of the response to the request that created the token, such as to read its expiry with `jsondecode()`.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"created_at": {
+				Type:        schema.TypeString,
+				Description: "When the token was created, in RFC 3339 format.",
+				Computed:    true,
+			},
+			"rotate_at": {
+				Type:        schema.TypeString,
+				Description: "When the token is due for rotation, in RFC 3339 format, or an empty string if it is not rotated on a schedule.",
+				Computed:    true,
+			},
+		}, /* End schema */
+
+	}
+}
+
+/* When a token created at createdAt is due for rotation */
+func tokenRotateAt(createdAt string, interval int) string {
+	created, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil || interval <= 0 {
+		return ""
+	}
+	return created.Add(time.Duration(interval) * time.Second).Format(time.RFC3339)
+}
+
+/* Replaces {id} and {token} in the path or body of the request that revokes the token */
+func expandTokenTemplate(template string, id string, token string) string {
+	return strings.NewReplacer("{id}", id, "{token}", token).Replace(template)
+}
+
+func resourceRestAPITokenCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*APIClient)
+	path := d.Get("path").(string)
+	debug := d.Get("debug").(bool)
+
+	data := d.Get("data").(string)
+	if data == "" {
+		data = "{}"
+	}
+	resultString, err := client.sendRequest(ctx, d.Get("create_method").(string), path, data)
+	if err != nil {
+		return err
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(resultString), &result); err != nil {
+		return fmt.Errorf("resource_token.go: unable to parse the response of '%s': %v", path, err)
+	}
+	token, err := GetStringAtKey(result, d.Get("token_key").(string), debug)
+	if err != nil || token == "" {
+		return fmt.Errorf("resource_token.go: unable to find the token in the response of '%s': %v", path, err)
+	}
+
+	idAttribute := d.Get("id_attribute").(string)
+	if idAttribute == "" {
+		idAttribute = client.idAttribute
+	}
+	id, err := getStringAtAttributePath(result, idAttribute, debug)
+	if err != nil || id == "" {
+		id = fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+	}
+	if debug {
+		log.Printf("resource_token.go: Created the token '%s' at '%s'", id, path)
+	}
+
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	d.SetId(id)
+	d.Set("token", token)
+	d.Set("api_response", resultString)
+	d.Set("created_at", createdAt)
+	d.Set("rotate_at", tokenRotateAt(createdAt, d.Get("rotation_interval").(int)))
+	return nil
+}
+
+func resourceRestAPITokenUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	d.Set("rotate_at", tokenRotateAt(d.Get("created_at").(string), d.Get("rotation_interval").(int)))
+	return nil
+}
+
+func resourceRestAPITokenDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	if !d.Get("revoke").(bool) {
+		log.Printf("resource_token.go: revoke is false. Only removing the token '%s' from state.", d.Id())
+		return nil
+	}
+
+	client := meta.(*APIClient)
+	token := d.Get("token").(string)
+	revokePath := d.Get("revoke_path").(string)
+	if revokePath == "" {
+		revokePath = d.Get("path").(string) + "/{id}"
+	}
+	revokePath = expandTokenTemplate(revokePath, d.Id(), token)
+
+	resp, err := client.sendRequestWithResponse(ctx, d.Get("revoke_method").(string), revokePath, expandTokenTemplate(d.Get("revoke_data").(string), d.Id(), token), nil)
+	if resp.statusCode == 404 {
+		log.Printf("resource_token.go: The token '%s' is already gone.", d.Id())
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceRestAPIToken(t *testing.T) {
+	var mutex sync.Mutex
+	issued := 0
+	revoked := []string{}
+
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc("/api/tokens", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		issued++
+		w.Write([]byte(fmt.Sprintf(`{ "meta": { "id": "%d" }, "secret": { "value": "s3cr3t-%d" } }`, issued, issued)))
+	})
+	serverMux.HandleFunc("/api/tokens/", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Method == "DELETE" {
+			revoked = append(revoked, strings.TrimPrefix(r.URL.Path, "/api/tokens/"))
+		}
+	})
+	svr := newTestServer(t, serverMux)
+
+	client, _ := NewAPIClient(&apiClientOpt{
+		uri:         svr.URL,
+		headers:     make(map[string]string),
+		timeout:     2,
+		rateLimit:   10,
+		idAttribute: "id",
+	})
+
+	r := resourceRestAPIToken()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"path":              "/api/tokens",
+		"token_key":         "secret/value",
+		"id_attribute":      "meta.id",
+		"rotation_interval": 3600,
+	})
+	diff, err := r.Diff(context.Background(), nil, config, client)
+	if err != nil {
+		t.Fatalf("resource_token_test.go: Failed to diff: %v", err)
+	}
+	state, diags := r.Apply(context.Background(), nil, diff, client)
+	if diags.HasError() {
+		t.Fatalf("resource_token_test.go: Failed to create the token: %v", diags)
+	}
+	if state.ID != "1" || state.Attributes["token"] != "s3cr3t-1" || state.Attributes["rotate_at"] == "" {
+		t.Fatalf("resource_token_test.go: Unexpected state after creating the token: %s %v", state.ID, state.Attributes)
+	}
+
+	diff, err = r.Diff(context.Background(), state, config, client)
+	if err != nil || (diff != nil && diff.RequiresNew()) {
+		t.Fatalf("resource_token_test.go: Expected the token not to be rotated before it is due: %v", err)
+	}
+
+	/* Rotate the token as if it was due */
+	state.Attributes["rotate_at"] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	diff, err = r.Diff(context.Background(), state, config, client)
+	if err != nil || diff == nil || !diff.RequiresNew() {
+		t.Fatalf("resource_token_test.go: Expected a token that is due to be rotated: %v", err)
+	}
+
+	if _, diags := r.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, client); diags.HasError() {
+		t.Fatalf("resource_token_test.go: Failed to revoke the token: %v", diags)
+	}
+	if !reflect.DeepEqual(revoked, []string{"1"}) {
+		t.Fatalf("resource_token_test.go: Expected token '1' to be revoked but got %v", revoked)
+	}
+}